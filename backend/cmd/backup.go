@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"todolist/database"
+)
+
+func runBackup(args []string) error {
+	fs, flags := newFlagSet("backup", "backup [-out <文件>]")
+	out := fs.String("out", "", "备份文件路径（默认 todo-backup-时间戳.db）")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	cfg, err := connect(flags)
+	if err != nil {
+		return err
+	}
+
+	path := *out
+	if path == "" {
+		path = fmt.Sprintf("todo-backup-%s.db", time.Now().Format("20060102-150405"))
+	}
+	if err := database.Backup(cfg.Database.Driver, path); err != nil {
+		return err
+	}
+	fmt.Printf("已备份到 %s\n", path)
+	return nil
+}
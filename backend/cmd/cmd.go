@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"todolist/config"
+	"todolist/database"
+)
+
+// command 一个子命令
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"serve", "启动HTTP服务（默认）", runServe},
+		{"migrate", "数据库迁移：migrate up|down|status", runMigrate},
+		{"user", "用户管理：user create|reset-password|activate", runUser},
+		{"backup", "备份数据库", runBackup},
+	}
+}
+
+// errUsage 表示参数错误，已输出用法说明
+var errUsage = errors.New("usage")
+
+// Execute 解析并执行子命令，返回进程退出码
+func Execute(args []string) int {
+	// 未指定子命令或以参数开头时，兼容旧的启动方式，默认执行 serve
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	if name == "help" {
+		printUsage(os.Stdout)
+		return 0
+	}
+
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
+		if err := c.run(args); err != nil {
+			if errors.Is(err, errUsage) {
+				return 2
+			}
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "未知命令: %s\n\n", name)
+	printUsage(os.Stderr)
+	return 2
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "用法: main <命令> [参数]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "命令:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.usage)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "使用 main <命令> -h 查看命令参数")
+}
+
+// newFlagSet 创建带有配置参数的 FlagSet
+func newFlagSet(name, usage string) (*flag.FlagSet, *config.Flags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags := config.BindFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "用法: main %s\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs, flags
+}
+
+// parse 解析参数，-h 和参数错误统一返回 errUsage
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	return nil
+}
+
+// connect 加载配置并连接数据库（不检查迁移状态）
+func connect(flags *config.Flags) (*config.Config, error) {
+	cfg, err := config.Load(flags)
+	if err != nil {
+		return nil, fmt.Errorf("加载配置失败: %w", err)
+	}
+	if err := database.Connect(cfg.Database); err != nil {
+		return nil, fmt.Errorf("连接数据库失败: %w", err)
+	}
+	return cfg, nil
+}
+
+// readPassword 未通过参数提供密码时从标准输入读取一行
+func readPassword(password string) (string, error) {
+	if password != "" {
+		return password, nil
+	}
+	fmt.Fprint(os.Stderr, "请输入密码: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	password = strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("密码不能为空")
+	}
+	return password, nil
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"todolist/database"
+	"todolist/migrations"
+)
+
+// runMigrate 处理 migrate up|down|status 子命令
+func runMigrate(args []string) error {
+	fs, flags := newFlagSet("migrate", "migrate [参数] up|down|status")
+	target := fs.Int("to", 0, "up: 执行到指定版本（默认全部）")
+	steps := fs.Int("steps", 1, "down: 回滚的迁移数量")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	if _, err := connect(flags); err != nil {
+		return err
+	}
+
+	switch fs.Arg(0) {
+	case "up":
+		applied, err := migrations.Up(database.DB, *target)
+		for _, m := range applied {
+			fmt.Printf("已执行 %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("没有需要执行的迁移")
+		}
+	case "down":
+		rolledBack, err := migrations.Down(database.DB, *steps)
+		for _, m := range rolledBack {
+			fmt.Printf("已回滚 %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(rolledBack) == 0 {
+			fmt.Println("没有可回滚的迁移")
+		}
+	case "status":
+		list, err := migrations.StatusList(database.DB)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range list {
+			status, appliedAt := "pending", ""
+			if s.Applied {
+				status = "applied"
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, status, appliedAt)
+		}
+		w.Flush()
+	default:
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"todolist/config"
+	"todolist/database"
+	"todolist/handlers"
+	"todolist/middleware"
+	"todolist/router"
+	"todolist/server"
+)
+
+func runServe(args []string) error {
+	fs, flags := newFlagSet("serve", "serve [参数]")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	// 加载配置：默认值 -> 配置文件 -> 环境变量 -> 命令行参数
+	cfg, err := config.Load(flags)
+	if err != nil {
+		return err
+	}
+
+	// 初始化数据库
+	if err := database.InitDB(cfg.Database); err != nil {
+		return err
+	}
+
+	middleware.Setup(cfg)
+	if err := handlers.Setup(cfg); err != nil {
+		return err
+	}
+
+	// 管理员账号需通过 user create -admin 创建，或显式开启 bootstrap_admin 在首次启动时生成
+	if cfg.BootstrapAdmin {
+		password, err := database.CreateDefaultAdmin()
+		if err != nil {
+			return err
+		}
+		if password != "" {
+			log.Printf("已创建管理员账号 admin，初始密码: %s（只显示这一次，首次登录后必须修改）", password)
+		}
+	} else if ok, err := database.HasAdmin(); err != nil {
+		return err
+	} else if !ok {
+		log.Println("警告: 当前没有管理员账号，请执行 main user create -admin 创建，或开启 bootstrap_admin")
+	}
+	if legacy, err := database.ExpireLegacyAdminPassword(); err != nil {
+		return err
+	} else if legacy {
+		log.Println("警告: 管理员账号 admin 仍在使用默认密码 123456，请立即修改")
+	}
+
+	srv := server.New(cfg.Server, router.New())
+	srv.OnShutdown("database", func(ctx context.Context) error {
+		return database.Close()
+	})
+
+	// 定期删除注销冷静期已过的账号，需在数据库关闭前停止
+	stopPurger := startAccountPurger(cfg.Account.PurgeInterval.Std())
+	srv.OnShutdown("account purger", func(ctx context.Context) error {
+		stopPurger()
+		return nil
+	})
+
+	// 开启提前生成时定期为重复任务生成之后的重复
+	if cfg.Recurrence.Lookahead > 0 {
+		stopScheduler := startRecurrenceScheduler(cfg.Recurrence.Interval.Std())
+		srv.OnShutdown("recurrence scheduler", func(ctx context.Context) error {
+			stopScheduler()
+			return nil
+		})
+	}
+
+	// 定期发送到期的提醒，服务重启后未发送的提醒会继续发送
+	stopReminders := startReminderScheduler(cfg.Notify.PollInterval.Std())
+	srv.OnShutdown("reminder scheduler", func(ctx context.Context) error {
+		stopReminders()
+		return nil
+	})
+
+	// 每隔一个统计窗口清理过期的登录失败记录，避免数据库存储中的记录无限增长
+	stopThrottlePruner := startThrottlePruner(cfg.Login.Window.Std())
+	srv.OnShutdown("login throttle pruner", func(ctx context.Context) error {
+		stopThrottlePruner()
+		return nil
+	})
+
+	// 启动服务器，收到 SIGINT/SIGTERM 后优雅退出
+	return srv.Run()
+}
+
+// startAccountPurger 启动后台任务，立即执行一次并按 interval 定期删除注销冷静期已过的账号，返回停止函数
+func startAccountPurger(interval time.Duration) func() {
+	return startPeriodic(interval, func() {
+		if n, err := handlers.PurgeDeletedAccounts(); err != nil {
+			log.Printf("删除已注销账号失败: %v", err)
+		} else if n > 0 {
+			log.Printf("已删除 %d 个注销冷静期已过的账号", n)
+		}
+	})
+}
+
+// startRecurrenceScheduler 启动后台任务，立即执行一次并按 interval 定期提前生成重复任务，返回停止函数
+func startRecurrenceScheduler(interval time.Duration) func() {
+	return startPeriodic(interval, func() {
+		if n, err := handlers.AdvanceRecurrences(); err != nil {
+			log.Printf("生成重复任务失败: %v", err)
+		} else if n > 0 {
+			log.Printf("已生成 %d 个重复任务", n)
+		}
+	})
+}
+
+// startReminderScheduler 启动后台任务，立即执行一次并按 interval 定期发送到期的提醒，返回停止函数
+func startReminderScheduler(interval time.Duration) func() {
+	return startPeriodic(interval, func() {
+		if n, err := handlers.DeliverDueReminders(); err != nil {
+			log.Printf("发送提醒失败: %v", err)
+		} else if n > 0 {
+			log.Printf("已处理 %d 个到期提醒", n)
+		}
+	})
+}
+
+// startThrottlePruner 启动后台任务，立即执行一次并按 interval 定期删除过期的登录失败记录，返回停止函数
+func startThrottlePruner(interval time.Duration) func() {
+	return startPeriodic(interval, func() {
+		if n, err := handlers.PruneLoginThrottles(); err != nil {
+			log.Printf("清理登录失败记录失败: %v", err)
+		} else if n > 0 {
+			log.Printf("已清理 %d 条过期的登录失败记录", n)
+		}
+	})
+}
+
+// startPeriodic 在后台立即执行一次 fn，之后每隔 interval 执行一次，返回的停止函数会等待正在执行的 fn 结束
+func startPeriodic(interval time.Duration, fn func()) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			fn()
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
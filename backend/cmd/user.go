@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+
+	"todolist/config"
+	"todolist/database"
+	"todolist/handlers"
+	"todolist/models"
+)
+
+// runUser 处理 user create|reset-password|activate 子命令
+func runUser(args []string) error {
+	if len(args) == 0 {
+		fmt.Println("用法: main user create|reset-password|activate [参数]")
+		return errUsage
+	}
+
+	switch args[0] {
+	case "create":
+		return runUserCreate(args[1:])
+	case "reset-password":
+		return runUserResetPassword(args[1:])
+	case "activate":
+		return runUserActivate(args[1:])
+	default:
+		fmt.Println("用法: main user create|reset-password|activate [参数]")
+		return errUsage
+	}
+}
+
+func runUserCreate(args []string) error {
+	fs, flags := newFlagSet("user create", "user create -username <用户名> [-password <密码>] [-admin | -role <角色>] [-must-change]")
+	username := fs.String("username", "", "用户名")
+	password := fs.String("password", "", "密码（为空时从标准输入读取）")
+	admin := fs.Bool("admin", false, "创建管理员账号")
+	roleName := fs.String("role", "", "角色名称，默认 user")
+	mustChange := fs.Bool("must-change", false, "首次登录后必须修改密码")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *username == "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	cfg, err := connect(flags)
+	if err != nil {
+		return err
+	}
+	pw, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+	if err := checkPassword(cfg, pw, *username); err != nil {
+		return err
+	}
+
+	role := models.RoleUser
+	if *admin {
+		role = models.RoleAdmin
+	} else if *roleName != "" {
+		if _, err := database.GetRole(*roleName); err != nil {
+			return err
+		}
+		role = *roleName
+	}
+	user, err := database.CreateUser(*username, pw, role, *mustChange)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("已创建用户 %s（id=%d, role=%s）\n", user.Username, user.ID, user.Role)
+	return nil
+}
+
+func runUserResetPassword(args []string) error {
+	fs, flags := newFlagSet("user reset-password", "user reset-password -username <用户名> [-password <密码>] [-must-change]")
+	username := fs.String("username", "", "用户名")
+	password := fs.String("password", "", "新密码（为空时从标准输入读取）")
+	mustChange := fs.Bool("must-change", false, "下次登录后必须修改密码")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *username == "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	cfg, err := connect(flags)
+	if err != nil {
+		return err
+	}
+	pw, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+	if err := checkPassword(cfg, pw, *username); err != nil {
+		return err
+	}
+
+	if err := database.ResetPassword(*username, pw, cfg.Password.HistorySize, *mustChange); err != nil {
+		return err
+	}
+	fmt.Printf("已重置用户 %s 的密码\n", *username)
+	return nil
+}
+
+func runUserActivate(args []string) error {
+	fs, flags := newFlagSet("user activate", "user activate -username <用户名>")
+	username := fs.String("username", "", "用户名")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *username == "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	if _, err := connect(flags); err != nil {
+		return err
+	}
+	if err := database.ActivateUser(*username); err != nil {
+		return err
+	}
+	fmt.Printf("已激活用户 %s\n", *username)
+	return nil
+}
+
+// checkPassword 按配置的密码策略检查密码
+func checkPassword(cfg *config.Config, password, username string) error {
+	policy, err := handlers.NewPasswordPolicy(cfg.Password)
+	if err != nil {
+		return err
+	}
+	return policy.Check(password, username)
+}
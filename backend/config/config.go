@@ -0,0 +1,652 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	EnvDev     = "dev"
+	EnvStaging = "staging"
+	EnvProd    = "prod"
+
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+
+	// 默认JWT密钥，仅允许在开发环境中使用
+	defaultJWTSecret = "your_jwt_secret_key"
+
+	// 注册模式
+	RegistrationOpen       = "open"        // 注册后直接激活
+	RegistrationApproval   = "approval"    // 注册后需管理员激活
+	RegistrationInviteOnly = "invite_only" // 必须使用邀请码注册
+	RegistrationClosed     = "closed"      // 关闭注册
+)
+
+// Config 应用配置
+type Config struct {
+	Env          string             `yaml:"env" toml:"env"`
+	Server       ServerConfig       `yaml:"server" toml:"server"`
+	Database     DatabaseConfig     `yaml:"database" toml:"database"`
+	JWT          JWTConfig          `yaml:"jwt" toml:"jwt"`
+	Login        LoginConfig        `yaml:"login" toml:"login"`
+	MFA          MFAConfig          `yaml:"mfa" toml:"mfa"`
+	Registration RegistrationConfig `yaml:"registration" toml:"registration"`
+	Password     PasswordConfig     `yaml:"password" toml:"password"`
+	Mail         MailConfig         `yaml:"mail" toml:"mail"`
+	Storage      StorageConfig      `yaml:"storage" toml:"storage"`
+	Account      AccountConfig      `yaml:"account" toml:"account"`
+	Recurrence   RecurrenceConfig   `yaml:"recurrence" toml:"recurrence"`
+	Notify       NotifyConfig       `yaml:"notify" toml:"notify"`
+	OIDC         OIDCConfig         `yaml:"oidc" toml:"oidc"`
+	Dify         DifyConfig         `yaml:"dify" toml:"dify"`
+
+	// BootstrapAdmin 启动时没有管理员账号则创建 admin 账号，随机生成的初始密码只在日志中输出一次
+	BootstrapAdmin bool `yaml:"bootstrap_admin" toml:"bootstrap_admin"`
+}
+
+// ServerConfig HTTP服务配置
+type ServerConfig struct {
+	Addr              string   `yaml:"addr" toml:"addr"`
+	ReadTimeout       Duration `yaml:"read_timeout" toml:"read_timeout"`
+	ReadHeaderTimeout Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+	WriteTimeout      Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout       Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	// ShutdownTimeout 收到退出信号后等待处理中请求完成的最长时间
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+}
+
+// DatabaseConfig 数据库配置
+type DatabaseConfig struct {
+	Driver          string `yaml:"driver" toml:"driver"`
+	DSN             string `yaml:"dsn" toml:"dsn"`
+	MaxOpenConns    int    `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int    `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime int    `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"` // 秒
+	// AutoMigrate 启动时自动执行未执行的迁移，关闭时存在未执行迁移将拒绝启动
+	AutoMigrate bool `yaml:"auto_migrate" toml:"auto_migrate"`
+}
+
+// JWTConfig 认证令牌配置
+type JWTConfig struct {
+	Secret string `yaml:"secret" toml:"secret"`
+	// AccessTokenTTL 访问令牌有效期，应尽量短
+	AccessTokenTTL Duration `yaml:"access_token_ttl" toml:"access_token_ttl"`
+	// RefreshTokenTTL 刷新令牌有效期，每次刷新都会轮换并重新计时
+	RefreshTokenTTL Duration `yaml:"refresh_token_ttl" toml:"refresh_token_ttl"`
+	// ImpersonationTTL 管理员模拟登录令牌有效期，不可刷新
+	ImpersonationTTL Duration `yaml:"impersonation_ttl" toml:"impersonation_ttl"`
+}
+
+// LoginConfig 登录防暴力破解配置
+type LoginConfig struct {
+	// ThrottleStore 限流状态存储：memory（单实例）或 database（多实例共享）
+	ThrottleStore    string   `yaml:"throttle_store" toml:"throttle_store"`
+	Window           Duration `yaml:"window" toml:"window"`
+	IPMaxFailures    int      `yaml:"ip_max_failures" toml:"ip_max_failures"`
+	BackoffAfter     int      `yaml:"backoff_after" toml:"backoff_after"`
+	BackoffBase      Duration `yaml:"backoff_base" toml:"backoff_base"`
+	BackoffMax       Duration `yaml:"backoff_max" toml:"backoff_max"`
+	LockoutThreshold int      `yaml:"lockout_threshold" toml:"lockout_threshold"`
+	LockoutDuration  Duration `yaml:"lockout_duration" toml:"lockout_duration"`
+}
+
+// RegistrationConfig 用户注册策略
+type RegistrationConfig struct {
+	Mode string `yaml:"mode" toml:"mode"`
+	// AllowedDomains 审核模式下，邮箱属于这些域名的用户验证邮箱后自动激活
+	AllowedDomains []string `yaml:"allowed_domains" toml:"allowed_domains"`
+}
+
+// PasswordConfig 密码相关配置
+type PasswordConfig struct {
+	// ResetTokenTTL 找回密码链接的有效期
+	ResetTokenTTL Duration `yaml:"reset_token_ttl" toml:"reset_token_ttl"`
+	// ResetURL 前端重置密码页面地址，令牌以 ?token= 参数附加在后面
+	ResetURL string `yaml:"reset_url" toml:"reset_url"`
+
+	// 密码策略，在注册、修改、重置密码和命令行创建用户时检查
+	MinLength     int  `yaml:"min_length" toml:"min_length"`
+	RequireUpper  bool `yaml:"require_upper" toml:"require_upper"`
+	RequireLower  bool `yaml:"require_lower" toml:"require_lower"`
+	RequireDigit  bool `yaml:"require_digit" toml:"require_digit"`
+	RequireSymbol bool `yaml:"require_symbol" toml:"require_symbol"`
+	// DisallowUsername 密码中不能包含用户名
+	DisallowUsername bool `yaml:"disallow_username" toml:"disallow_username"`
+	// HistorySize 新密码不能与最近 N 次使用过的密码相同（含当前密码），0 表示不检查
+	HistorySize int `yaml:"history_size" toml:"history_size"`
+	// BreachFile 本地泄露密码库（SHA-1，k-anonymity 分片目录或排序文件），为空时不检查
+	BreachFile string `yaml:"breach_file" toml:"breach_file"`
+}
+
+// MailConfig 邮件发送配置
+type MailConfig struct {
+	// Driver 发送方式：log（输出到日志）/ file（写入 .eml 文件）/ smtp
+	Driver       string `yaml:"driver" toml:"driver"`
+	From         string `yaml:"from" toml:"from"`
+	Dir          string `yaml:"dir" toml:"dir"` // file 方式的输出目录
+	SMTPHost     string `yaml:"smtp_host" toml:"smtp_host"`
+	SMTPPort     int    `yaml:"smtp_port" toml:"smtp_port"`
+	SMTPUsername string `yaml:"smtp_username" toml:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password" toml:"smtp_password"`
+	// VerifyTokenTTL 邮箱验证链接的有效期
+	VerifyTokenTTL Duration `yaml:"verify_token_ttl" toml:"verify_token_ttl"`
+	// VerifyURL 前端验证邮箱页面地址，令牌以 ?token= 参数附加在后面
+	VerifyURL string `yaml:"verify_url" toml:"verify_url"`
+}
+
+// StorageConfig 上传文件存储配置
+type StorageConfig struct {
+	UploadDir      string `yaml:"upload_dir" toml:"upload_dir"`
+	AvatarMaxBytes int64  `yaml:"avatar_max_bytes" toml:"avatar_max_bytes"`
+}
+
+// AccountConfig 账号注销配置
+type AccountConfig struct {
+	// DeletionGracePeriod 用户申请注销后到彻底删除前的冷静期，期间可以撤销，0 表示立即删除
+	DeletionGracePeriod Duration `yaml:"deletion_grace_period" toml:"deletion_grace_period"`
+	// PurgeInterval 检查并删除冷静期已过账号的间隔
+	PurgeInterval Duration `yaml:"purge_interval" toml:"purge_interval"`
+}
+
+// RecurrenceConfig 重复任务配置
+type RecurrenceConfig struct {
+	// Lookahead 提前生成开始时间在这段时间之内的重复，0 表示只在上一次完成或删除后生成下一次
+	Lookahead Duration `yaml:"lookahead" toml:"lookahead"`
+	// Interval 后台提前生成重复的检查间隔，Lookahead 为 0 时不启动
+	Interval Duration `yaml:"interval" toml:"interval"`
+}
+
+// NotifyConfig 提醒和通知配置
+type NotifyConfig struct {
+	// PollInterval 后台检查到期提醒的间隔
+	PollInterval Duration `yaml:"poll_interval" toml:"poll_interval"`
+	// MaxDelay 到期后超过这段时间仍未发送的提醒（如服务停机期间到期的）不再发送
+	MaxDelay Duration `yaml:"max_delay" toml:"max_delay"`
+	// Timeout 发送单条通知（邮件、Webhook、ntfy）的超时时间
+	Timeout Duration `yaml:"timeout" toml:"timeout"`
+	// AllowPrivateWebhooks 允许 Webhook 地址指向回环或内网地址，只应在可信环境中开启
+	AllowPrivateWebhooks bool `yaml:"allow_private_webhooks" toml:"allow_private_webhooks"`
+	// NtfyServer ntfy 推送服务器地址，用户只能设置主题
+	NtfyServer string `yaml:"ntfy_server" toml:"ntfy_server"`
+	// NtfyToken 服务器需要认证时使用的访问令牌
+	NtfyToken string `yaml:"ntfy_token" toml:"ntfy_token"`
+}
+
+// MFAConfig 两步验证配置
+type MFAConfig struct {
+	// Issuer 显示在认证器App中的服务名称
+	Issuer string `yaml:"issuer" toml:"issuer"`
+}
+
+// OIDCConfig OpenID Connect 单点登录配置
+type OIDCConfig struct {
+	Enabled      bool     `yaml:"enabled" toml:"enabled"`
+	Issuer       string   `yaml:"issuer" toml:"issuer"`
+	ClientID     string   `yaml:"client_id" toml:"client_id"`
+	ClientSecret string   `yaml:"client_secret" toml:"client_secret"`
+	RedirectURL  string   `yaml:"redirect_url" toml:"redirect_url"` // 指向 /auth/oidc/callback 的完整地址
+	Scopes       []string `yaml:"scopes" toml:"scopes"`
+	// AutoProvision 首次登录且无法关联到已有账号时自动创建用户（仍需管理员激活）
+	AutoProvision bool `yaml:"auto_provision" toml:"auto_provision"`
+	// LinkByEmail 按已验证的邮箱关联已有账号
+	LinkByEmail bool `yaml:"link_by_email" toml:"link_by_email"`
+	// PostLoginRedirect 登录完成后跳转的前端地址，令牌通过 URL fragment 传递
+	PostLoginRedirect string `yaml:"post_login_redirect" toml:"post_login_redirect"`
+}
+
+// DifyConfig Dify AI服务配置
+type DifyConfig struct {
+	BaseURL string   `yaml:"base_url" toml:"base_url"`
+	APIKey  string   `yaml:"api_key" toml:"api_key"`
+	Timeout Duration `yaml:"timeout" toml:"timeout"`
+}
+
+// Flags 命令行参数，优先级高于配置文件和环境变量
+type Flags struct {
+	ConfigFile string
+	Env        string
+	Addr       string
+	DBDriver   string
+	DBDSN      string
+}
+
+// Default 返回默认配置
+func Default() *Config {
+	return &Config{
+		Env: EnvDev,
+		Server: ServerConfig{
+			Addr:              "0.0.0.0:8080",
+			ReadTimeout:       Duration(15 * time.Second),
+			ReadHeaderTimeout: Duration(5 * time.Second),
+			WriteTimeout:      Duration(90 * time.Second),
+			IdleTimeout:       Duration(120 * time.Second),
+			ShutdownTimeout:   Duration(20 * time.Second),
+		},
+		Database: DatabaseConfig{
+			Driver: DriverSQLite,
+			DSN:    "backend/data/todo.db",
+		},
+		JWT: JWTConfig{
+			Secret:          defaultJWTSecret,
+			AccessTokenTTL:  Duration(15 * time.Minute),
+			RefreshTokenTTL: Duration(30 * 24 * time.Hour),
+
+			ImpersonationTTL: Duration(15 * time.Minute),
+		},
+		Login: LoginConfig{
+			ThrottleStore:    "memory",
+			Window:           Duration(15 * time.Minute),
+			IPMaxFailures:    50,
+			BackoffAfter:     3,
+			BackoffBase:      Duration(time.Second),
+			BackoffMax:       Duration(time.Minute),
+			LockoutThreshold: 10,
+			LockoutDuration:  Duration(15 * time.Minute),
+		},
+		MFA: MFAConfig{
+			Issuer: "TodoList",
+		},
+		Registration: RegistrationConfig{
+			Mode: RegistrationApproval,
+		},
+		Password: PasswordConfig{
+			ResetTokenTTL: Duration(30 * time.Minute),
+			ResetURL:      "http://localhost:5173/reset-password",
+
+			MinLength:        8,
+			DisallowUsername: true,
+			HistorySize:      5,
+		},
+		Storage: StorageConfig{
+			UploadDir:      "backend/data/uploads",
+			AvatarMaxBytes: 2 << 20,
+		},
+		Account: AccountConfig{
+			DeletionGracePeriod: Duration(30 * 24 * time.Hour),
+			PurgeInterval:       Duration(time.Hour),
+		},
+		Recurrence: RecurrenceConfig{
+			Interval: Duration(time.Hour),
+		},
+		Notify: NotifyConfig{
+			PollInterval: Duration(30 * time.Second),
+			MaxDelay:     Duration(24 * time.Hour),
+			Timeout:      Duration(10 * time.Second),
+			NtfyServer:   "https://ntfy.sh",
+		},
+		Mail: MailConfig{
+			Driver:   "log",
+			From:     "TodoList <noreply@localhost>",
+			Dir:      "backend/data/mail",
+			SMTPPort: 587,
+
+			VerifyTokenTTL: Duration(24 * time.Hour),
+			VerifyURL:      "http://localhost:5173/verify-email",
+		},
+		OIDC: OIDCConfig{
+			Scopes:            []string{"openid", "profile", "email"},
+			PostLoginRedirect: "/",
+		},
+		Dify: DifyConfig{
+			BaseURL: "https://dify.frankgu.club:8888",
+			Timeout: Duration(60 * time.Second),
+		},
+	}
+}
+
+// BindFlags 在给定的FlagSet上注册配置相关的命令行参数
+func BindFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{}
+	fs.StringVar(&f.ConfigFile, "config", "", "配置文件路径（.yaml/.yml/.toml）")
+	fs.StringVar(&f.Env, "env", "", "运行环境（dev/staging/prod）")
+	fs.StringVar(&f.Addr, "addr", "", "HTTP监听地址")
+	fs.StringVar(&f.DBDriver, "db-driver", "", "数据库驱动")
+	fs.StringVar(&f.DBDSN, "db-dsn", "", "数据库连接串")
+	return f
+}
+
+// Load 按 默认值 -> 配置文件 -> 环境变量 -> 命令行参数 的顺序加载配置并校验
+func Load(f *Flags) (*Config, error) {
+	if f == nil {
+		f = &Flags{}
+	}
+	cfg := Default()
+
+	path := f.ConfigFile
+	if path == "" {
+		path = os.Getenv("TODOLIST_CONFIG")
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	cfg.applyFlags(f)
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile 根据扩展名解析YAML或TOML配置文件
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, c)
+	case ".toml":
+		err = toml.Unmarshal(data, c)
+	default:
+		return fmt.Errorf("不支持的配置文件格式: %s", path)
+	}
+	if err != nil {
+		return fmt.Errorf("解析配置文件失败: %w", err)
+	}
+	return nil
+}
+
+// applyEnv 使用 TODOLIST_ 前缀的环境变量覆盖配置，无法解析的值返回错误而不是静默使用默认值
+func (c *Config) applyEnv() error {
+	var errs []error
+	collect := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	setFromEnv(&c.Env, "TODOLIST_ENV")
+	collect(setBoolFromEnv(&c.BootstrapAdmin, "TODOLIST_BOOTSTRAP_ADMIN"))
+	setFromEnv(&c.Server.Addr, "TODOLIST_SERVER_ADDR")
+	collect(setDurationFromEnv(&c.Server.ReadTimeout, "TODOLIST_SERVER_READ_TIMEOUT"))
+	collect(setDurationFromEnv(&c.Server.ReadHeaderTimeout, "TODOLIST_SERVER_READ_HEADER_TIMEOUT"))
+	collect(setDurationFromEnv(&c.Server.WriteTimeout, "TODOLIST_SERVER_WRITE_TIMEOUT"))
+	collect(setDurationFromEnv(&c.Server.IdleTimeout, "TODOLIST_SERVER_IDLE_TIMEOUT"))
+	collect(setDurationFromEnv(&c.Server.ShutdownTimeout, "TODOLIST_SERVER_SHUTDOWN_TIMEOUT"))
+	setFromEnv(&c.Database.Driver, "TODOLIST_DB_DRIVER")
+	setFromEnv(&c.Database.DSN, "TODOLIST_DB_DSN")
+	collect(setBoolFromEnv(&c.Database.AutoMigrate, "TODOLIST_DB_AUTO_MIGRATE"))
+	setFromEnv(&c.JWT.Secret, "TODOLIST_JWT_SECRET")
+	collect(setDurationFromEnv(&c.JWT.AccessTokenTTL, "TODOLIST_JWT_ACCESS_TOKEN_TTL"))
+	collect(setDurationFromEnv(&c.JWT.ImpersonationTTL, "TODOLIST_JWT_IMPERSONATION_TTL"))
+	collect(setDurationFromEnv(&c.JWT.RefreshTokenTTL, "TODOLIST_JWT_REFRESH_TOKEN_TTL"))
+	setFromEnv(&c.Login.ThrottleStore, "TODOLIST_LOGIN_THROTTLE_STORE")
+	setFromEnv(&c.Registration.Mode, "TODOLIST_REGISTRATION_MODE")
+	setListFromEnv(&c.Registration.AllowedDomains, "TODOLIST_REGISTRATION_ALLOWED_DOMAINS")
+	collect(setDurationFromEnv(&c.Password.ResetTokenTTL, "TODOLIST_PASSWORD_RESET_TOKEN_TTL"))
+	setFromEnv(&c.Password.ResetURL, "TODOLIST_PASSWORD_RESET_URL")
+	collect(setIntFromEnv(&c.Password.MinLength, "TODOLIST_PASSWORD_MIN_LENGTH"))
+	collect(setIntFromEnv(&c.Password.HistorySize, "TODOLIST_PASSWORD_HISTORY_SIZE"))
+	setFromEnv(&c.Password.BreachFile, "TODOLIST_PASSWORD_BREACH_FILE")
+	setFromEnv(&c.Storage.UploadDir, "TODOLIST_STORAGE_UPLOAD_DIR")
+	collect(setDurationFromEnv(&c.Account.DeletionGracePeriod, "TODOLIST_ACCOUNT_DELETION_GRACE_PERIOD"))
+	collect(setDurationFromEnv(&c.Recurrence.Lookahead, "TODOLIST_RECURRENCE_LOOKAHEAD"))
+	collect(setDurationFromEnv(&c.Notify.PollInterval, "TODOLIST_NOTIFY_POLL_INTERVAL"))
+	collect(setBoolFromEnv(&c.Notify.AllowPrivateWebhooks, "TODOLIST_NOTIFY_ALLOW_PRIVATE_WEBHOOKS"))
+	setFromEnv(&c.Notify.NtfyServer, "TODOLIST_NOTIFY_NTFY_SERVER")
+	setFromEnv(&c.Notify.NtfyToken, "TODOLIST_NOTIFY_NTFY_TOKEN")
+	setFromEnv(&c.Mail.Driver, "TODOLIST_MAIL_DRIVER")
+	setFromEnv(&c.Mail.From, "TODOLIST_MAIL_FROM")
+	setFromEnv(&c.Mail.Dir, "TODOLIST_MAIL_DIR")
+	setFromEnv(&c.Mail.SMTPHost, "TODOLIST_MAIL_SMTP_HOST")
+	collect(setIntFromEnv(&c.Mail.SMTPPort, "TODOLIST_MAIL_SMTP_PORT"))
+	setFromEnv(&c.Mail.SMTPUsername, "TODOLIST_MAIL_SMTP_USERNAME")
+	setFromEnv(&c.Mail.SMTPPassword, "TODOLIST_MAIL_SMTP_PASSWORD")
+	collect(setDurationFromEnv(&c.Mail.VerifyTokenTTL, "TODOLIST_MAIL_VERIFY_TOKEN_TTL"))
+	setFromEnv(&c.Mail.VerifyURL, "TODOLIST_MAIL_VERIFY_URL")
+	collect(setBoolFromEnv(&c.OIDC.Enabled, "TODOLIST_OIDC_ENABLED"))
+	setFromEnv(&c.OIDC.Issuer, "TODOLIST_OIDC_ISSUER")
+	setFromEnv(&c.OIDC.ClientID, "TODOLIST_OIDC_CLIENT_ID")
+	setFromEnv(&c.OIDC.ClientSecret, "TODOLIST_OIDC_CLIENT_SECRET")
+	setFromEnv(&c.OIDC.RedirectURL, "TODOLIST_OIDC_REDIRECT_URL")
+	collect(setBoolFromEnv(&c.OIDC.AutoProvision, "TODOLIST_OIDC_AUTO_PROVISION"))
+	collect(setBoolFromEnv(&c.OIDC.LinkByEmail, "TODOLIST_OIDC_LINK_BY_EMAIL"))
+	setFromEnv(&c.OIDC.PostLoginRedirect, "TODOLIST_OIDC_POST_LOGIN_REDIRECT")
+	setFromEnv(&c.Dify.BaseURL, "TODOLIST_DIFY_BASE_URL")
+	setFromEnv(&c.Dify.APIKey, "TODOLIST_DIFY_API_KEY")
+	collect(setDurationFromEnv(&c.Dify.Timeout, "TODOLIST_DIFY_TIMEOUT"))
+	return errors.Join(errs...)
+}
+
+func (c *Config) applyFlags(f *Flags) {
+	setIfNotEmpty(&c.Env, f.Env)
+	setIfNotEmpty(&c.Server.Addr, f.Addr)
+	setIfNotEmpty(&c.Database.Driver, f.DBDriver)
+	setIfNotEmpty(&c.Database.DSN, f.DBDSN)
+}
+
+// Validate 校验配置的完整性，非开发环境下禁止使用默认密钥
+func (c *Config) Validate() error {
+	switch c.Env {
+	case EnvDev, EnvStaging, EnvProd:
+	default:
+		return fmt.Errorf("无效的运行环境: %q", c.Env)
+	}
+
+	if c.Server.Addr == "" {
+		return errors.New("server.addr 不能为空")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		return errors.New("server.shutdown_timeout 必须大于0")
+	}
+	switch c.Database.Driver {
+	case DriverSQLite, DriverPostgres, DriverMySQL:
+	default:
+		return fmt.Errorf("不支持的数据库驱动: %q", c.Database.Driver)
+	}
+	if c.Database.DSN == "" {
+		return errors.New("database.dsn 不能为空")
+	}
+
+	if c.JWT.Secret == "" {
+		return errors.New("jwt.secret 不能为空")
+	}
+	if c.JWT.AccessTokenTTL <= 0 || c.JWT.RefreshTokenTTL <= 0 {
+		return errors.New("jwt.access_token_ttl 和 jwt.refresh_token_ttl 必须大于0")
+	}
+	if c.JWT.AccessTokenTTL >= c.JWT.RefreshTokenTTL {
+		return errors.New("jwt.access_token_ttl 必须小于 jwt.refresh_token_ttl")
+	}
+	if c.JWT.ImpersonationTTL <= 0 {
+		return errors.New("jwt.impersonation_ttl 必须大于0")
+	}
+	if c.Env != EnvDev {
+		if c.JWT.Secret == defaultJWTSecret {
+			return fmt.Errorf("%s 环境下必须配置 jwt.secret", c.Env)
+		}
+		if len(c.JWT.Secret) < 32 {
+			return errors.New("jwt.secret 长度至少为32个字符")
+		}
+	}
+
+	switch c.Login.ThrottleStore {
+	case "memory", "database":
+	default:
+		return fmt.Errorf("不支持的 login.throttle_store: %q", c.Login.ThrottleStore)
+	}
+	if c.Login.Window <= 0 {
+		return errors.New("login.window 必须大于0")
+	}
+	if c.Login.LockoutThreshold > 0 && c.Login.LockoutDuration <= 0 {
+		return errors.New("启用锁定时 login.lockout_duration 必须大于0")
+	}
+
+	if c.MFA.Issuer == "" {
+		return errors.New("mfa.issuer 不能为空")
+	}
+
+	switch c.Registration.Mode {
+	case RegistrationOpen, RegistrationApproval, RegistrationInviteOnly, RegistrationClosed:
+	default:
+		return fmt.Errorf("不支持的 registration.mode: %q", c.Registration.Mode)
+	}
+
+	if c.Password.ResetTokenTTL <= 0 {
+		return errors.New("password.reset_token_ttl 必须大于0")
+	}
+	if c.Password.ResetURL == "" {
+		return errors.New("password.reset_url 不能为空")
+	}
+	if c.Password.MinLength < 1 || c.Password.MinLength > 72 {
+		return errors.New("password.min_length 必须在1到72之间")
+	}
+	if c.Password.HistorySize < 0 {
+		return errors.New("password.history_size 不能为负数")
+	}
+	if c.Password.BreachFile != "" {
+		if _, err := os.Stat(c.Password.BreachFile); err != nil {
+			return fmt.Errorf("password.breach_file 不可用: %w", err)
+		}
+	}
+
+	if c.Storage.UploadDir == "" {
+		return errors.New("storage.upload_dir 不能为空")
+	}
+	if c.Storage.AvatarMaxBytes <= 0 {
+		return errors.New("storage.avatar_max_bytes 必须大于0")
+	}
+
+	if c.Account.DeletionGracePeriod < 0 {
+		return errors.New("account.deletion_grace_period 不能为负数")
+	}
+	if c.Account.PurgeInterval <= 0 {
+		return errors.New("account.purge_interval 必须大于0")
+	}
+
+	if c.Recurrence.Lookahead < 0 {
+		return errors.New("recurrence.lookahead 不能为负数")
+	}
+	if c.Recurrence.Lookahead > 0 && c.Recurrence.Interval <= 0 {
+		return errors.New("recurrence.interval 必须大于0")
+	}
+
+	if c.Notify.PollInterval <= 0 {
+		return errors.New("notify.poll_interval 必须大于0")
+	}
+	if c.Notify.MaxDelay <= 0 {
+		return errors.New("notify.max_delay 必须大于0")
+	}
+	if c.Notify.Timeout <= 0 {
+		return errors.New("notify.timeout 必须大于0")
+	}
+	if u, err := url.Parse(c.Notify.NtfyServer); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("notify.ntfy_server 必须是 http 或 https 地址")
+	}
+
+	if c.Mail.From == "" {
+		return errors.New("mail.from 不能为空")
+	}
+	switch c.Mail.Driver {
+	case "log":
+	case "file":
+		if c.Mail.Dir == "" {
+			return errors.New("mail.driver 为 file 时 mail.dir 不能为空")
+		}
+	case "smtp":
+		if c.Mail.SMTPHost == "" || c.Mail.SMTPPort <= 0 {
+			return errors.New("mail.driver 为 smtp 时 mail.smtp_host 和 mail.smtp_port 不能为空")
+		}
+	default:
+		return fmt.Errorf("不支持的 mail.driver: %q", c.Mail.Driver)
+	}
+	if c.Mail.VerifyTokenTTL <= 0 {
+		return errors.New("mail.verify_token_ttl 必须大于0")
+	}
+	if c.Mail.VerifyURL == "" {
+		return errors.New("mail.verify_url 不能为空")
+	}
+
+	if c.OIDC.Enabled {
+		if c.OIDC.Issuer == "" || c.OIDC.ClientID == "" || c.OIDC.RedirectURL == "" {
+			return errors.New("启用 oidc 时 issuer、client_id、redirect_url 不能为空")
+		}
+		if c.OIDC.PostLoginRedirect == "" {
+			return errors.New("oidc.post_login_redirect 不能为空")
+		}
+	}
+
+	if c.Dify.APIKey != "" && c.Dify.BaseURL == "" {
+		return errors.New("配置了 dify.api_key 时 dify.base_url 不能为空")
+	}
+	if c.Dify.Timeout <= 0 {
+		return errors.New("dify.timeout 必须大于0")
+	}
+	return nil
+}
+
+func setFromEnv(dst *string, key string) {
+	if v, ok := os.LookupEnv(key); ok {
+		*dst = v
+	}
+}
+
+func setBoolFromEnv(dst *bool, key string) error {
+	if v, ok := os.LookupEnv(key); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("环境变量 %s 的值 %q 无效，应为 true 或 false", key, v)
+		}
+		*dst = b
+	}
+	return nil
+}
+
+// setListFromEnv 读取逗号分隔的列表
+func setListFromEnv(dst *[]string, key string) {
+	if v, ok := os.LookupEnv(key); ok {
+		list := []string{}
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		*dst = list
+	}
+}
+
+func setIntFromEnv(dst *int, key string) error {
+	if v, ok := os.LookupEnv(key); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("环境变量 %s 的值 %q 无效，应为整数", key, v)
+		}
+		*dst = n
+	}
+	return nil
+}
+
+func setDurationFromEnv(dst *Duration, key string) error {
+	if v, ok := os.LookupEnv(key); ok {
+		var d Duration
+		if err := d.UnmarshalText([]byte(v)); err != nil {
+			return fmt.Errorf("环境变量 %s 的值 %q 无效，应为带单位的时长，如 30s、5m", key, v)
+		}
+		*dst = d
+	}
+	return nil
+}
+
+func setIfNotEmpty(dst *string, v string) {
+	if v != "" {
+		*dst = v
+	}
+}
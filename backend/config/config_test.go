@@ -0,0 +1,189 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFile 在临时目录中写入配置文件并返回路径
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadLayering(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+server:
+  addr: 127.0.0.1:9000
+  read_timeout: 20s
+  write_timeout: 2m
+database:
+  dsn: /tmp/file.db
+mail:
+  smtp_port: 2525
+registration:
+  allowed_domains: [file.example.com]
+`)
+	t.Setenv("TODOLIST_SERVER_WRITE_TIMEOUT", "3m")
+	t.Setenv("TODOLIST_DB_DSN", "/tmp/env.db")
+	t.Setenv("TODOLIST_MAIL_SMTP_PORT", "465")
+	t.Setenv("TODOLIST_OIDC_AUTO_PROVISION", "true")
+	t.Setenv("TODOLIST_REGISTRATION_ALLOWED_DOMAINS", " a.example.com, ,b.example.com ")
+
+	cfg, err := Load(&Flags{ConfigFile: path, DBDSN: "/tmp/flag.db"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"默认值", cfg.Server.IdleTimeout, Duration(120 * time.Second)},
+		{"配置文件", cfg.Server.Addr, "127.0.0.1:9000"},
+		{"配置文件中的时长", cfg.Server.ReadTimeout, Duration(20 * time.Second)},
+		{"环境变量覆盖配置文件", cfg.Server.WriteTimeout, Duration(3 * time.Minute)},
+		{"环境变量中的整数", cfg.Mail.SMTPPort, 465},
+		{"环境变量中的布尔值", cfg.OIDC.AutoProvision, true},
+		{"环境变量中的列表", strings.Join(cfg.Registration.AllowedDomains, ","), "a.example.com,b.example.com"},
+		{"命令行参数覆盖环境变量", cfg.Database.DSN, "/tmp/flag.db"},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s: 得到 %v，期望 %v", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestLoadTOMLFromEnvPath(t *testing.T) {
+	path := writeFile(t, "config.toml", `
+env = "staging"
+
+[jwt]
+secret = "0123456789abcdef0123456789abcdef"
+
+[notify]
+timeout = "5s"
+`)
+	t.Setenv("TODOLIST_CONFIG", path)
+	cfg, err := Load(&Flags{Addr: ":7000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Env != EnvStaging || cfg.Notify.Timeout != Duration(5*time.Second) || cfg.Server.Addr != ":7000" {
+		t.Errorf("配置不正确: env=%s timeout=%v addr=%s", cfg.Env, cfg.Notify.Timeout, cfg.Server.Addr)
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	cases := map[string]string{
+		"config.json": `{}`,
+		"config.yaml": "server: [",
+		"config.toml": "[server",
+	}
+	for name, content := range cases {
+		if _, err := Load(&Flags{ConfigFile: writeFile(t, name, content)}); err == nil {
+			t.Errorf("%s 应当加载失败", name)
+		}
+	}
+	if _, err := Load(&Flags{ConfigFile: filepath.Join(t.TempDir(), "missing.yaml")}); err == nil {
+		t.Error("不存在的配置文件应当加载失败")
+	}
+	// 配置文件中无效的时长
+	if _, err := Load(&Flags{ConfigFile: writeFile(t, "bad.yaml", "server:\n  read_timeout: 30\n")}); err == nil {
+		t.Error("缺少单位的时长应当加载失败")
+	}
+}
+
+func TestLoadEnvErrors(t *testing.T) {
+	cases := []struct {
+		key, value string
+	}{
+		{"TODOLIST_SERVER_READ_TIMEOUT", "30"},
+		{"TODOLIST_JWT_ACCESS_TOKEN_TTL", "15 minutes"},
+		{"TODOLIST_DB_AUTO_MIGRATE", "yes"},
+		{"TODOLIST_OIDC_ENABLED", "on"},
+		{"TODOLIST_PASSWORD_MIN_LENGTH", "ten"},
+		{"TODOLIST_MAIL_SMTP_PORT", "587.0"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.key, func(t *testing.T) {
+			t.Setenv(tc.key, tc.value)
+			_, err := Load(nil)
+			if err == nil {
+				t.Fatal("无效的环境变量应当加载失败")
+			}
+			if !strings.Contains(err.Error(), tc.key) || !strings.Contains(err.Error(), tc.value) {
+				t.Errorf("错误信息应当包含变量名和值: %v", err)
+			}
+		})
+	}
+
+	// 多个无效值一起报告
+	t.Setenv("TODOLIST_SERVER_READ_TIMEOUT", "30")
+	t.Setenv("TODOLIST_DB_AUTO_MIGRATE", "yes")
+	_, err := Load(nil)
+	if err == nil || !strings.Contains(err.Error(), "TODOLIST_SERVER_READ_TIMEOUT") || !strings.Contains(err.Error(), "TODOLIST_DB_AUTO_MIGRATE") {
+		t.Errorf("应当报告全部无效的环境变量: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatalf("默认配置应当通过校验: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		modify func(c *Config)
+	}{
+		{"无效的运行环境", func(c *Config) { c.Env = "test" }},
+		{"空监听地址", func(c *Config) { c.Server.Addr = "" }},
+		{"无效的数据库驱动", func(c *Config) { c.Database.Driver = "oracle" }},
+		{"访问令牌有效期不小于刷新令牌", func(c *Config) { c.JWT.AccessTokenTTL = c.JWT.RefreshTokenTTL }},
+		{"生产环境使用默认密钥", func(c *Config) { c.Env = EnvProd }},
+		{"生产环境密钥过短", func(c *Config) {
+			c.Env = EnvProd
+			c.JWT.Secret = "short-secret"
+		}},
+		{"无效的限流存储", func(c *Config) { c.Login.ThrottleStore = "redis" }},
+		{"锁定时长为0", func(c *Config) { c.Login.LockoutDuration = 0 }},
+		{"无效的注册模式", func(c *Config) { c.Registration.Mode = "public" }},
+		{"密码最短长度超出范围", func(c *Config) { c.Password.MinLength = 73 }},
+		{"泄露密码列表不存在", func(c *Config) { c.Password.BreachFile = "/nonexistent/breach.txt" }},
+		{"提前生成重复但没有检查间隔", func(c *Config) {
+			c.Recurrence.Lookahead = Duration(time.Hour)
+			c.Recurrence.Interval = 0
+		}},
+		{"ntfy 地址不是 http", func(c *Config) { c.Notify.NtfyServer = "ftp://ntfy.example.com" }},
+		{"smtp 缺少主机", func(c *Config) { c.Mail.Driver = "smtp" }},
+		{"file 缺少目录", func(c *Config) {
+			c.Mail.Driver = "file"
+			c.Mail.Dir = ""
+		}},
+		{"启用 oidc 缺少 issuer", func(c *Config) { c.OIDC.Enabled = true }},
+		{"dify 超时为0", func(c *Config) { c.Dify.Timeout = 0 }},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := Default()
+			tc.modify(c)
+			if err := c.Validate(); err == nil {
+				t.Error("应当校验失败")
+			}
+		})
+	}
+
+	prod := Default()
+	prod.Env = EnvProd
+	prod.JWT.Secret = strings.Repeat("s", 32)
+	if err := prod.Validate(); err != nil {
+		t.Errorf("配置了密钥的生产环境应当通过校验: %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"time"
+)
+
+// Duration 支持在配置文件中以 "30s"、"5m" 等形式书写的时长
+type Duration time.Duration
+
+// UnmarshalText 实现 encoding.TextUnmarshaler，YAML 和 TOML 均通过它解析
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText 实现 encoding.TextMarshaler
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Std 转换为 time.Duration
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
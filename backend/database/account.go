@@ -0,0 +1,59 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"todolist/models"
+)
+
+// DeleteUserData 删除用户及其待办事项、会话、令牌、第三方账号关联等数据，审计日志保留。
+// 共享项目中由用户创建的待办事项保留，转给项目的所有者
+func DeleteUserData(user *models.User) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := leaveProjects(tx, user.ID); err != nil {
+			return err
+		}
+		if err := tx.Where("todo_id IN (?)", tx.Model(&models.Todo{}).Select("id").Where("user_id = ?", user.ID)).
+			Delete(&models.ChecklistItem{}).Error; err != nil {
+			return err
+		}
+		owned := []interface{}{
+			&models.Todo{},
+			&models.Recurrence{},
+			&models.Reminder{},
+			&models.Notification{},
+			&models.NotificationSettings{},
+			&models.RefreshToken{},
+			&models.Session{},
+			&models.APIToken{},
+			&models.UserIdentity{},
+			&models.RecoveryCode{},
+			&models.PasswordReset{},
+			&models.EmailVerification{},
+			&models.PasswordHistory{},
+		}
+		for _, model := range owned {
+			if err := tx.Where("user_id = ?", user.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(user).Error
+	})
+}
+
+// ScheduleUserDeletion 设置或清除（deleteAfter 为 nil）用户的注销时间
+func ScheduleUserDeletion(user *models.User, deleteAfter *time.Time) error {
+	var value *models.CustomTime
+	if deleteAfter != nil {
+		value = &models.CustomTime{Time: deleteAfter.UTC()}
+	}
+	return DB.Model(user).Update("delete_after", value).Error
+}
+
+// DueUserDeletions 返回注销冷静期已过、需要删除的用户
+func DueUserDeletions(now time.Time) ([]models.User, error) {
+	var users []models.User
+	err := DB.Where("delete_after IS NOT NULL AND delete_after <= ?", now.UTC()).Find(&users).Error
+	return users, err
+}
@@ -1,24 +1,130 @@
 package database
 
 import (
+    "errors"
+    "fmt"
+
+    "gorm.io/gorm"
     "todolist/models"
 )
 
-// CreateDefaultAdmin 创建默认管理员账号
-func CreateDefaultAdmin() error {
-    var count int64
-    DB.Model(&models.User{}).Where("role = ?", models.RoleAdmin).Count(&count)
-    if count == 0 {
-        admin := &models.User{
-            Username: "admin",
-            Password: "123456",
-            Role:     models.RoleAdmin,
-            Status:   models.StatusActive,
+// legacyAdminPassword 旧版本自动创建的管理员账号使用的固定初始密码
+const legacyAdminPassword = "123456"
+
+// CreateDefaultAdmin 没有管理员账号时创建 admin 账号，返回随机生成的初始密码（首次登录后必须修改）；
+// 已有管理员账号时不创建，返回空字符串
+func CreateDefaultAdmin() (string, error) {
+    ok, err := HasAdmin()
+    if err != nil || ok {
+        return "", err
+    }
+
+    password, err := RandomToken(12)
+    if err != nil {
+        return "", err
+    }
+    if _, err := CreateUser("admin", password, models.RoleAdmin, true); err != nil {
+        return "", err
+    }
+    return password, nil
+}
+
+// ExpireLegacyAdminPassword 旧版本创建的 admin 账号仍在使用固定密码 123456 时要求下次登录修改密码，
+// 返回是否存在这样的账号
+func ExpireLegacyAdminPassword() (bool, error) {
+    var admin models.User
+    if err := DB.Where("username = ? AND role = ?", "admin", models.RoleAdmin).Limit(1).Find(&admin).Error; err != nil {
+        return false, err
+    }
+    if admin.ID == 0 || admin.CheckPassword(legacyAdminPassword) != nil {
+        return false, nil
+    }
+    if !admin.MustChangePassword {
+        if err := DB.Model(&admin).Update("must_change_password", true).Error; err != nil {
+            return false, err
         }
-        if err := admin.HashPassword(); err != nil {
-            return err
+    }
+    return true, nil
+}
+
+// HasAdmin 判断是否已存在管理员账号
+func HasAdmin() (bool, error) {
+    count, err := CountAdmins()
+    if err != nil {
+        return false, err
+    }
+    return count > 0, nil
+}
+
+// CountAdmins 统计管理员账号数量
+func CountAdmins() (int64, error) {
+    var count int64
+    err := DB.Model(&models.User{}).Where("role = ?", models.RoleAdmin).Count(&count).Error
+    return count, err
+}
+
+// CreateUser 创建一个已激活的用户，调用方负责检查密码策略
+func CreateUser(username, password, role string, mustChange bool) (*models.User, error) {
+    var count int64
+    if err := DB.Model(&models.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+        return nil, err
+    }
+    if count > 0 {
+        return nil, errors.New("用户名已存在")
+    }
+
+    user := &models.User{
+        Username:           username,
+        Password:           password,
+        Role:               role,
+        Status:             models.StatusActive,
+        MustChangePassword: mustChange,
+    }
+    if err := user.HashPassword(); err != nil {
+        return nil, err
+    }
+    if err := DB.Create(user).Error; err != nil {
+        return nil, err
+    }
+    return user, nil
+}
+
+// ResetPassword 重置指定用户的密码，history 含义同 SetPassword，调用方负责检查密码策略
+func ResetPassword(username, password string, history int, mustChange bool) error {
+    user, err := findUserByUsername(username)
+    if err != nil {
+        return err
+    }
+    reused, err := PasswordReused(user, password, history)
+    if err != nil {
+        return err
+    }
+    if reused {
+        return ErrPasswordReused
+    }
+    hashed := models.User{Password: password}
+    if err := hashed.HashPassword(); err != nil {
+        return err
+    }
+    return SetPassword(DB, user, hashed.Password, history, mustChange)
+}
+
+// ActivateUser 激活指定用户（包括被禁用的用户）
+func ActivateUser(username string) error {
+    user, err := findUserByUsername(username)
+    if err != nil {
+        return err
+    }
+    return DB.Model(user).Update("status", models.StatusActive).Error
+}
+
+func findUserByUsername(username string) (*models.User, error) {
+    var user models.User
+    if err := DB.Where("username = ?", username).First(&user).Error; err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return nil, fmt.Errorf("用户 %s 不存在", username)
         }
-        return DB.Create(admin).Error
+        return nil, err
     }
-    return nil
-} 
\ No newline at end of file
+    return &user, nil
+}
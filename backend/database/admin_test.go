@@ -0,0 +1,59 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"todolist/config"
+	"todolist/models"
+)
+
+func TestCreateDefaultAdmin(t *testing.T) {
+	openTestDB(t, config.DatabaseConfig{
+		Driver: config.DriverSQLite,
+		DSN:    filepath.Join(t.TempDir(), "test.db"),
+	})
+
+	password, err := CreateDefaultAdmin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(password) < 16 || password == legacyAdminPassword {
+		t.Fatalf("初始密码应当随机生成: %q", password)
+	}
+	var admin models.User
+	if err := DB.Where("username = ?", "admin").First(&admin).Error; err != nil {
+		t.Fatal(err)
+	}
+	if admin.Role != models.RoleAdmin || !admin.MustChangePassword || admin.CheckPassword(password) != nil {
+		t.Errorf("管理员账号不正确: %+v", admin)
+	}
+
+	// 已有管理员时不再创建，也不输出密码
+	if again, err := CreateDefaultAdmin(); err != nil || again != "" {
+		t.Fatalf("已有管理员时不应创建: %q %v", again, err)
+	}
+	if legacy, err := ExpireLegacyAdminPassword(); err != nil || legacy {
+		t.Fatalf("随机密码不应被当作旧默认密码: %v %v", legacy, err)
+	}
+}
+
+func TestExpireLegacyAdminPassword(t *testing.T) {
+	openTestDB(t, config.DatabaseConfig{
+		Driver: config.DriverSQLite,
+		DSN:    filepath.Join(t.TempDir(), "test.db"),
+	})
+
+	admin, err := CreateUser("admin", legacyAdminPassword, models.RoleAdmin, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy, err := ExpireLegacyAdminPassword()
+	if err != nil || !legacy {
+		t.Fatalf("应当识别旧默认密码: %v %v", legacy, err)
+	}
+	DB.First(admin, admin.ID)
+	if !admin.MustChangePassword {
+		t.Error("使用旧默认密码的管理员应当被要求修改密码")
+	}
+}
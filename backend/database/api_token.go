@@ -0,0 +1,61 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"todolist/models"
+)
+
+var ErrAPITokenInvalid = errors.New("无效的API令牌")
+
+// CreateAPIToken 创建个人访问令牌，返回令牌原文（仅此一次可见）
+func CreateAPIToken(token *models.APIToken) (string, error) {
+	secret, err := RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	raw := models.APITokenPrefix + secret
+	token.Prefix = raw[:len(models.APITokenPrefix)+6]
+	token.TokenHash = HashToken(raw)
+	if err := DB.Create(token).Error; err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// FindAPIToken 按令牌原文查找有效的个人访问令牌
+func FindAPIToken(raw string) (*models.APIToken, error) {
+	var token models.APIToken
+	if err := DB.Where("token_hash = ?", HashToken(raw)).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPITokenInvalid
+		}
+		return nil, err
+	}
+	if !token.IsValid() {
+		return nil, ErrAPITokenInvalid
+	}
+	return &token, nil
+}
+
+// TouchAPIToken 记录令牌的最近使用时间
+func TouchAPIToken(id uint) error {
+	return DB.Model(&models.APIToken{}).Where("id = ?", id).Update("last_used_at", time.Now().UTC()).Error
+}
+
+// ListAPITokens 列出用户未吊销的个人访问令牌
+func ListAPITokens(userID uint) ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	err := DB.Where("user_id = ? AND revoked_at IS NULL", userID).Order("id DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+// RevokeAPIToken 吊销用户的指定令牌，返回是否找到该令牌
+func RevokeAPIToken(userID, id uint) (bool, error) {
+	result := DB.Model(&models.APIToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now().UTC())
+	return result.RowsAffected > 0, result.Error
+}
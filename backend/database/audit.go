@@ -0,0 +1,46 @@
+package database
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"todolist/models"
+)
+
+// RecordAudit 写入一条审计事件
+func RecordAudit(event *models.AuditEvent) error {
+	return DB.Create(event).Error
+}
+
+// AuditDiff 比较两个对象序列化为 JSON 后的字段，返回发生变化的字段，before 或 after 可为 nil
+func AuditDiff(before, after interface{}) models.AuditChanges {
+	b, a := toFieldMap(before), toFieldMap(after)
+	changes := models.AuditChanges{}
+	for k, v := range b {
+		if k == "updated_at" {
+			continue
+		}
+		if av, ok := a[k]; !ok || !reflect.DeepEqual(v, av) {
+			changes[k] = models.AuditChange{Before: v, After: a[k]}
+		}
+	}
+	for k, v := range a {
+		if _, ok := b[k]; !ok && k != "updated_at" {
+			changes[k] = models.AuditChange{After: v}
+		}
+	}
+	return changes
+}
+
+func toFieldMap(v interface{}) map[string]interface{} {
+	m := map[string]interface{}{}
+	if v == nil {
+		return m
+	}
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		return m
+	}
+	_ = json.Unmarshal(bytes, &m)
+	return m
+}
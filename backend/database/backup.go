@@ -0,0 +1,25 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"todolist/config"
+)
+
+// Backup 将数据库备份到指定文件
+//
+// SQLite 使用 VACUUM INTO 生成一致性快照；PostgreSQL 和 MySQL 请使用各自的
+// pg_dump / mysqldump 工具，以保留完整的结构和权限信息。
+func Backup(driver, path string) error {
+	if driver != config.DriverSQLite {
+		return fmt.Errorf("%s 数据库请使用官方备份工具（pg_dump / mysqldump）", driver)
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("备份文件已存在: %s", path)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return DB.Exec("VACUUM INTO ?", path).Error
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"todolist/models"
+)
+
+var ErrChecklistOrder = errors.New("排序列表必须包含该待办事项的全部检查项")
+
+// LoadChecklist 重新加载待办事项的检查项并更新进度
+func LoadChecklist(todo *models.Todo) error {
+	todo.Checklist = nil
+	if err := DB.Where("todo_id = ?", todo.ID).Order("sort_order, id").Find(&todo.Checklist).Error; err != nil {
+		return err
+	}
+	todo.UpdateProgress()
+	return nil
+}
+
+// InsertChecklistItem 在 position 位置插入检查项，之后的检查项依次后移；position 为 nil 或超出范围时追加到末尾
+func InsertChecklistItem(todoID uint, item *models.ChecklistItem, position *int) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.ChecklistItem{}).Where("todo_id = ?", todoID).Count(&count).Error; err != nil {
+			return err
+		}
+		item.TodoID = todoID
+		item.SortOrder = int(count)
+		if position != nil && *position < int(count) {
+			// 先整理为连续的顺序，再为新检查项腾出位置
+			if err := renumberChecklist(tx, todoID); err != nil {
+				return err
+			}
+			if err := tx.Model(&models.ChecklistItem{}).
+				Where("todo_id = ? AND sort_order >= ?", todoID, *position).
+				Update("sort_order", gorm.Expr("sort_order + 1")).Error; err != nil {
+				return err
+			}
+			item.SortOrder = *position
+		}
+		return tx.Create(item).Error
+	})
+}
+
+// ReorderChecklist 按 ids 的顺序重新排列检查项，ids 必须恰好包含该待办事项的全部检查项
+func ReorderChecklist(todoID uint, ids []uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var existing []uint
+		if err := tx.Model(&models.ChecklistItem{}).Where("todo_id = ?", todoID).Pluck("id", &existing).Error; err != nil {
+			return err
+		}
+		if len(existing) != len(ids) {
+			return ErrChecklistOrder
+		}
+		known := make(map[uint]bool, len(existing))
+		for _, id := range existing {
+			known[id] = true
+		}
+		for i, id := range ids {
+			if !known[id] {
+				return ErrChecklistOrder
+			}
+			delete(known, id)
+			if err := tx.Model(&models.ChecklistItem{}).Where("id = ?", id).Update("sort_order", i).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// renumberChecklist 将检查项的顺序整理为从0开始的连续值
+func renumberChecklist(tx *gorm.DB, todoID uint) error {
+	var ids []uint
+	if err := tx.Model(&models.ChecklistItem{}).Where("todo_id = ?", todoID).Order("sort_order, id").Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	for i, id := range ids {
+		if err := tx.Model(&models.ChecklistItem{}).Where("id = ?", id).Update("sort_order", i).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,31 +1,98 @@
 package database
 
 import (
+    "fmt"
+    "log"
+    "time"
+
+    "gorm.io/driver/mysql"
+    "gorm.io/driver/postgres"
     "gorm.io/gorm"
     "github.com/glebarez/sqlite"
-    "todolist/models"
+    "todolist/config"
+    "todolist/migrations"
 )
 
 var DB *gorm.DB
 
-func InitDB() {
-    var err error
-    DB, err = gorm.Open(sqlite.Open("backend/data/todo.db"), &gorm.Config{})
+// Open 根据配置的驱动打开数据库连接
+func Open(cfg config.DatabaseConfig) (*gorm.DB, error) {
+    var dialector gorm.Dialector
+    switch cfg.Driver {
+    case config.DriverSQLite:
+        dialector = sqlite.Open(cfg.DSN)
+    case config.DriverPostgres:
+        dialector = postgres.Open(cfg.DSN)
+    case config.DriverMySQL:
+        dialector = mysql.Open(cfg.DSN)
+    default:
+        return nil, fmt.Errorf("不支持的数据库驱动: %s", cfg.Driver)
+    }
+
+    db, err := gorm.Open(dialector, &gorm.Config{})
     if err != nil {
-        panic("failed to connect database")
+        return nil, fmt.Errorf("failed to connect database: %w", err)
     }
 
-    // 自动迁移
-    err = DB.AutoMigrate(&models.User{}, &models.Todo{})
+    sqlDB, err := db.DB()
     if err != nil {
-        panic("failed to migrate database")
+        return nil, err
+    }
+    if cfg.MaxOpenConns > 0 {
+        sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+    }
+    if cfg.MaxIdleConns > 0 {
+        sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+    }
+    if cfg.ConnMaxLifetime > 0 {
+        sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime) * time.Second)
+    }
+    return db, nil
+}
+
+// Connect 仅建立数据库连接，不检查迁移状态（供 migrate 等命令使用）
+func Connect(cfg config.DatabaseConfig) error {
+    var err error
+    DB, err = Open(cfg)
+    return err
+}
+
+// InitDB 建立数据库连接并检查迁移状态
+// 存在未执行的迁移时，除非开启了 auto_migrate，否则拒绝启动
+func InitDB(cfg config.DatabaseConfig) error {
+    if err := Connect(cfg); err != nil {
+        return err
     }
 
-    // 添加last_active字段（如果不存在）
-    if !DB.Migrator().HasColumn(&models.User{}, "last_active") {
-        err = DB.Migrator().AddColumn(&models.User{}, "last_active")
+    if cfg.AutoMigrate {
+        applied, err := migrations.Up(DB, 0)
+        for _, m := range applied {
+            log.Printf("已执行数据库迁移 %04d_%s", m.Version, m.Name)
+        }
         if err != nil {
-            panic("failed to add last_active column")
+            return err
         }
+        return nil
+    }
+
+    pending, err := migrations.Pending(DB)
+    if err != nil {
+        return fmt.Errorf("检查数据库迁移失败: %w", err)
+    }
+    if len(pending) > 0 {
+        return fmt.Errorf("存在 %d 个未执行的数据库迁移，请先执行 migrate up 或开启 database.auto_migrate", len(pending))
+    }
+    return nil
+}
+
+// Close 关闭数据库连接
+func Close() error {
+    if DB == nil {
+        return nil
+    }
+    sqlDB, err := DB.DB()
+    if err != nil {
+        return err
     }
-} 
\ No newline at end of file
+    return sqlDB.Close()
+}
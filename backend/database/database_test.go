@@ -0,0 +1,344 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"todolist/config"
+	"todolist/migrations"
+	"todolist/models"
+)
+
+// openTestDB 连接测试数据库并执行全部迁移，测试结束后回滚全部迁移并关闭连接
+func openTestDB(t *testing.T, cfg config.DatabaseConfig) {
+	t.Helper()
+	if err := Connect(cfg); err != nil {
+		t.Fatalf("连接数据库失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := migrations.Down(DB, len(migrations.All())); err != nil {
+			t.Errorf("回滚迁移失败: %v", err)
+		}
+		Close()
+		DB = nil
+	})
+	if _, err := migrations.Up(DB, 0); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+}
+
+// runEngineSuite 在当前连接的数据库上运行与引擎相关的测试
+func runEngineSuite(t *testing.T, cfg config.DatabaseConfig) {
+	openTestDB(t, cfg)
+	t.Run("Migrations", testMigrations)
+	t.Run("UserCRUD", testUserCRUD)
+	t.Run("TodoCRUD", testTodoCRUD)
+	t.Run("Projects", testProjects)
+	t.Run("DeleteUserData", testDeleteUserData)
+}
+
+func TestSQLite(t *testing.T) {
+	runEngineSuite(t, config.DatabaseConfig{
+		Driver: config.DriverSQLite,
+		DSN:    filepath.Join(t.TempDir(), "test.db"),
+	})
+}
+
+func TestMigrationStatusReadOnly(t *testing.T) {
+	if err := Connect(config.DatabaseConfig{
+		Driver: config.DriverSQLite,
+		DSN:    filepath.Join(t.TempDir(), "test.db"),
+	}); err != nil {
+		t.Fatalf("连接数据库失败: %v", err)
+	}
+	t.Cleanup(func() {
+		Close()
+		DB = nil
+	})
+
+	// 查看状态和启动检查不应创建迁移表
+	pending, err := migrations.Pending(DB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != len(migrations.All()) {
+		t.Errorf("空数据库应当有 %d 个未执行的迁移，实际 %d 个", len(migrations.All()), len(pending))
+	}
+	list, err := migrations.StatusList(DB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range list {
+		if s.Applied {
+			t.Errorf("迁移 %04d_%s 不应标记为已执行", s.Version, s.Name)
+		}
+	}
+	if DB.Migrator().HasTable(&migrations.SchemaMigration{}) {
+		t.Error("只读检查创建了迁移表")
+	}
+}
+
+func testMigrations(t *testing.T) {
+	pending, err := migrations.Pending(DB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("执行后仍有 %d 个未执行的迁移", len(pending))
+	}
+
+	// 全部回滚后重新执行，检查每个迁移都可逆
+	all := migrations.All()
+	down, err := migrations.Down(DB, len(all))
+	if err != nil {
+		t.Fatalf("回滚迁移失败: %v", err)
+	}
+	if len(down) != len(all) {
+		t.Fatalf("回滚了 %d 个迁移，期望 %d 个", len(down), len(all))
+	}
+	for _, table := range []string{"users", "todos", "roles", "projects"} {
+		if DB.Migrator().HasTable(table) {
+			t.Errorf("回滚后表 %s 仍然存在", table)
+		}
+	}
+	if _, err := migrations.Up(DB, 0); err != nil {
+		t.Fatalf("重新执行迁移失败: %v", err)
+	}
+
+	list, err := migrations.StatusList(DB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range list {
+		if !s.Applied {
+			t.Errorf("迁移 %04d_%s 未执行", s.Version, s.Name)
+		}
+	}
+}
+
+func testUserCRUD(t *testing.T) {
+	user, err := CreateUser("alice", "Secret123!", "user", false)
+	if err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	if _, err := CreateUser("alice", "Secret123!", "user", false); err == nil {
+		t.Error("重复的用户名应当创建失败")
+	}
+
+	var loaded models.User
+	if err := DB.First(&loaded, user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Status != models.StatusActive || loaded.Timezone != "UTC" || loaded.CheckPassword("Secret123!") != nil {
+		t.Errorf("读取的用户不正确: %+v", loaded)
+	}
+
+	if err := DB.Model(&loaded).Update("display_name", "Alice").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := DB.First(&loaded, user.ID).Error; err != nil || loaded.DisplayName != "Alice" {
+		t.Errorf("更新用户失败: %v %q", err, loaded.DisplayName)
+	}
+
+	role, err := GetRole("admin")
+	if err != nil || !role.HasPermission(models.PermUsersDelete) {
+		t.Errorf("内置角色 admin 不正确: %v %+v", err, role)
+	}
+
+	if err := DeleteUserData(&loaded); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	DB.Model(&models.User{}).Where("id = ?", user.ID).Count(&count)
+	if count != 0 {
+		t.Error("删除后用户仍然存在")
+	}
+}
+
+func testTodoCRUD(t *testing.T) {
+	user, err := CreateUser("bob", "Secret123!", "user", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DeleteUserData(user)
+
+	todo := &models.Todo{Title: "写测试", UserID: user.ID, Tags: models.StringSlice{"work", "go"}}
+	if err := SaveTodo(DB, todo, nil); err != nil {
+		t.Fatalf("创建待办事项失败: %v", err)
+	}
+	if todo.EndTime == nil {
+		t.Error("非长期任务应当设置默认结束时间")
+	}
+	other := &models.Todo{Title: "买菜", UserID: user.ID, Tags: models.StringSlice{"home"}}
+	if err := SaveTodo(DB, other, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, title := range []string{"第一步", "第二步"} {
+		if err := InsertChecklistItem(todo.ID, &models.ChecklistItem{Title: title}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first := 0
+	if err := InsertChecklistItem(todo.ID, &models.ChecklistItem{Title: "准备"}, &first); err != nil {
+		t.Fatal(err)
+	}
+
+	var loaded models.Todo
+	if err := PreloadTodo(DB).First(&loaded, todo.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Checklist) != 3 || loaded.Checklist[0].Title != "准备" || loaded.Checklist[2].Title != "第二步" {
+		t.Errorf("检查项顺序不正确: %+v", loaded.Checklist)
+	}
+	if len(loaded.Tags) != 2 || loaded.Tags[0] != "work" {
+		t.Errorf("标签读取不正确: %v", loaded.Tags)
+	}
+
+	// 标签筛选在各引擎上使用不同的 JSON 函数
+	var tagged []models.Todo
+	if err := DB.Where("user_id = ?", user.ID).Where(JSONArrayContains(DB, "tags", "go")).Find(&tagged).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(tagged) != 1 || tagged[0].ID != todo.ID {
+		t.Errorf("按标签筛选的结果不正确: %+v", tagged)
+	}
+
+	loaded.Completed = true
+	if err := SaveTodo(DB, &loaded, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := DB.First(&loaded, todo.ID).Error; err != nil || !loaded.Completed {
+		t.Errorf("更新待办事项失败: %v", err)
+	}
+
+	if err := DeleteTodo(&loaded); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	DB.Model(&models.ChecklistItem{}).Where("todo_id = ?", todo.ID).Count(&count)
+	if count != 0 {
+		t.Error("删除待办事项后检查项仍然存在")
+	}
+	DB.Model(&models.Todo{}).Where("user_id = ?", user.ID).Count(&count)
+	if count != 1 {
+		t.Errorf("剩余 %d 个待办事项，期望 1 个", count)
+	}
+}
+
+func testProjects(t *testing.T) {
+	owner, err := CreateUser("carol", "Secret123!", "user", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DeleteUserData(owner)
+	member, err := CreateUser("dave", "Secret123!", "user", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DeleteUserData(member)
+
+	project := &models.Project{UserID: owner.ID, Name: "家务"}
+	if err := CreateProject(project); err != nil {
+		t.Fatal(err)
+	}
+	if role, err := ProjectRole(project.ID, owner.ID); err != nil || role != models.ProjectOwner {
+		t.Fatalf("创建者应当是所有者: %q %v", role, err)
+	}
+
+	shared := &models.Todo{Title: "打扫", UserID: owner.ID, ProjectID: &project.ID}
+	private := &models.Todo{Title: "私人", UserID: owner.ID}
+	for _, todo := range []*models.Todo{shared, private} {
+		if err := SaveTodo(DB, todo, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	visible := func(userID uint) int64 {
+		var count int64
+		if err := AccessibleTodos(DB.Model(&models.Todo{}), userID).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		return count
+	}
+	if n := visible(member.ID); n != 0 {
+		t.Errorf("加入项目前可见 %d 个待办事项", n)
+	}
+
+	invitation := &models.ProjectInvitation{ProjectID: project.ID, InviteeID: member.ID, InviterID: owner.ID, Role: models.ProjectEditor}
+	if err := DB.Create(invitation).Error; err != nil {
+		t.Fatal(err)
+	}
+	m, err := AcceptProjectInvitation(invitation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := visible(member.ID); n != 1 {
+		t.Errorf("加入项目后可见 %d 个待办事项，期望 1 个", n)
+	}
+
+	var ownerMember models.ProjectMember
+	if err := DB.Where("project_id = ? AND user_id = ?", project.ID, owner.ID).First(&ownerMember).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateProjectMember(&ownerMember, models.ProjectViewer); err != ErrLastProjectOwner {
+		t.Errorf("降级最后一个所有者应当失败，得到 %v", err)
+	}
+
+	projects := []models.Project{*project}
+	if err := CountOpenTodos(projects); err != nil || projects[0].TodoCount != 1 {
+		t.Errorf("未完成数量不正确: %d %v", projects[0].TodoCount, err)
+	}
+
+	if err := RemoveProjectMember(m); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteProject(project, models.ProjectTodosKeep, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := DB.First(shared, shared.ID).Error; err != nil || shared.ProjectID != nil {
+		t.Errorf("删除项目后待办事项应当保留并移出项目: %v %v", err, shared.ProjectID)
+	}
+}
+
+func testDeleteUserData(t *testing.T) {
+	user, err := CreateUser("erin", "Secret123!", "user", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	todo := &models.Todo{Title: "待删除", UserID: user.ID}
+	if err := SaveTodo(DB, todo, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := InsertChecklistItem(todo.ID, &models.ChecklistItem{Title: "子任务"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateSession(&models.Session{UserID: user.ID}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	project := &models.Project{UserID: user.ID, Name: "个人"}
+	if err := CreateProject(project); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DeleteUserData(user); err != nil {
+		t.Fatal(err)
+	}
+	for _, model := range []interface{}{&models.Todo{}, &models.Session{}, &models.ProjectMember{}} {
+		var count int64
+		DB.Model(model).Where("user_id = ?", user.ID).Count(&count)
+		if count != 0 {
+			t.Errorf("%T 仍有 %d 条记录", model, count)
+		}
+	}
+	var count int64
+	DB.Model(&models.ChecklistItem{}).Where("todo_id = ?", todo.ID).Count(&count)
+	if count != 0 {
+		t.Error("检查项仍然存在")
+	}
+	DB.Model(&models.Project{}).Where("id = ?", project.ID).Count(&count)
+	if count != 0 {
+		t.Error("没有其他成员的项目应当被删除")
+	}
+}
@@ -0,0 +1,21 @@
+package database
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JSONArrayContains 返回“JSON字符串数组列包含指定值”的查询条件
+//
+// 各数据库的JSON函数不同，统一在这里按方言生成，保证三种引擎的筛选结果一致。
+// column 必须是受信任的列名，不能来自用户输入。
+func JSONArrayContains(db *gorm.DB, column, value string) clause.Expression {
+	switch db.Dialector.Name() {
+	case "mysql":
+		return gorm.Expr("JSON_CONTAINS("+column+", JSON_QUOTE(?))", value)
+	case "postgres":
+		return gorm.Expr("CAST("+column+" AS jsonb) @> jsonb_build_array(CAST(? AS text))", value)
+	default:
+		return gorm.Expr("EXISTS (SELECT 1 FROM json_each("+column+") WHERE json_each.value = ?)", value)
+	}
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"todolist/models"
+)
+
+var ErrEmailVerificationInvalid = errors.New("验证链接无效或已过期")
+
+// CreateEmailVerification 为用户当前的邮箱签发验证令牌并使之前未使用的令牌失效，返回令牌原文
+func CreateEmailVerification(user *models.User, ttl time.Duration) (string, error) {
+	raw, err := RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now().UTC()
+
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.EmailVerification{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.EmailVerification{
+			UserID:    user.ID,
+			Email:     user.Email,
+			TokenHash: HashToken(raw),
+			ExpiresAt: now.Add(ttl),
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// VerifyEmail 校验并使用邮箱验证令牌，令牌签发后邮箱已修改的视为无效，成功后返回已验证的用户
+func VerifyEmail(raw string) (*models.User, error) {
+	var user models.User
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var verification models.EmailVerification
+		if err := tx.Where("token_hash = ?", HashToken(raw)).First(&verification).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEmailVerificationInvalid
+			}
+			return err
+		}
+
+		// 条件更新保证令牌只能使用一次
+		now := time.Now().UTC()
+		result := tx.Model(&models.EmailVerification{}).
+			Where("id = ? AND used_at IS NULL AND expires_at > ?", verification.ID, now).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrEmailVerificationInvalid
+		}
+
+		if err := tx.First(&user, verification.UserID).Error; err != nil {
+			return ErrEmailVerificationInvalid
+		}
+		if !strings.EqualFold(user.Email, verification.Email) {
+			return ErrEmailVerificationInvalid
+		}
+		user.EmailVerified = true
+		return tx.Model(&user).Update("email_verified", true).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
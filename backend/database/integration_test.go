@@ -0,0 +1,34 @@
+//go:build integration
+
+package database
+
+import (
+	"os"
+	"testing"
+
+	"todolist/config"
+)
+
+// 外部数据库的测试需要 -tags integration，连接串从环境变量读取，未设置时跳过：
+//
+//	TODOLIST_TEST_POSTGRES_DSN="host=localhost user=todo password=todo dbname=todo_test sslmode=disable" \
+//	TODOLIST_TEST_MYSQL_DSN="todo:todo@tcp(localhost:3306)/todo_test?charset=utf8mb4&parseTime=True&loc=UTC" \
+//	go test -tags integration ./database
+//
+// 测试会执行并回滚全部迁移，请使用专用的空数据库。
+
+func TestPostgres(t *testing.T) {
+	runEngineSuite(t, integrationConfig(t, config.DriverPostgres, "TODOLIST_TEST_POSTGRES_DSN"))
+}
+
+func TestMySQL(t *testing.T) {
+	runEngineSuite(t, integrationConfig(t, config.DriverMySQL, "TODOLIST_TEST_MYSQL_DSN"))
+}
+
+func integrationConfig(t *testing.T, driver, env string) config.DatabaseConfig {
+	dsn := os.Getenv(env)
+	if dsn == "" {
+		t.Skipf("未设置 %s，跳过 %s 测试", env, driver)
+	}
+	return config.DatabaseConfig{Driver: driver, DSN: dsn}
+}
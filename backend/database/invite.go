@@ -0,0 +1,63 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"todolist/models"
+)
+
+var ErrInviteInvalid = errors.New("邀请码无效或已过期")
+
+// CreateInvite 生成邀请码并保存
+func CreateInvite(invite *models.Invite) error {
+	code, err := RandomToken(12)
+	if err != nil {
+		return err
+	}
+	invite.Code = code
+	return DB.Create(invite).Error
+}
+
+// RedeemInvite 在事务中使用一次邀请码，通过条件更新保证并发时不会超出使用次数
+func RedeemInvite(tx *gorm.DB, code string) (*models.Invite, error) {
+	var invite models.Invite
+	if err := tx.Where("code = ?", code).First(&invite).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInviteInvalid
+		}
+		return nil, err
+	}
+	if !invite.IsValid() {
+		return nil, ErrInviteInvalid
+	}
+
+	result := tx.Model(&models.Invite{}).
+		Where("id = ? AND revoked_at IS NULL AND (max_uses = 0 OR uses < max_uses)", invite.ID).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now().UTC()).
+		Update("uses", gorm.Expr("uses + 1"))
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInviteInvalid
+	}
+	invite.Uses++
+	return &invite, nil
+}
+
+// ListInvites 列出所有未吊销的邀请码
+func ListInvites() ([]models.Invite, error) {
+	invites := []models.Invite{}
+	err := DB.Where("revoked_at IS NULL").Order("id DESC").Find(&invites).Error
+	return invites, err
+}
+
+// RevokeInvite 吊销邀请码，返回是否存在
+func RevokeInvite(id uint) (bool, error) {
+	result := DB.Model(&models.Invite{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now().UTC())
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,143 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"todolist/models"
+	"todolist/notify"
+)
+
+// Inbox 将通知保存为站内信，实现 notify.Inbox
+type Inbox struct{}
+
+func (Inbox) Add(ctx context.Context, to notify.Recipient, msg notify.Message) error {
+	n := &models.Notification{UserID: to.UserID, Title: msg.Title, Body: msg.Body}
+	if msg.TodoID != 0 {
+		n.TodoID = &msg.TodoID
+	}
+	if msg.ReminderID != 0 {
+		n.ReminderID = &msg.ReminderID
+	}
+	return DB.WithContext(ctx).Create(n).Error
+}
+
+// GetNotificationSettings 获取用户的通知设置，没有设置过时返回默认值（只发送站内信）
+func GetNotificationSettings(userID uint) (*models.NotificationSettings, error) {
+	settings := &models.NotificationSettings{UserID: userID}
+	err := DB.Where("user_id = ?", userID).First(settings).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		settings.DefaultChannels = models.StringSlice{notify.ChannelInApp}
+		return settings, nil
+	}
+	return settings, err
+}
+
+// RescheduleReminders 待办事项的时间修改后，重新计算其未发送提醒的发送时间
+func RescheduleReminders(todo *models.Todo) error {
+	var reminders []models.Reminder
+	if err := DB.Where("todo_id = ? AND status = ?", todo.ID, models.ReminderPending).Find(&reminders).Error; err != nil {
+		return err
+	}
+	for i := range reminders {
+		before := reminders[i].FireAt
+		reminders[i].Schedule(todo)
+		if sameTime(before, reminders[i].FireAt) {
+			continue
+		}
+		if err := DB.Model(&reminders[i]).Update("fire_at", reminders[i].FireAt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sameTime(a, b *models.CustomTime) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(b.Time)
+}
+
+// copyRelativeReminders 把 from 的相对提醒复制给新生成的重复 to，按 to 的时间计算发送时间。
+// 提醒仍属于设置它的用户，已无权查看 to 的用户（如已离开项目）的提醒不再复制
+func copyRelativeReminders(tx *gorm.DB, from uint, to *models.Todo) error {
+	var reminders []models.Reminder
+	if err := tx.Where("todo_id = ? AND anchor <> ?", from, "").Find(&reminders).Error; err != nil {
+		return err
+	}
+	canView := map[uint]bool{}
+	for _, r := range reminders {
+		ok, seen := canView[r.UserID]
+		if !seen {
+			var err error
+			if ok, err = canViewTodo(tx, to, r.UserID); err != nil {
+				return err
+			}
+			canView[r.UserID] = ok
+		}
+		if !ok {
+			continue
+		}
+		copied := models.Reminder{
+			TodoID:        to.ID,
+			UserID:        r.UserID,
+			Anchor:        r.Anchor,
+			OffsetMinutes: r.OffsetMinutes,
+			Channels:      r.Channels,
+			Status:        models.ReminderPending,
+			Delivered:     models.StringSlice{},
+		}
+		copied.Schedule(to)
+		if err := tx.Create(&copied).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// canViewTodo 判断用户是否可以查看待办事项：不属于项目时只有创建者可以查看，否则需要是项目成员
+func canViewTodo(tx *gorm.DB, todo *models.Todo, userID uint) (bool, error) {
+	if todo.ProjectID == nil {
+		return todo.UserID == userID, nil
+	}
+	var member models.ProjectMember
+	if err := tx.Where("project_id = ? AND user_id = ?", *todo.ProjectID, userID).Limit(1).Find(&member).Error; err != nil {
+		return false, err
+	}
+	return models.ProjectRoleAllows(member.Role, models.ProjectViewer), nil
+}
+
+// ClaimDueReminder 领取一个已到发送时间的提醒并标记为发送中，没有到期的提醒时返回 nil。
+// 领取是带状态条件的更新，多个实例同时领取时每个提醒只会被一个实例领取；
+// 领取时刷新 updated_at，调用方应立即发送，在 ReleaseStaleReminders 的超时之前完成
+func ClaimDueReminder(now time.Time) (*models.Reminder, error) {
+	for {
+		var r models.Reminder
+		if err := DB.Where("status = ? AND fire_at IS NOT NULL AND fire_at <= ?", models.ReminderPending, now.UTC()).
+			Order("fire_at, id").Limit(1).Find(&r).Error; err != nil {
+			return nil, err
+		}
+		if r.ID == 0 {
+			return nil, nil
+		}
+		result := DB.Model(&models.Reminder{}).Where("id = ? AND status = ?", r.ID, models.ReminderPending).
+			Updates(map[string]interface{}{"status": models.ReminderSending, "updated_at": time.Now().UTC()})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		// 已被其他实例领取时领取下一个
+		if result.RowsAffected == 1 {
+			r.Status = models.ReminderSending
+			return &r, nil
+		}
+	}
+}
+
+// ReleaseStaleReminders 将 before 之前领取但未完成的提醒（如发送过程中进程退出）恢复为等待发送
+func ReleaseStaleReminders(before time.Time) error {
+	return DB.Model(&models.Reminder{}).Where("status = ? AND updated_at < ?", models.ReminderSending, before.UTC()).
+		Update("status", models.ReminderPending).Error
+}
@@ -0,0 +1,191 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"todolist/config"
+	"todolist/models"
+)
+
+func openSQLite(t *testing.T) {
+	openTestDB(t, config.DatabaseConfig{
+		Driver: config.DriverSQLite,
+		DSN:    filepath.Join(t.TempDir(), "test.db"),
+	})
+}
+
+// addReminder 为用户在待办事项上添加相对开始时间的提醒
+func addReminder(t *testing.T, todo *models.Todo, userID uint, offset int) {
+	t.Helper()
+	r := models.Reminder{
+		TodoID:        todo.ID,
+		UserID:        userID,
+		Anchor:        models.ReminderAnchorStart,
+		OffsetMinutes: offset,
+		Channels:      models.StringSlice{"in_app"},
+		Status:        models.ReminderPending,
+		Delivered:     models.StringSlice{},
+	}
+	r.Schedule(todo)
+	if err := DB.Create(&r).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAdvanceSeriesCopiesMemberReminders(t *testing.T) {
+	openSQLite(t)
+	owner, err := CreateUser("owner", "Secret123!", "user", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	member, err := CreateUser("member", "Secret123!", "user", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	former, err := CreateUser("former", "Secret123!", "user", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	project := &models.Project{UserID: owner.ID, Name: "共享"}
+	if err := CreateProject(project); err != nil {
+		t.Fatal(err)
+	}
+	formerMember := &models.ProjectMember{ProjectID: project.ID, UserID: former.ID, Role: models.ProjectEditor}
+	for _, m := range []*models.ProjectMember{
+		{ProjectID: project.ID, UserID: member.ID, Role: models.ProjectViewer},
+		formerMember,
+	} {
+		if err := DB.Create(m).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start := time.Now().Add(time.Hour).Truncate(time.Minute).UTC()
+	todo := &models.Todo{Title: "站会", UserID: owner.ID, ProjectID: &project.ID, StartTime: models.CustomTime{Time: start}}
+	if err := CreateRecurringTodo(todo, "FREQ=DAILY", nil, time.UTC); err != nil {
+		t.Fatal(err)
+	}
+	addReminder(t, todo, owner.ID, -10)
+	addReminder(t, todo, member.ID, -15)
+	addReminder(t, todo, former.ID, -5)
+	// 失去访问权限的用户的提醒不再复制
+	if err := DB.Delete(formerMember).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := AdvanceSeries(*todo.RecurrenceID, time.UTC, start.Add(36*time.Hour))
+	if err != nil || n != 1 {
+		t.Fatalf("应当生成 1 次重复: %d %v", n, err)
+	}
+	var next models.Todo
+	if err := DB.Where("recurrence_id = ? AND id <> ?", todo.RecurrenceID, todo.ID).First(&next).Error; err != nil {
+		t.Fatal(err)
+	}
+	var copied []models.Reminder
+	if err := DB.Where("todo_id = ?", next.ID).Order("user_id").Find(&copied).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(copied) != 2 {
+		t.Fatalf("应当复制 2 个提醒，实际 %d 个: %+v", len(copied), copied)
+	}
+	want := map[uint]int{owner.ID: -10, member.ID: -15}
+	for _, r := range copied {
+		offset, ok := want[r.UserID]
+		if !ok || r.OffsetMinutes != offset {
+			t.Errorf("提醒复制给了错误的用户: user=%d offset=%d", r.UserID, r.OffsetMinutes)
+		}
+		fireAt := next.StartTime.Add(time.Duration(offset) * time.Minute)
+		if r.FireAt == nil || !r.FireAt.Equal(fireAt) {
+			t.Errorf("用户 %d 的提醒时间应为 %v，实际 %v", r.UserID, fireAt, r.FireAt)
+		}
+	}
+}
+
+// dueReminder 创建发送时间为 fireAt 的绝对提醒
+func dueReminder(t *testing.T, todoID, userID uint, fireAt time.Time, status string) *models.Reminder {
+	t.Helper()
+	at := models.CustomTime{Time: fireAt.UTC()}
+	r := &models.Reminder{
+		TodoID:    todoID,
+		UserID:    userID,
+		RemindAt:  &at,
+		FireAt:    &at,
+		Channels:  models.StringSlice{"in_app"},
+		Status:    status,
+		Delivered: models.StringSlice{},
+	}
+	if err := DB.Create(r).Error; err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func reminderStatus(t *testing.T, id uint) string {
+	t.Helper()
+	var r models.Reminder
+	if err := DB.First(&r, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return r.Status
+}
+
+func TestClaimAndReleaseReminders(t *testing.T) {
+	openSQLite(t)
+	user, err := CreateUser("alice", "Secret123!", "user", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	todo := &models.Todo{Title: "提醒", UserID: user.ID}
+	if err := SaveTodo(DB, todo, nil); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	second := dueReminder(t, todo.ID, user.ID, now.Add(-time.Minute), models.ReminderPending)
+	first := dueReminder(t, todo.ID, user.ID, now.Add(-2*time.Minute), models.ReminderPending)
+	future := dueReminder(t, todo.ID, user.ID, now.Add(time.Hour), models.ReminderPending)
+	sent := dueReminder(t, todo.ID, user.ID, now.Add(-3*time.Minute), models.ReminderSent)
+
+	// 按发送时间依次领取，每个到期的提醒只能领取一次
+	for _, want := range []*models.Reminder{first, second} {
+		r, err := ClaimDueReminder(now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r == nil || r.ID != want.ID || r.Status != models.ReminderSending {
+			t.Fatalf("应当领取提醒 %d，实际 %+v", want.ID, r)
+		}
+	}
+	if r, err := ClaimDueReminder(now); err != nil || r != nil {
+		t.Fatalf("没有到期的提醒时应当返回 nil: %+v %v", r, err)
+	}
+
+	// 刚领取的提醒不会被当作中断的发送恢复
+	if err := ReleaseStaleReminders(now.Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if s := reminderStatus(t, first.ID); s != models.ReminderSending {
+		t.Fatalf("刚领取的提醒被恢复为 %s", s)
+	}
+
+	// 超时后恢复为等待发送，重复恢复不影响结果，也不影响其他状态的提醒
+	for i := 0; i < 2; i++ {
+		if err := ReleaseStaleReminders(time.Now().Add(time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for id, want := range map[uint]string{
+		first.ID:  models.ReminderPending,
+		second.ID: models.ReminderPending,
+		future.ID: models.ReminderPending,
+		sent.ID:   models.ReminderSent,
+	} {
+		if s := reminderStatus(t, id); s != want {
+			t.Errorf("提醒 %d 的状态应为 %s，实际 %s", id, want, s)
+		}
+	}
+	if r, err := ClaimDueReminder(now); err != nil || r == nil || r.ID != first.ID {
+		t.Fatalf("恢复后应当可以重新领取: %+v %v", r, err)
+	}
+}
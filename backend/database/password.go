@@ -0,0 +1,67 @@
+package database
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"todolist/models"
+)
+
+var ErrPasswordReused = errors.New("不能使用最近用过的密码")
+
+// PasswordReused 判断 plain 是否与用户最近 history 次使用过的密码（含当前密码）相同，history 为 0 时不检查
+func PasswordReused(user *models.User, plain string, history int) (bool, error) {
+	if history <= 0 || user.ID == 0 {
+		return false, nil
+	}
+	if user.Password != "" && user.CheckPassword(plain) == nil {
+		return true, nil
+	}
+	if history == 1 {
+		return false, nil
+	}
+
+	var hashes []string
+	if err := DB.Model(&models.PasswordHistory{}).
+		Where("user_id = ?", user.ID).
+		Order("id DESC").
+		Limit(history-1).
+		Pluck("hash", &hashes).Error; err != nil {
+		return false, err
+	}
+	for _, hash := range hashes {
+		old := models.User{Password: hash}
+		if old.CheckPassword(plain) == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// SetPassword 将用户密码更新为 newHash（已加密的密码），当前密码写入历史记录并只保留最近 history-1 条，
+// mustChange 表示用户下次登录后是否必须修改密码
+func SetPassword(tx *gorm.DB, user *models.User, newHash string, history int, mustChange bool) error {
+	if history > 1 && user.Password != "" {
+		if err := tx.Create(&models.PasswordHistory{UserID: user.ID, Hash: user.Password}).Error; err != nil {
+			return err
+		}
+		var stale []uint
+		if err := tx.Model(&models.PasswordHistory{}).
+			Where("user_id = ?", user.ID).
+			Order("id DESC").
+			Offset(history-1).
+			Limit(1000).
+			Pluck("id", &stale).Error; err != nil {
+			return err
+		}
+		if len(stale) > 0 {
+			if err := tx.Delete(&models.PasswordHistory{}, stale).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Model(user).Updates(map[string]interface{}{
+		"password":             newHash,
+		"must_change_password": mustChange,
+	}).Error
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"todolist/models"
+)
+
+var ErrPasswordResetInvalid = errors.New("重置链接无效或已过期")
+
+// CreatePasswordReset 为用户签发找回密码令牌并使之前未使用的令牌失效，返回令牌原文
+func CreatePasswordReset(userID uint, ip string, ttl time.Duration) (string, error) {
+	raw, err := RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now().UTC()
+
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.PasswordReset{}).
+			Where("user_id = ? AND used_at IS NULL", userID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PasswordReset{
+			UserID:    userID,
+			TokenHash: HashToken(raw),
+			IP:        ip,
+			ExpiresAt: now.Add(ttl),
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// PasswordResetUser 返回有效的找回密码令牌对应的用户，不使用令牌
+func PasswordResetUser(raw string) (*models.User, error) {
+	var reset models.PasswordReset
+	if err := DB.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", HashToken(raw), time.Now().UTC()).
+		First(&reset).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPasswordResetInvalid
+		}
+		return nil, err
+	}
+	var user models.User
+	if err := DB.First(&user, reset.UserID).Error; err != nil {
+		return nil, ErrPasswordResetInvalid
+	}
+	return &user, nil
+}
+
+// ResetPasswordWithToken 校验并使用找回密码令牌，将密码更新为 newHash（已加密的密码），history 含义同 SetPassword
+func ResetPasswordWithToken(raw, newHash string, history int) (*models.User, error) {
+	var user models.User
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var reset models.PasswordReset
+		if err := tx.Where("token_hash = ?", HashToken(raw)).First(&reset).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPasswordResetInvalid
+			}
+			return err
+		}
+
+		// 条件更新保证令牌只能使用一次
+		now := time.Now().UTC()
+		result := tx.Model(&models.PasswordReset{}).
+			Where("id = ? AND used_at IS NULL AND expires_at > ?", reset.ID, now).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPasswordResetInvalid
+		}
+
+		if err := tx.First(&user, reset.UserID).Error; err != nil {
+			return ErrPasswordResetInvalid
+		}
+		return SetPassword(tx, &user, newHash, history, false)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"testing"
+
+	"todolist/models"
+)
+
+// changePassword 按 history 保留历史记录并把密码改为 plain，返回重新读取的用户
+func changePassword(t *testing.T, user *models.User, plain string, history int) *models.User {
+	t.Helper()
+	hashed := models.User{Password: plain}
+	if err := hashed.HashPassword(); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetPassword(DB, user, hashed.Password, history, false); err != nil {
+		t.Fatal(err)
+	}
+	var reloaded models.User
+	if err := DB.First(&reloaded, user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &reloaded
+}
+
+func TestPasswordReused(t *testing.T) {
+	openSQLite(t)
+
+	const history = 3
+	user, err := CreateUser("alice", "Passw0rd-0", models.RoleUser, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pw := range []string{"Passw0rd-1", "Passw0rd-2", "Passw0rd-3"} {
+		user = changePassword(t, user, pw, history)
+	}
+	if user.MustChangePassword {
+		t.Fatal("修改密码后应当解除必须修改密码的要求")
+	}
+
+	// 只保留当前密码之外的 history-1 条历史
+	var count int64
+	DB.Model(&models.PasswordHistory{}).Where("user_id = ?", user.ID).Count(&count)
+	if count != history-1 {
+		t.Fatalf("保留了 %d 条历史密码，期望 %d", count, history-1)
+	}
+
+	tests := []struct {
+		password string
+		history  int
+		want     bool
+	}{
+		{"Passw0rd-3", history, true}, // 当前密码
+		{"Passw0rd-2", history, true},
+		{"Passw0rd-1", history, true},
+		{"Passw0rd-0", history, false}, // 已超出历史范围
+		{"Passw0rd-9", history, false},
+		{"Passw0rd-3", 1, true},
+		{"Passw0rd-2", 1, false},
+		{"Passw0rd-3", 0, false}, // 不检查
+	}
+	for _, tt := range tests {
+		reused, err := PasswordReused(user, tt.password, tt.history)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reused != tt.want {
+			t.Errorf("PasswordReused(%q, %d) = %v，期望 %v", tt.password, tt.history, reused, tt.want)
+		}
+	}
+
+	// 新用户没有历史记录
+	if reused, err := PasswordReused(&models.User{}, "Passw0rd-3", history); err != nil || reused {
+		t.Fatalf("新用户不应检查历史密码: reused=%v err=%v", reused, err)
+	}
+}
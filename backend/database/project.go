@@ -0,0 +1,234 @@
+package database
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"todolist/models"
+)
+
+// ErrLastProjectOwner 操作会使项目没有所有者
+var ErrLastProjectOwner = errors.New("项目至少需要一个所有者")
+
+// ProjectRole 返回用户在项目中的角色，不是成员时返回空字符串
+func ProjectRole(projectID, userID uint) (string, error) {
+	var member models.ProjectMember
+	err := DB.Where("project_id = ? AND user_id = ?", projectID, userID).Limit(1).Find(&member).Error
+	return member.Role, err
+}
+
+// AccessibleTodos 限定为用户可以访问的待办事项：不属于项目的本人待办事项，以及用户所在项目中的全部待办事项
+func AccessibleTodos(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Where("((project_id IS NULL AND user_id = ?) OR project_id IN (?))", userID,
+		DB.Model(&models.ProjectMember{}).Select("project_id").Where("user_id = ?", userID))
+}
+
+// CreateProject 创建项目，创建者成为所有者
+func CreateProject(project *models.Project) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(project).Error; err != nil {
+			return err
+		}
+		project.Role = models.ProjectOwner
+		return tx.Create(&models.ProjectMember{
+			ProjectID: project.ID,
+			UserID:    project.UserID,
+			Role:      models.ProjectOwner,
+		}).Error
+	})
+}
+
+// UpdateProjectMember 修改成员角色，不能把最后一个所有者降级
+func UpdateProjectMember(member *models.ProjectMember, role string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if member.Role == models.ProjectOwner && role != models.ProjectOwner {
+			if err := checkOtherOwner(tx, member); err != nil {
+				return err
+			}
+		}
+		member.Role = role
+		return tx.Save(member).Error
+	})
+}
+
+// RemoveProjectMember 移除成员（或成员退出），同时删除其在项目待办事项上设置的提醒；不能移除最后一个所有者
+func RemoveProjectMember(member *models.ProjectMember) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if member.Role == models.ProjectOwner {
+			if err := checkOtherOwner(tx, member); err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("user_id = ? AND todo_id IN (?)", member.UserID,
+			tx.Model(&models.Todo{}).Select("id").Where("project_id = ?", member.ProjectID)).
+			Delete(&models.Reminder{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(member).Error
+	})
+}
+
+// checkOtherOwner 检查项目中除 member 外是否还有其他所有者
+func checkOtherOwner(tx *gorm.DB, member *models.ProjectMember) error {
+	var count int64
+	if err := tx.Model(&models.ProjectMember{}).Where("project_id = ? AND role = ? AND id <> ?",
+		member.ProjectID, models.ProjectOwner, member.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrLastProjectOwner
+	}
+	return nil
+}
+
+// AcceptProjectInvitation 接受邀请，被邀请的用户成为成员，邀请随之删除
+func AcceptProjectInvitation(invitation *models.ProjectInvitation) (*models.ProjectMember, error) {
+	member := &models.ProjectMember{
+		ProjectID: invitation.ProjectID,
+		UserID:    invitation.InviteeID,
+		Role:      invitation.Role,
+	}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(invitation).Error; err != nil {
+			return err
+		}
+		return tx.Create(member).Error
+	})
+	return member, err
+}
+
+// CountOpenTodos 统计各项目中未完成的待办事项数量
+func CountOpenTodos(projects []models.Project) error {
+	if len(projects) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(projects))
+	for i, p := range projects {
+		ids[i] = p.ID
+	}
+	var rows []struct {
+		ProjectID uint
+		Count     int64
+	}
+	if err := DB.Model(&models.Todo{}).Select("project_id, COUNT(*) AS count").
+		Where("project_id IN ? AND completed = ?", ids, false).Group("project_id").Scan(&rows).Error; err != nil {
+		return err
+	}
+	counts := make(map[uint]int64, len(rows))
+	for _, r := range rows {
+		counts[r.ProjectID] = r.Count
+	}
+	for i := range projects {
+		projects[i].TodoCount = counts[projects[i].ID]
+	}
+	return nil
+}
+
+// DeleteProject 删除项目，mode 为其中待办事项（和重复任务系列）的处理方式：
+// keep 移出项目，move 移动到 target 项目，delete 一并删除
+func DeleteProject(project *models.Project, mode string, target *uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		switch mode {
+		case models.ProjectTodosDelete:
+			var seriesIDs []uint
+			if err := tx.Model(&models.Todo{}).Where("project_id = ? AND recurrence_id IS NOT NULL", project.ID).
+				Distinct().Pluck("recurrence_id", &seriesIDs).Error; err != nil {
+				return err
+			}
+			if err := deleteTodos(tx, "project_id = ?", project.ID); err != nil {
+				return err
+			}
+			// 待办事项全部删除的系列一并删除，避免重新生成
+			if err := tx.Where("id IN ? AND id NOT IN (?)", seriesIDs,
+				tx.Model(&models.Todo{}).Select("recurrence_id").Where("recurrence_id IS NOT NULL")).
+				Delete(&models.Recurrence{}).Error; err != nil {
+				return err
+			}
+		default:
+			if mode != models.ProjectTodosMove {
+				target = nil
+			}
+			// 移出后无法再访问这些待办事项的用户，其提醒一并删除
+			lost := tx.Model(&models.Todo{}).Select("id").Where("project_id = ?", project.ID)
+			if target == nil {
+				lost = lost.Where("todos.user_id <> reminders.user_id")
+			} else {
+				lost = lost.Where("reminders.user_id NOT IN (?)",
+					tx.Model(&models.ProjectMember{}).Select("user_id").Where("project_id = ?", *target))
+			}
+			if err := tx.Where("todo_id IN (?)", lost).Delete(&models.Reminder{}).Error; err != nil {
+				return err
+			}
+			for _, model := range []interface{}{&models.Todo{}, &models.Recurrence{}} {
+				if err := tx.Model(model).Where("project_id = ?", project.ID).Update("project_id", target).Error; err != nil {
+					return err
+				}
+			}
+		}
+		// 待办事项已移出或删除，剩余系列的模板不再引用该项目
+		if err := tx.Model(&models.Recurrence{}).Where("project_id = ?", project.ID).Update("project_id", nil).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&models.ProjectMember{}, &models.ProjectInvitation{}} {
+			if err := tx.Where("project_id = ?", project.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(project).Error
+	})
+}
+
+// leaveProjects 注销账号前退出全部项目：用户是唯一所有者时由最早加入的成员接任，
+// 用户在仍有成员的项目中创建的待办事项和重复系列转给项目的所有者，没有其他成员的项目被删除，
+// 其中其他用户的待办事项移出项目
+func leaveProjects(tx *gorm.DB, userID uint) error {
+	var memberships []models.ProjectMember
+	if err := tx.Where("user_id = ?", userID).Find(&memberships).Error; err != nil {
+		return err
+	}
+	for i := range memberships {
+		m := &memberships[i]
+		var others []models.ProjectMember
+		if err := tx.Where("project_id = ? AND user_id <> ?", m.ProjectID, userID).Order("id").Find(&others).Error; err != nil {
+			return err
+		}
+
+		if len(others) == 0 {
+			for _, model := range []interface{}{&models.Todo{}, &models.Recurrence{}} {
+				if err := tx.Model(model).Where("project_id = ? AND user_id <> ?", m.ProjectID, userID).
+					Update("project_id", nil).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Where("project_id = ?", m.ProjectID).Delete(&models.ProjectInvitation{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&models.Project{}, m.ProjectID).Error; err != nil {
+				return err
+			}
+		} else {
+			heir := &others[0]
+			for j := range others {
+				if others[j].Role == models.ProjectOwner {
+					heir = &others[j]
+					break
+				}
+			}
+			if heir.Role != models.ProjectOwner {
+				if err := tx.Model(heir).Update("role", models.ProjectOwner).Error; err != nil {
+					return err
+				}
+			}
+			for _, model := range []interface{}{&models.Todo{}, &models.Recurrence{}} {
+				if err := tx.Model(model).Where("project_id = ? AND user_id = ?", m.ProjectID, userID).
+					Update("user_id", heir.UserID).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if err := tx.Delete(m).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Where("invitee_id = ? OR inviter_id = ?", userID, userID).Delete(&models.ProjectInvitation{}).Error
+}
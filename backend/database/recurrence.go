@@ -0,0 +1,323 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"todolist/models"
+	"todolist/rrule"
+)
+
+var ErrRecurrenceEmpty = errors.New("重复规则没有可用的日期")
+
+// maxOccurrencesPerRun 每个系列每次最多生成的次数，避免开始时间很早的规则一次生成过多
+const maxOccurrencesPerRun = 100
+
+// eachOccurrence 按时间顺序遍历系列中晚于 after 且未跳过的日期（按 loc 时区展开规则），fn 返回 false 时停止
+func eachOccurrence(rec *models.Recurrence, loc *time.Location, after time.Time, fn func(time.Time) bool) error {
+	rule, err := rrule.Parse(rec.RRule)
+	if err != nil {
+		return err
+	}
+	it := rule.Iter(rec.DTStart.In(loc))
+	for t, ok := it.Next(); ok; t, ok = it.Next() {
+		if !t.After(after) || rec.IsExcluded(t) {
+			continue
+		}
+		if !fn(t) {
+			break
+		}
+	}
+	return nil
+}
+
+// UpcomingOccurrences 返回系列中晚于 after 的至多 limit 个日期，包括尚未生成的
+func UpcomingOccurrences(rec *models.Recurrence, loc *time.Location, after time.Time, limit int) ([]time.Time, error) {
+	var times []time.Time
+	err := eachOccurrence(rec, loc, after, func(t time.Time) bool {
+		times = append(times, t.UTC())
+		return len(times) < limit
+	})
+	return times, err
+}
+
+// CreateRecurringTodo 创建重复任务系列，todo 作为系列的第一次一起创建
+func CreateRecurringTodo(todo *models.Todo, rule string, exdates []models.CustomTime, loc *time.Location) error {
+	if todo.StartTime.IsZero() {
+		todo.StartTime = models.CustomTime{Time: time.Now().UTC()}
+	}
+	rec := &models.Recurrence{UserID: todo.UserID, RRule: rule, DTStart: todo.StartTime}
+	rec.SetExDates(exdates)
+	rec.SetTemplate(todo)
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := startSeries(tx, todo, rec, loc); err != nil {
+			return err
+		}
+		return tx.Set(models.LocationKey, loc).Create(todo).Error
+	})
+	if err != nil {
+		return err
+	}
+	todo.Recurrence = rec
+	return nil
+}
+
+// MakeRecurring 把已有的普通待办事项变为重复任务系列的第一次
+func MakeRecurring(todo *models.Todo, rule string, exdates []models.CustomTime, loc *time.Location) error {
+	rec := &models.Recurrence{UserID: todo.UserID, RRule: rule, DTStart: todo.StartTime}
+	rec.SetExDates(exdates)
+	rec.SetTemplate(todo)
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := startSeries(tx, todo, rec, loc); err != nil {
+			return err
+		}
+		return SaveTodo(tx, todo, loc)
+	})
+	if err != nil {
+		return err
+	}
+	todo.Recurrence = rec
+	return nil
+}
+
+// startSeries 保存新系列并把 todo 设为它的第一次。第一次为规则中第一个未跳过的日期，
+// 与 todo 的开始时间不同时连同结束时间一起平移；todo 本身由调用方保存
+func startSeries(tx *gorm.DB, todo *models.Todo, rec *models.Recurrence, loc *time.Location) error {
+	var first time.Time
+	if err := eachOccurrence(rec, loc, time.Time{}, func(t time.Time) bool {
+		first = t.UTC()
+		return false
+	}); err != nil {
+		return err
+	}
+	if first.IsZero() {
+		return ErrRecurrenceEmpty
+	}
+	if shift := first.Sub(todo.StartTime.Time); shift != 0 {
+		todo.StartTime = models.CustomTime{Time: first}
+		if todo.EndTime != nil {
+			todo.EndTime = &models.CustomTime{Time: todo.EndTime.Add(shift)}
+		}
+	}
+
+	rec.LastOccurrence = models.CustomTime{Time: first}
+	if err := tx.Create(rec).Error; err != nil {
+		return err
+	}
+	todo.RecurrenceID = &rec.ID
+	todo.OccurrenceAt = &models.CustomTime{Time: first}
+	todo.Recurrence = nil
+	return nil
+}
+
+// UpdateFollowing 保存对重复任务这一次及之后的修改：原系列截止到这一次之前，从这一次起按 todo 修改后的内容
+// 开始新的系列，原系列中之后已生成但未完成的重复会被删除，由新系列重新生成。
+// rule 为 nil 时沿用原规则（COUNT 扣除这一次之前的次数），为空字符串时从这一次起不再重复；
+// exdates 为 nil 时保留原系列中这一次及之后的跳过日期
+func UpdateFollowing(todo *models.Todo, rule *string, exdates []models.CustomTime, loc *time.Location) error {
+	var rec *models.Recurrence
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var old models.Recurrence
+		if err := tx.First(&old, *todo.RecurrenceID).Error; err != nil {
+			return err
+		}
+		occ := todo.OccurrenceAt.Time
+
+		newRule := ""
+		if rule != nil {
+			newRule = *rule
+		} else {
+			oldRule, err := rrule.Parse(old.RRule)
+			if err != nil {
+				return err
+			}
+			if oldRule.Count > 0 {
+				oldRule.Count -= countBefore(oldRule, old.DTStart.In(loc), occ)
+			}
+			newRule = oldRule.String()
+		}
+
+		if newRule == "" {
+			todo.RecurrenceID, todo.OccurrenceAt, todo.Recurrence = nil, nil, nil
+			if err := SaveTodo(tx, todo, loc); err != nil {
+				return err
+			}
+		} else {
+			rec = &models.Recurrence{UserID: todo.UserID, RRule: newRule, DTStart: todo.StartTime}
+			if exdates != nil {
+				rec.SetExDates(exdates)
+			} else {
+				rec.ExDates = models.StringSlice{}
+				from := occ.UTC().Format(models.TimeFormat)
+				for _, d := range old.ExDates {
+					if d >= from {
+						rec.ExDates = append(rec.ExDates, d)
+					}
+				}
+			}
+			rec.SetTemplate(todo)
+			if err := startSeries(tx, todo, rec, loc); err != nil {
+				return err
+			}
+			if err := SaveTodo(tx, todo, loc); err != nil {
+				return err
+			}
+		}
+		return truncateSeries(tx, &old, occ)
+	})
+	if err != nil {
+		return err
+	}
+	todo.Recurrence = rec
+	return nil
+}
+
+// DeleteOccurrence 删除重复任务的这一次，并把它加入跳过的日期，避免重新生成
+func DeleteOccurrence(todo *models.Todo) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var rec models.Recurrence
+		if err := tx.First(&rec, *todo.RecurrenceID).Error; err != nil {
+			return err
+		}
+		if !rec.IsExcluded(todo.OccurrenceAt.Time) {
+			rec.ExDates = append(rec.ExDates, todo.OccurrenceAt.UTC().Format(models.TimeFormat))
+			if err := tx.Model(&rec).Update("exdates", rec.ExDates).Error; err != nil {
+				return err
+			}
+		}
+		return deleteTodos(tx, "id = ?", todo.ID)
+	})
+}
+
+// DeleteFollowing 删除重复任务的这一次及之后：原系列截止到这一次之前，之后已生成但未完成的重复一并删除
+func DeleteFollowing(todo *models.Todo) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var rec models.Recurrence
+		if err := tx.First(&rec, *todo.RecurrenceID).Error; err != nil {
+			return err
+		}
+		if err := deleteTodos(tx, "id = ?", todo.ID); err != nil {
+			return err
+		}
+		return truncateSeries(tx, &rec, todo.OccurrenceAt.Time)
+	})
+}
+
+// truncateSeries 让系列截止到 occ 之前，删除 occ 之后已生成但未完成的重复；系列不再有待办事项时一并删除
+func truncateSeries(tx *gorm.DB, rec *models.Recurrence, occ time.Time) error {
+	rule, err := rrule.Parse(rec.RRule)
+	if err != nil {
+		return err
+	}
+	rule.Count = 0
+	rule.Until = occ.Add(-time.Second).UTC()
+	rule.UntilDate = false
+	rec.RRule = rule.String()
+
+	if err := deleteTodos(tx, "recurrence_id = ? AND completed = ? AND occurrence_at > ?", rec.ID, false, occ); err != nil {
+		return err
+	}
+	return saveOrDeleteSeries(tx, rec)
+}
+
+// saveOrDeleteSeries 系列仍有待办事项时保存，否则删除
+func saveOrDeleteSeries(tx *gorm.DB, rec *models.Recurrence) error {
+	var count int64
+	if err := tx.Model(&models.Todo{}).Where("recurrence_id = ?", rec.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return tx.Delete(rec).Error
+	}
+	return tx.Save(rec).Error
+}
+
+// countBefore 统计规则中早于 occ 的次数（包括跳过的日期，与 RFC 5545 中 COUNT 的计算方式一致）
+func countBefore(rule *rrule.Rule, dtstart, occ time.Time) int {
+	n := 0
+	it := rule.Iter(dtstart)
+	for t, ok := it.Next(); ok && t.Before(occ); t, ok = it.Next() {
+		n++
+	}
+	return n
+}
+
+// AdvanceSeries 生成系列中开始时间不晚于 horizon 的重复；没有未完成的重复时至少生成下一次。
+// horizon 为零值时只在没有未完成的重复时生成下一次。规则已结束且没有待办事项的系列会被删除，返回生成的数量
+func AdvanceSeries(id uint, loc *time.Location, horizon time.Time) (int, error) {
+	created := 0
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var rec models.Recurrence
+		if err := tx.First(&rec, id).Error; err != nil {
+			return err
+		}
+		var open int64
+		if err := tx.Model(&models.Todo{}).Where("recurrence_id = ? AND completed = ?", rec.ID, false).Count(&open).Error; err != nil {
+			return err
+		}
+
+		// 新的一次沿用最近一次的相对提醒
+		var latest models.Todo
+		if err := tx.Where("recurrence_id = ?", rec.ID).Order("occurrence_at DESC").Limit(1).Find(&latest).Error; err != nil {
+			return err
+		}
+
+		var createErr error
+		if err := eachOccurrence(&rec, loc, rec.LastOccurrence.Time, func(t time.Time) bool {
+			if created >= maxOccurrencesPerRun || open > 0 && t.After(horizon) {
+				return false
+			}
+			todo := rec.NewOccurrence(t)
+			if createErr = tx.Set(models.LocationKey, loc).Create(todo).Error; createErr != nil {
+				return false
+			}
+			if latest.ID != 0 {
+				if createErr = copyRelativeReminders(tx, latest.ID, todo); createErr != nil {
+					return false
+				}
+			}
+			rec.LastOccurrence = models.CustomTime{Time: t.UTC()}
+			created++
+			open++
+			return true
+		}); err != nil {
+			return err
+		}
+		if createErr != nil {
+			return createErr
+		}
+		return saveOrDeleteSeries(tx, &rec)
+	})
+	return created, err
+}
+
+// AdvanceAllSeries 为全部系列生成开始时间在 lookahead 之内的重复，按各自用户的时区展开。
+// 单个系列失败不影响其他系列，返回生成的数量和遇到的第一个错误
+func AdvanceAllSeries(lookahead time.Duration) (int, error) {
+	var series []models.Recurrence
+	if err := DB.Select("id", "user_id").Order("id").Find(&series).Error; err != nil {
+		return 0, err
+	}
+	horizon := time.Now().Add(lookahead)
+	locations := map[uint]*time.Location{}
+	total := 0
+	var firstErr error
+	for _, rec := range series {
+		loc, ok := locations[rec.UserID]
+		if !ok {
+			var user models.User
+			if err := DB.First(&user, rec.UserID).Error; err != nil {
+				continue
+			}
+			loc = user.Location()
+			locations[rec.UserID] = loc
+		}
+		n, err := AdvanceSeries(rec.ID, loc, horizon)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		total += n
+	}
+	return total, firstErr
+}
@@ -0,0 +1,215 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"todolist/models"
+)
+
+// seriesStart 第一次的开始时间，使用未来的时间让提前生成的范围可控
+var seriesStart = time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+
+// createSeries 为新用户创建从 seriesStart 开始的重复任务
+func createSeries(t *testing.T, rule string, exdates ...time.Time) *models.Todo {
+	t.Helper()
+	user, err := CreateUser("alice", "Secret123!", "user", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ex []models.CustomTime
+	for _, d := range exdates {
+		ex = append(ex, models.CustomTime{Time: d})
+	}
+	todo := &models.Todo{Title: "晨跑", UserID: user.ID, StartTime: models.CustomTime{Time: seriesStart}}
+	if err := CreateRecurringTodo(todo, rule, ex, time.UTC); err != nil {
+		t.Fatal(err)
+	}
+	return todo
+}
+
+// occurrences 返回系列中已生成的各次的原始开始日期（MM-DD）
+func occurrences(t *testing.T, recID uint) []string {
+	t.Helper()
+	var todos []models.Todo
+	if err := DB.Where("recurrence_id = ?", recID).Order("occurrence_at").Find(&todos).Error; err != nil {
+		t.Fatal(err)
+	}
+	days := []string{}
+	for _, todo := range todos {
+		days = append(days, todo.OccurrenceAt.Format("01-02"))
+	}
+	return days
+}
+
+func day(n int) time.Time {
+	return seriesStart.AddDate(0, 0, n)
+}
+
+func TestRecurrenceExDates(t *testing.T) {
+	openSQLite(t)
+	// 第一次被跳过时系列从下一个日期开始
+	todo := createSeries(t, "FREQ=DAILY;COUNT=5", day(0), day(2))
+	if !todo.StartTime.Equal(day(1)) || !todo.OccurrenceAt.Equal(day(1)) {
+		t.Fatalf("第一次应当平移到 %v，实际 %v", day(1), todo.StartTime)
+	}
+
+	var rec models.Recurrence
+	if err := DB.First(&rec, *todo.RecurrenceID).Error; err != nil {
+		t.Fatal(err)
+	}
+	times, err := UpcomingOccurrences(&rec, time.UTC, todo.OccurrenceAt.Time, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// COUNT 包括跳过的日期
+	if want := []time.Time{day(3), day(4)}; !reflect.DeepEqual(times, want) {
+		t.Errorf("之后的日期应为 %v，实际 %v", want, times)
+	}
+
+	// 全部日期都被跳过时无法创建
+	empty := &models.Todo{Title: "空", UserID: todo.UserID, StartTime: models.CustomTime{Time: seriesStart}}
+	err = CreateRecurringTodo(empty, "FREQ=DAILY;COUNT=2", []models.CustomTime{{Time: day(0)}, {Time: day(1)}}, time.UTC)
+	if err != ErrRecurrenceEmpty {
+		t.Errorf("应当返回 ErrRecurrenceEmpty，实际 %v", err)
+	}
+}
+
+func TestAdvanceSeries(t *testing.T) {
+	openSQLite(t)
+	todo := createSeries(t, "FREQ=DAILY;COUNT=4")
+	id := *todo.RecurrenceID
+
+	// 不提前生成时，有未完成的一次就不生成
+	if n, err := AdvanceSeries(id, time.UTC, time.Time{}); err != nil || n != 0 {
+		t.Fatalf("有未完成的一次时不应生成: %d %v", n, err)
+	}
+	todo.Completed = true
+	if err := SaveTodo(DB, todo, time.UTC); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := AdvanceSeries(id, time.UTC, time.Time{}); err != nil || n != 1 {
+		t.Fatalf("完成后应当生成下一次: %d %v", n, err)
+	}
+
+	// 提前生成 horizon 之内的全部重复，不超过 COUNT
+	if n, err := AdvanceSeries(id, time.UTC, day(2)); err != nil || n != 1 {
+		t.Fatalf("应当生成到 horizon 为止: %d %v", n, err)
+	}
+	if n, err := AdvanceSeries(id, time.UTC, day(30)); err != nil || n != 1 {
+		t.Fatalf("应当只生成到 COUNT 为止: %d %v", n, err)
+	}
+	if got, want := occurrences(t, id), []string{"01-07", "01-08", "01-09", "01-10"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("生成的日期应为 %v，实际 %v", want, got)
+	}
+	if n, err := AdvanceSeries(id, time.UTC, day(30)); err != nil || n != 0 {
+		t.Errorf("规则结束后不应再生成: %d %v", n, err)
+	}
+}
+
+func TestUpdateFollowing(t *testing.T) {
+	openSQLite(t)
+	first := createSeries(t, "FREQ=DAILY;COUNT=6")
+	oldID := *first.RecurrenceID
+	if _, err := AdvanceSeries(oldID, time.UTC, day(4)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 修改第三次及之后，不修改规则时沿用原规则并扣除已经过的次数
+	var third models.Todo
+	if err := DB.Where("recurrence_id = ? AND occurrence_at = ?", oldID, day(2)).First(&third).Error; err != nil {
+		t.Fatal(err)
+	}
+	third.Title = "夜跑"
+	if err := UpdateFollowing(&third, nil, nil, time.UTC); err != nil {
+		t.Fatal(err)
+	}
+	if third.RecurrenceID == nil || *third.RecurrenceID == oldID || third.Recurrence.RRule != "FREQ=DAILY;COUNT=4" {
+		t.Fatalf("应当从这一次开始新的系列: %+v", third.Recurrence)
+	}
+	newID := *third.RecurrenceID
+
+	var old models.Recurrence
+	if err := DB.First(&old, oldID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if old.RRule != "FREQ=DAILY;UNTIL=20300109T085959Z" {
+		t.Errorf("原系列应当截止到这一次之前: %s", old.RRule)
+	}
+	if got, want := occurrences(t, oldID), []string{"01-07", "01-08"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("原系列之后的重复应当删除: %v", got)
+	}
+
+	// 新系列按修改后的内容生成
+	if _, err := AdvanceSeries(newID, time.UTC, day(30)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := occurrences(t, newID), []string{"01-09", "01-10", "01-11", "01-12"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("新系列的日期应为 %v，实际 %v", want, got)
+	}
+	var titles []string
+	DB.Model(&models.Todo{}).Where("recurrence_id = ?", newID).Distinct().Pluck("title", &titles)
+	if len(titles) != 1 || titles[0] != "夜跑" {
+		t.Errorf("新系列应当使用修改后的标题: %v", titles)
+	}
+
+	// 规则为空字符串时这一次不再重复，之后的重复删除
+	var last models.Todo
+	if err := DB.Where("recurrence_id = ? AND occurrence_at = ?", newID, day(3)).First(&last).Error; err != nil {
+		t.Fatal(err)
+	}
+	none := ""
+	if err := UpdateFollowing(&last, &none, nil, time.UTC); err != nil {
+		t.Fatal(err)
+	}
+	if last.RecurrenceID != nil {
+		t.Error("不再重复的待办事项不应属于系列")
+	}
+	if got, want := occurrences(t, newID), []string{"01-09"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("停止重复后系列应为 %v，实际 %v", want, got)
+	}
+}
+
+func TestDeleteOccurrenceAndFollowing(t *testing.T) {
+	openSQLite(t)
+	first := createSeries(t, "FREQ=DAILY")
+	id := *first.RecurrenceID
+	if _, err := AdvanceSeries(id, time.UTC, day(4)); err != nil {
+		t.Fatal(err)
+	}
+	byDay := func(n int) *models.Todo {
+		var todo models.Todo
+		if err := DB.Where("recurrence_id = ? AND occurrence_at = ?", id, day(n)).First(&todo).Error; err != nil {
+			t.Fatal(err)
+		}
+		return &todo
+	}
+
+	// 删除这一次会加入跳过的日期，不会重新生成
+	if err := DeleteOccurrence(byDay(1)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AdvanceSeries(id, time.UTC, day(4)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := occurrences(t, id), []string{"01-07", "01-09", "01-10", "01-11"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("删除这一次后应为 %v，实际 %v", want, got)
+	}
+
+	// 删除这一次及之后，之后已完成的保留
+	done := byDay(4)
+	done.Completed = true
+	if err := SaveTodo(DB, done, time.UTC); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteFollowing(byDay(2)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := occurrences(t, id), []string{"01-07", "01-11"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("删除之后的重复后应为 %v，实际 %v", want, got)
+	}
+	if n, err := AdvanceSeries(id, time.UTC, day(30)); err != nil || n != 0 {
+		t.Errorf("截止后不应再生成: %d %v", n, err)
+	}
+}
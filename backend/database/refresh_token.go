@@ -0,0 +1,107 @@
+package database
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"todolist/models"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("无效的刷新令牌")
+	ErrRefreshTokenExpired = errors.New("刷新令牌已过期")
+	// ErrRefreshTokenReused 已轮换或已吊销的令牌被再次使用，整个令牌族已被吊销
+	ErrRefreshTokenReused = errors.New("刷新令牌已失效")
+)
+
+// RandomToken 生成 URL 安全的随机令牌
+func RandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken 返回令牌的 SHA-256 十六进制摘要，用于落库和查找
+func HashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func issueRefreshToken(tx *gorm.DB, userID uint, familyID string, ttl time.Duration) (string, error) {
+	raw, err := RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	token := &models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: HashToken(raw),
+		ExpiresAt: time.Now().UTC().Add(ttl),
+	}
+	if err := tx.Create(token).Error; err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// RotateRefreshToken 使用刷新令牌换取同族的新令牌，旧令牌立即失效
+//
+// 已使用或已吊销的令牌再次出现说明令牌可能泄露，此时吊销整个令牌族及对应会话。
+func RotateRefreshToken(raw string, ttl time.Duration) (*models.RefreshToken, string, error) {
+	var token models.RefreshToken
+	if err := DB.Where("token_hash = ?", HashToken(raw)).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", ErrRefreshTokenInvalid
+		}
+		return nil, "", err
+	}
+
+	if token.UsedAt != nil || token.RevokedAt != nil {
+		if err := RevokeSession(token.FamilyID); err != nil {
+			return nil, "", err
+		}
+		return nil, "", ErrRefreshTokenReused
+	}
+	if time.Now().After(token.ExpiresAt) {
+		return nil, "", ErrRefreshTokenExpired
+	}
+
+	var newRaw string
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		// 带条件更新，防止并发请求同时轮换同一个令牌
+		now := time.Now().UTC()
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", token.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenReused
+		}
+
+		var err error
+		newRaw, err = issueRefreshToken(tx, token.UserID, token.FamilyID, ttl)
+		if err != nil {
+			return err
+		}
+		return extendSession(tx, token.FamilyID, ttl)
+	})
+	if errors.Is(err, ErrRefreshTokenReused) {
+		if err := RevokeSession(token.FamilyID); err != nil {
+			return nil, "", err
+		}
+		return nil, "", ErrRefreshTokenReused
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return &token, newRaw, nil
+}
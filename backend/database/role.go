@@ -0,0 +1,75 @@
+package database
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"todolist/models"
+)
+
+var (
+	ErrRoleNotFound = errors.New("角色不存在")
+	ErrRoleBuiltIn  = errors.New("内置角色不能修改或删除")
+	ErrRoleInUse    = errors.New("仍有用户使用该角色")
+)
+
+// GetRole 按名称获取角色
+func GetRole(name string) (*models.Role, error) {
+	var role models.Role
+	if err := DB.Where("name = ?", name).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+// ListRoles 获取所有角色及其用户数
+func ListRoles() ([]models.Role, map[string]int64, error) {
+	var roles []models.Role
+	if err := DB.Order("id").Find(&roles).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var rows []struct {
+		Role  string
+		Count int64
+	}
+	if err := DB.Model(&models.User{}).Select("role, COUNT(*) AS count").Group("role").Scan(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		counts[r.Role] = r.Count
+	}
+	return roles, counts, nil
+}
+
+// DeleteRole 删除自定义角色，内置角色或仍被使用的角色不能删除
+func DeleteRole(id uint) (*models.Role, error) {
+	var role models.Role
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&role, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRoleNotFound
+			}
+			return err
+		}
+		if role.BuiltIn {
+			return ErrRoleBuiltIn
+		}
+		var count int64
+		if err := tx.Model(&models.User{}).Where("role = ?", role.Name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrRoleInUse
+		}
+		return tx.Delete(&role).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
@@ -0,0 +1,107 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"todolist/models"
+)
+
+var ErrSessionNotFound = errors.New("会话不存在")
+
+// CreateSession 创建会话及其第一个刷新令牌，返回刷新令牌原文
+func CreateSession(session *models.Session, ttl time.Duration) (string, error) {
+	id, err := RandomToken(16)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now().UTC()
+	session.ID = id
+	session.LastUsedAt = models.CustomTime{Time: now}
+	session.ExpiresAt = now.Add(ttl)
+
+	var raw string
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		var err error
+		raw, err = issueRefreshToken(tx, session.UserID, session.ID, ttl)
+		return err
+	})
+	return raw, err
+}
+
+// GetSession 按ID获取会话
+func GetSession(id string) (*models.Session, error) {
+	var session models.Session
+	if err := DB.Where("id = ?", id).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// TouchSession 记录会话的最近使用时间和IP
+func TouchSession(id, ip string) error {
+	return DB.Model(&models.Session{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_used_at": time.Now().UTC(),
+		"ip":           ip,
+	}).Error
+}
+
+// extendSession 刷新令牌轮换后顺延会话有效期
+func extendSession(tx *gorm.DB, id string, ttl time.Duration) error {
+	return tx.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("expires_at", time.Now().UTC().Add(ttl)).Error
+}
+
+// ListActiveSessions 列出用户未吊销且未过期的会话
+func ListActiveSessions(userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	err := DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now().UTC()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// RevokeSession 吊销会话及其所有刷新令牌
+func RevokeSession(id string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+		if err := tx.Model(&models.Session{}).
+			Where("id = ? AND revoked_at IS NULL", id).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", id).
+			Update("revoked_at", now).Error
+	})
+}
+
+// RevokeUserSessions 吊销用户的所有会话，exceptID 非空时保留该会话
+func RevokeUserSessions(userID uint, exceptID string) (int64, error) {
+	var revoked int64
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+		sessions := tx.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", userID)
+		tokens := tx.Model(&models.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", userID)
+		if exceptID != "" {
+			sessions = sessions.Where("id <> ?", exceptID)
+			tokens = tokens.Where("family_id <> ?", exceptID)
+		}
+
+		result := sessions.Update("revoked_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		revoked = result.RowsAffected
+		return tokens.Update("revoked_at", now).Error
+	})
+	return revoked, err
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"todolist/models"
+)
+
+// PreloadTodo 查询待办事项时预加载检查项（按顺序）和所属的重复系列
+func PreloadTodo(db *gorm.DB) *gorm.DB {
+	return db.Preload("Checklist", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("sort_order, id")
+	}).Preload("Recurrence")
+}
+
+// SaveTodo 保存待办事项本身（不保存已加载的检查项和重复系列），默认结束时间按 loc 时区计算
+func SaveTodo(tx *gorm.DB, todo *models.Todo, loc *time.Location) error {
+	return tx.Set(models.LocationKey, loc).Omit(clause.Associations).Save(todo).Error
+}
+
+// DeleteTodo 删除待办事项及其检查项和提醒
+func DeleteTodo(todo *models.Todo) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		return deleteTodos(tx, "id = ?", todo.ID)
+	})
+}
+
+// deleteTodos 删除符合条件的待办事项及其检查项和提醒
+func deleteTodos(tx *gorm.DB, query string, args ...interface{}) error {
+	var ids []uint
+	if err := tx.Model(&models.Todo{}).Where(query, args...).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	for _, model := range []interface{}{&models.ChecklistItem{}, &models.Reminder{}} {
+		if err := tx.Where("todo_id IN ?", ids).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Where("id IN ?", ids).Delete(&models.Todo{}).Error
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"todolist/database"
+	"todolist/models"
+)
+
+// ExportData 导出当前用户的个人数据，ZIP 中包含资料、待办事项、标签和审计日志的 JSON 文件
+func ExportData(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	var todos []models.Todo
+	if err := database.PreloadTodo(database.DB).Where("user_id = ?", user.ID).Order("id").Find(&todos).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "导出数据失败"})
+		return
+	}
+	var projects []models.Project
+	if err := database.DB.Where("user_id = ?", user.ID).Order("sort_order, id").Find(&projects).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "导出数据失败"})
+		return
+	}
+	var reminders []models.Reminder
+	if err := database.DB.Where("user_id = ?", user.ID).Order("id").Find(&reminders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "导出数据失败"})
+		return
+	}
+	var notifications []models.Notification
+	if err := database.DB.Where("user_id = ?", user.ID).Order("id").Find(&notifications).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "导出数据失败"})
+		return
+	}
+	var events []models.AuditEvent
+	if err := database.DB.Where("actor_id = ? OR target_user_id = ?", user.ID, user.ID).
+		Order("id").Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "导出数据失败"})
+		return
+	}
+
+	// 先记录审计日志，导出文件中包含本次导出
+	recordAudit(c, models.AuditEvent{
+		Action:       models.AuditDataExport,
+		TargetUserID: &user.ID,
+	})
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", profileResponse(user)},
+		{"todos.json", todos},
+		{"projects.json", projects},
+		{"tags.json", tagCounts(todos)},
+		{"reminders.json", reminders},
+		{"notifications.json", notifications},
+		{"audit.json", events},
+	}
+
+	filename := fmt.Sprintf("todolist-%s-%s.zip", user.Username, time.Now().UTC().Format("20060102"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	// 响应头已发送，之后的错误只能记录日志
+	zw := zip.NewWriter(c.Writer)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			log.Printf("导出数据失败: %v", err)
+			return
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			log.Printf("导出数据失败: %v", err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		log.Printf("导出数据失败: %v", err)
+	}
+}
+
+// tagCounts 统计每个标签使用的待办事项数量，按数量降序
+func tagCounts(todos []models.Todo) []gin.H {
+	counts := map[string]int{}
+	for _, todo := range todos {
+		for _, tag := range todo.Tags {
+			counts[tag]++
+		}
+	}
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	result := make([]gin.H, 0, len(names))
+	for _, name := range names {
+		result = append(result, gin.H{"tag": name, "count": counts[name]})
+	}
+	return result
+}
+
+// DeleteAccount 注销当前账号，需要确认密码；冷静期内可以撤销，冷静期为 0 时立即删除
+func DeleteAccount(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	var request models.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := user.CheckPassword(request.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "密码错误"})
+		return
+	}
+
+	// 不能注销唯一的管理员账号
+	if user.Role == models.RoleAdmin {
+		count, err := database.CountAdmins()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "注销账号失败"})
+			return
+		}
+		if count <= 1 {
+			c.JSON(http.StatusConflict, gin.H{"error": "不能注销唯一的管理员账号"})
+			return
+		}
+	}
+
+	grace := accountConfig.DeletionGracePeriod.Std()
+	if grace == 0 {
+		if err := database.DeleteUserData(user); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "注销账号失败"})
+			return
+		}
+		removeAvatarFile(user.Avatar)
+		// 审计日志保留，只记录用户ID，不能写入用户名、邮箱、IP等已删除的个人信息
+		if err := database.RecordAudit(&models.AuditEvent{
+			ActorID:      &user.ID,
+			Action:       models.AuditUserDelete,
+			TargetUserID: &user.ID,
+		}); err != nil {
+			log.Printf("写入审计日志失败 (%s): %v", models.AuditUserDelete, err)
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "账号已注销"})
+		return
+	}
+
+	deleteAfter := time.Now().UTC().Add(grace)
+	if err := database.ScheduleUserDeletion(user, &deleteAfter); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "注销账号失败"})
+		return
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:       models.AuditDeleteRequest,
+		TargetUserID: &user.ID,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "已申请注销账号，冷静期结束后将删除所有数据，期间登录后可以撤销",
+		"delete_after": user.DeleteAfter,
+	})
+}
+
+// CancelAccountDeletion 撤销注销申请
+func CancelAccountDeletion(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	if user.DeleteAfter == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "账号未申请注销"})
+		return
+	}
+	if err := database.ScheduleUserDeletion(user, nil); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "撤销注销失败"})
+		return
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:       models.AuditDeleteCancel,
+		TargetUserID: &user.ID,
+	})
+	c.JSON(http.StatusOK, gin.H{"message": "已撤销注销申请"})
+}
+
+// PurgeDeletedAccounts 删除注销冷静期已过的账号，返回删除的数量
+func PurgeDeletedAccounts() (int, error) {
+	users, err := database.DueUserDeletions(time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for i := range users {
+		user := &users[i]
+		if err := database.DeleteUserData(user); err != nil {
+			return purged, fmt.Errorf("删除用户 %s 失败: %w", user.Username, err)
+		}
+		removeAvatarFile(user.Avatar)
+		purged++
+
+		// 只记录用户ID，不保留已删除的个人信息
+		if err := database.RecordAudit(&models.AuditEvent{
+			Action:       models.AuditUserPurge,
+			TargetUserID: &user.ID,
+		}); err != nil {
+			log.Printf("写入审计日志失败 (%s): %v", models.AuditUserPurge, err)
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"todolist/config"
+	"todolist/database"
+	"todolist/middleware"
+	"todolist/models"
+)
+
+// assertErasedAudit 检查删除账号的审计日志只记录了用户ID，没有保留个人信息
+func assertErasedAudit(t *testing.T, action string, user *models.User) {
+	t.Helper()
+	var event models.AuditEvent
+	if err := database.DB.Where("action = ? AND target_user_id = ?", action, user.ID).First(&event).Error; err != nil {
+		t.Fatalf("缺少 %s 审计日志: %v", action, err)
+	}
+	if len(event.Changes) != 0 || event.ActorUsername != "" || event.IP != "" || event.UserAgent != "" {
+		t.Errorf("审计日志保留了个人信息: %+v", event)
+	}
+
+	var events []models.AuditEvent
+	database.DB.Where("action IN ?", []string{models.AuditUserDelete, models.AuditUserPurge}).Find(&events)
+	for _, e := range events {
+		for _, v := range e.Changes {
+			if s, ok := v.Before.(string); ok && (strings.Contains(s, user.Email) || s == user.Username) {
+				t.Errorf("审计日志中包含已删除的个人信息: %+v", e)
+			}
+		}
+	}
+}
+
+func TestDeleteAccountImmediatelyErasesAudit(t *testing.T) {
+	setupTest(t, func(cfg *config.Config) {
+		cfg.Account.DeletionGracePeriod = 0
+	})
+	r := gin.New()
+	r.DELETE("/me", middleware.AuthMiddleware(), DeleteAccount)
+
+	user := createTestUser(t, "alice", "alice@example.com", true)
+	w := doJSON(r, http.MethodDelete, "/me", sessionToken(t, user), gin.H{"password": "Password123"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("注销账号返回 %d: %s", w.Code, w.Body.String())
+	}
+	if err := database.DB.First(&models.User{}, user.ID).Error; err == nil {
+		t.Fatal("账号应当已删除")
+	}
+	assertErasedAudit(t, models.AuditUserDelete, user)
+}
+
+func TestPurgeDeletedAccountsErasesAudit(t *testing.T) {
+	setupTest(t, nil)
+	user := createTestUser(t, "bob", "bob@example.com", true)
+	past := time.Now().Add(-time.Hour)
+	if err := database.ScheduleUserDeletion(user, &past); err != nil {
+		t.Fatal(err)
+	}
+
+	purged, err := PurgeDeletedAccounts()
+	if err != nil || purged != 1 {
+		t.Fatalf("删除了 %d 个账号: %v", purged, err)
+	}
+	assertErasedAudit(t, models.AuditUserPurge, user)
+}
@@ -1,21 +1,17 @@
 package handlers
 
 import (
+    "errors"
+    "fmt"
     "github.com/gin-gonic/gin"
     "net/http"
+    "strings"
     "todolist/database"
     "todolist/models"
 )
 
 // GetUsers 获取用户列表
 func GetUsers(c *gin.Context) {
-    // 检查是否是管理员
-    currentUser, _ := c.Get("user")
-    if !currentUser.(*models.User).IsAdmin() {
-        c.JSON(http.StatusForbidden, gin.H{"error": "无权限访问"})
-        return
-    }
-
     var users []models.User
     query := database.DB.Model(&models.User{})
 
@@ -37,6 +33,22 @@ func GetUsers(c *gin.Context) {
         return
     }
 
+    // 填充登录锁定状态
+    lockouts, err := loginThrottler.Lockouts()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户列表失败"})
+        return
+    }
+    lockedUntil := make(map[string]models.CustomTime, len(lockouts))
+    for _, l := range lockouts {
+        lockedUntil[l.Username] = models.CustomTime{Time: l.LockedUntil}
+    }
+    for i := range users {
+        if t, ok := lockedUntil[strings.ToLower(users[i].Username)]; ok {
+            users[i].LockedUntil = &t
+        }
+    }
+
     c.JSON(http.StatusOK, gin.H{
         "total": total,
         "users": users,
@@ -45,13 +57,6 @@ func GetUsers(c *gin.Context) {
 
 // ActivateUser 激活用户
 func ActivateUser(c *gin.Context) {
-    // 检��是否是管理员
-    currentUser, _ := c.Get("user")
-    if !currentUser.(*models.User).IsAdmin() {
-        c.JSON(http.StatusForbidden, gin.H{"error": "无权限访问"})
-        return
-    }
-
     userID := c.Param("id")
     var user models.User
 
@@ -60,11 +65,17 @@ func ActivateUser(c *gin.Context) {
         return
     }
 
+    if !requireCoversTarget(c, &user) {
+        return
+    }
+
+    before := user
     user.Status = models.StatusActive
     if err := database.DB.Save(&user).Error; err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "激活用户失败"})
         return
     }
+    recordUserAudit(c, models.AuditUserActivate, &before, &user)
 
     c.JSON(http.StatusOK, gin.H{
         "message": "用户已激活",
@@ -74,13 +85,6 @@ func ActivateUser(c *gin.Context) {
 
 // BlockUser 禁用用户
 func BlockUser(c *gin.Context) {
-    // 检查是否是管理员
-    currentUser, _ := c.Get("user")
-    if !currentUser.(*models.User).IsAdmin() {
-        c.JSON(http.StatusForbidden, gin.H{"error": "无权限访问"})
-        return
-    }
-
     userID := c.Param("id")
     var user models.User
 
@@ -89,17 +93,28 @@ func BlockUser(c *gin.Context) {
         return
     }
 
-    // 不能禁用管理员
-    if user.IsAdmin() {
-        c.JSON(http.StatusForbidden, gin.H{"error": "不能禁用管理员账号"})
+    // 不能禁用自己
+    if user.ID == c.MustGet("user").(*models.User).ID {
+        c.JSON(http.StatusForbidden, gin.H{"error": "不能禁用自己的账号"})
+        return
+    }
+
+    if !requireCoversTarget(c, &user) {
         return
     }
 
+    before := user
     user.Status = models.StatusBlocked
     if err := database.DB.Save(&user).Error; err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "禁用用户失败"})
         return
     }
+    recordUserAudit(c, models.AuditUserBlock, &before, &user)
+
+    if _, err := database.RevokeUserSessions(user.ID, ""); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "吊销会话失败"})
+        return
+    }
 
     c.JSON(http.StatusOK, gin.H{
         "message": "用户已禁用",
@@ -109,13 +124,6 @@ func BlockUser(c *gin.Context) {
 
 // UpdateUserRole 修改用户角色
 func UpdateUserRole(c *gin.Context) {
-    // 检查是否是管理员
-    currentUser, _ := c.Get("user")
-    if !currentUser.(*models.User).IsAdmin() {
-        c.JSON(http.StatusForbidden, gin.H{"error": "无权限访问"})
-        return
-    }
-
     userID := c.Param("id")
     var user models.User
     var request models.UpdateUserRole
@@ -130,11 +138,36 @@ func UpdateUserRole(c *gin.Context) {
         return
     }
 
-    user.Role = request.Role
+    // 不能修改自己的角色，避免误操作导致失去权限
+    if user.ID == c.MustGet("user").(*models.User).ID {
+        c.JSON(http.StatusForbidden, gin.H{"error": "不能修改自己的角色"})
+        return
+    }
+
+    // 被替换的角色同样不能超出自身权限，避免降级更高权限的用户
+    if !requireCoversTarget(c, &user) {
+        return
+    }
+
+    role, err := database.GetRole(request.Role)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "角色不存在"})
+        return
+    }
+
+    // 只能分配不超出自身权限的角色
+    if !c.MustGet("role").(*models.Role).Covers(role.Permissions) {
+        c.JSON(http.StatusForbidden, gin.H{"error": "不能分配超出自身权限的角色"})
+        return
+    }
+
+    before := user
+    user.Role = role.Name
     if err := database.DB.Save(&user).Error; err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "更新用户角色失败"})
         return
     }
+    recordUserAudit(c, models.AuditUserRoleChange, &before, &user)
 
     c.JSON(http.StatusOK, gin.H{
         "message": "用户角色已更新",
@@ -144,13 +177,6 @@ func UpdateUserRole(c *gin.Context) {
 
 // AdminUpdateUserPassword 管理员修改用户密码
 func AdminUpdateUserPassword(c *gin.Context) {
-    // 检查是否是管理员
-    currentUser, _ := c.Get("user")
-    if !currentUser.(*models.User).IsAdmin() {
-        c.JSON(http.StatusForbidden, gin.H{"error": "无权限访问"})
-        return
-    }
-
     userID := c.Param("id")
     var user models.User
     var request models.AdminUpdateUserPassword
@@ -165,18 +191,33 @@ func AdminUpdateUserPassword(c *gin.Context) {
         return
     }
 
+    if !requireCoversTarget(c, &user) {
+        return
+    }
+
+    if !validateNewPassword(c, &user, request.Password) {
+        return
+    }
+
     // 更新密码
-    user.Password = request.Password
-    if err := user.HashPassword(); err != nil {
+    before := user
+    hashed := models.User{Password: request.Password}
+    if err := hashed.HashPassword(); err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "密码加密失败"})
         return
     }
-
-    if err := database.DB.Save(&user).Error; err != nil {
+    if err := database.SetPassword(database.DB, &user, hashed.Password, passwordConfig.HistorySize, request.MustChange); err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "更新密码失败"})
         return
     }
 
+    // 密码被重置后吊销该用户的所有会话
+    if _, err := database.RevokeUserSessions(user.ID, ""); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "吊销会话失败"})
+        return
+    }
+    recordUserAudit(c, models.AuditUserPasswordReset, &before, &user)
+
     c.JSON(http.StatusOK, gin.H{
         "message": "密码修改成功",
         "user": gin.H{
@@ -190,13 +231,6 @@ func AdminUpdateUserPassword(c *gin.Context) {
 
 // DeleteUser 管理员删除用户
 func DeleteUser(c *gin.Context) {
-    // 检查是否是管理员
-    currentUser, _ := c.Get("user")
-    if !currentUser.(*models.User).IsAdmin() {
-        c.JSON(http.StatusForbidden, gin.H{"error": "无权限访问"})
-        return
-    }
-
     userID := c.Param("id")
     var user models.User
 
@@ -206,22 +240,29 @@ func DeleteUser(c *gin.Context) {
     }
 
     // 不能删除自己
-    if user.ID == currentUser.(*models.User).ID {
+    currentUser := c.MustGet("user").(*models.User)
+    if user.ID == currentUser.ID {
         c.JSON(http.StatusForbidden, gin.H{"error": "不能删除自己的账号"})
         return
     }
 
-    // 删除用户的所有待办事项
-    if err := database.DB.Where("user_id = ?", user.ID).Delete(&models.Todo{}).Error; err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "删除用户待办事项失败"})
+    if !requireCoversTarget(c, &user) {
         return
     }
 
-    // 删除用户
-    if err := database.DB.Delete(&user).Error; err != nil {
+    // 删除用户及其待办事项、会话等所有数据
+    if err := database.DeleteUserData(&user); err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "删除用户失败"})
         return
     }
+    removeAvatarFile(user.Avatar)
+    // 用户数据已删除，审计日志只记录用户ID，不保留个人信息
+    recordAudit(c, models.AuditEvent{
+        Action:       models.AuditUserDelete,
+        TargetUserID: &user.ID,
+        Resource:     "user",
+        ResourceID:   fmt.Sprint(user.ID),
+    })
 
     c.JSON(http.StatusOK, gin.H{
         "message": "用户删除成功",
@@ -232,4 +273,132 @@ func DeleteUser(c *gin.Context) {
             "status":   user.Status,
         },
     })
-} 
\ No newline at end of file
+} 
+
+// RevokeUserSessions 管理员吊销用户的所有会话
+func RevokeUserSessions(c *gin.Context) {
+    userID := c.Param("id")
+    var user models.User
+
+    if err := database.DB.First(&user, userID).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+        return
+    }
+
+    if !requireCoversTarget(c, &user) {
+        return
+    }
+
+    revoked, err := database.RevokeUserSessions(user.ID, "")
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "吊销会话失败"})
+        return
+    }
+    recordUserAudit(c, models.AuditUserSessionsRevoke, &user, &user)
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "已吊销用户的所有会话",
+        "revoked": revoked,
+    })
+}
+
+// ClearUserLockout 管理员解除用户的登录锁定
+func ClearUserLockout(c *gin.Context) {
+    userID := c.Param("id")
+    var user models.User
+
+    if err := database.DB.First(&user, userID).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+        return
+    }
+
+    if !requireCoversTarget(c, &user) {
+        return
+    }
+
+    if err := loginThrottler.Clear(user.Username); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "解除锁定失败"})
+        return
+    }
+    recordUserAudit(c, models.AuditUserLockoutClear, &user, &user)
+
+    c.JSON(http.StatusOK, gin.H{"message": "已解除登录锁定"})
+}
+
+// ExpireUserPassword 管理员要求用户下次登录后必须修改密码，已登录的会话在修改密码前也会受限
+func ExpireUserPassword(c *gin.Context) {
+    userID := c.Param("id")
+    var user models.User
+
+    if err := database.DB.First(&user, userID).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+        return
+    }
+
+    if !requireCoversTarget(c, &user) {
+        return
+    }
+
+    before := user
+    if err := database.DB.Model(&user).Update("must_change_password", true).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "操作失败"})
+        return
+    }
+    recordUserAudit(c, models.AuditUserPasswordExpire, &before, &user)
+
+    c.JSON(http.StatusOK, gin.H{"message": "用户下次登录后必须修改密码"})
+}
+
+// ResetUserMFA 管理员重置用户的两步验证（用户丢失认证器且没有恢复码时使用）
+func ResetUserMFA(c *gin.Context) {
+    userID := c.Param("id")
+    var user models.User
+
+    if err := database.DB.First(&user, userID).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+        return
+    }
+
+    if !requireCoversTarget(c, &user) {
+        return
+    }
+
+    before := user
+    if err := database.ResetMFA(user.ID); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "重置两步验证失败"})
+        return
+    }
+    user.TOTPEnabled = false
+    recordUserAudit(c, models.AuditUserMFAReset, &before, &user)
+
+    c.JSON(http.StatusOK, gin.H{"message": "两步验证已重置"})
+}
+
+// requireCoversTarget 检查当前角色是否覆盖目标用户角色的全部权限，防止对权限更高的用户执行管理操作；
+// 不满足时写入 403 响应。目标用户的角色已不存在时视为没有任何权限
+func requireCoversTarget(c *gin.Context, target *models.User) bool {
+    role, err := database.GetRole(target.Role)
+    if errors.Is(err, database.ErrRoleNotFound) {
+        return true
+    }
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户角色失败"})
+        return false
+    }
+    if !c.MustGet("role").(*models.Role).Covers(role.Permissions) {
+        c.JSON(http.StatusForbidden, gin.H{"error": "不能管理权限高于自己的用户"})
+        return false
+    }
+    return true
+}
+
+// recordUserAudit 记录针对用户的管理操作，after 为 nil 表示用户已被删除
+func recordUserAudit(c *gin.Context, action string, before, after *models.User) {
+    recordAudit(c, models.AuditEvent{
+        Action:       action,
+        TargetUserID: &before.ID,
+        Resource:     "user",
+        ResourceID:   fmt.Sprint(before.ID),
+        Changes:      database.AuditDiff(before, after),
+    })
+}
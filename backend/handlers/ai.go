@@ -9,13 +9,13 @@ import (
     "todolist/models"
 )
 
-const (
-    DifyBaseURL = "https://dify.frankgu.club:8888"
-    DifyAPIKey  = "Bearer app-aFzcAUK2Xap71boryALR4Pc5"
-)
-
 // ProcessAI 处理AI识别请求
 func ProcessAI(c *gin.Context) {
+    if difyConfig.APIKey == "" {
+        c.JSON(http.StatusServiceUnavailable, gin.H{"error": "AI服务未配置"})
+        return
+    }
+
     var request models.AIRequest
     if err := c.ShouldBindJSON(&request); err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -39,19 +39,18 @@ func ProcessAI(c *gin.Context) {
     }
 
     // 创建HTTP请求
-    req, err := http.NewRequest("POST", DifyBaseURL+"/v1/workflows/run", bytes.NewBuffer(jsonData))
+    req, err := http.NewRequestWithContext(c.Request.Context(), "POST", difyConfig.BaseURL+"/v1/workflows/run", bytes.NewBuffer(jsonData))
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "创建请求失败"})
         return
     }
 
     // 设置请求头
-    req.Header.Set("Authorization", DifyAPIKey)
+    req.Header.Set("Authorization", "Bearer "+difyConfig.APIKey)
     req.Header.Set("Content-Type", "application/json")
 
-    // 发送请求
-    client := &http.Client{}
-    resp, err := client.Do(req)
+    // 发送请求（超时由 dify.timeout 控制，客户端断开时同时取消上游请求）
+    resp, err := difyClient.Do(req)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "请求失败"})
         return
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"todolist/database"
+	"todolist/models"
+)
+
+// CreateAPIToken 创建个人访问令牌
+func CreateAPIToken(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var request models.CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token := &models.APIToken{
+		UserID: userID.(uint),
+		Name:   request.Name,
+		Scopes: request.Scopes,
+	}
+	if request.ExpiresInDays > 0 {
+		expiresAt := models.CustomTime{Time: time.Now().UTC().AddDate(0, 0, request.ExpiresInDays)}
+		token.ExpiresAt = &expiresAt
+	}
+
+	raw, err := database.CreateAPIToken(token)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建API令牌失败"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "API令牌已创建，请妥善保存，令牌只显示一次",
+		"token":     raw,
+		"api_token": token,
+	})
+}
+
+// GetAPITokens 获取当前用户的个人访问令牌列表
+func GetAPITokens(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	tokens, err := database.ListAPITokens(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取API令牌失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// DeleteAPIToken 吊销个人访问令牌
+func DeleteAPIToken(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API令牌不存在"})
+		return
+	}
+
+	found, err := database.RevokeAPIToken(userID.(uint), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "吊销API令牌失败"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API令牌不存在"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API令牌已吊销"})
+}
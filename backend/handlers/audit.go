@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"todolist/database"
+	"todolist/models"
+	"todolist/util"
+)
+
+// recordAudit 记录审计事件，未指定操作者时取当前登录用户（模拟登录时为发起模拟的管理员）；
+// 写入失败只记录日志，不影响请求结果
+func recordAudit(c *gin.Context, event models.AuditEvent) {
+	if event.ActorID == nil {
+		if u, ok := c.Get("impersonator"); ok {
+			actor := u.(*models.User)
+			event.ActorID = &actor.ID
+			event.ActorUsername = actor.Username
+		} else if u, ok := c.Get("user"); ok {
+			actor := u.(*models.User)
+			event.ActorID = &actor.ID
+			event.ActorUsername = actor.Username
+		}
+	}
+	event.IP = c.ClientIP()
+	event.UserAgent = util.Truncate(c.Request.UserAgent(), 255)
+
+	if err := database.RecordAudit(&event); err != nil {
+		log.Printf("写入审计日志失败 (%s): %v", event.Action, err)
+	}
+}
+
+// GetAuditEvents 分页查询审计日志，按时间倒序
+func GetAuditEvents(c *gin.Context) {
+	query, ok := auditQuery(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+	if pageSize < 1 || pageSize > 200 {
+		pageSize = 50
+	}
+
+	var total int64
+	if err := query.Model(&models.AuditEvent{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取审计日志失败"})
+		return
+	}
+
+	events := []models.AuditEvent{}
+	if err := query.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取审计日志失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+		"events":    events,
+	})
+}
+
+// ExportAuditEvents 以 NDJSON（每行一个 JSON 对象）格式导出符合条件的全部审计日志，按时间正序
+func ExportAuditEvents(c *gin.Context) {
+	query, ok := auditQuery(c)
+	if !ok {
+		return
+	}
+
+	filename := fmt.Sprintf("audit-%s.ndjson", time.Now().Format("20060102-150405"))
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	var batch []models.AuditEvent
+	err := query.Order("id").FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			if err := encoder.Encode(&batch[i]); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	}).Error
+	if err != nil {
+		// 响应头已发送，只能记录日志
+		log.Printf("导出审计日志失败: %v", err)
+	}
+}
+
+// auditQuery 根据查询参数构建审计日志筛选条件，参数无效时写入 400 响应
+func auditQuery(c *gin.Context) (*gorm.DB, bool) {
+	query := database.DB.Model(&models.AuditEvent{})
+
+	for param, column := range map[string]string{"actor_id": "actor_id", "target_user_id": "target_user_id"} {
+		if v := c.Query(param); v != "" {
+			id, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的参数: " + param})
+				return nil, false
+			}
+			query = query.Where(column+" = ?", id)
+		}
+	}
+
+	// 动作筛选，以 "." 结尾时按前缀匹配，例如 user.
+	if action := c.Query("action"); action != "" {
+		if strings.HasSuffix(action, ".") {
+			query = query.Where("action LIKE ?", action+"%")
+		} else {
+			query = query.Where("action = ?", action)
+		}
+	}
+	if resource := c.Query("resource"); resource != "" {
+		query = query.Where("resource = ?", resource)
+	}
+	if ip := c.Query("ip"); ip != "" {
+		query = query.Where("ip = ?", ip)
+	}
+
+	// 时间范围筛选
+	for param, op := range map[string]string{"from": ">=", "to": "<="} {
+		if v := c.Query(param); v != "" {
+			t, err := time.Parse(models.TimeFormat, v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的时间格式: " + param})
+				return nil, false
+			}
+			query = query.Where("created_at "+op+" ?", t)
+		}
+	}
+
+	return query, true
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"todolist/database"
+	"todolist/models"
+)
+
+// maxChecklistItems 每个待办事项最多的检查项数量
+const maxChecklistItems = 100
+
+// 检查项的增删改都返回更新后的待办事项（含检查项、进度和完成状态），
+// 开启自动完成时会同步待办事项的完成状态
+
+// GetChecklist 获取待办事项的检查项
+func GetChecklist(c *gin.Context) {
+	todo, ok := loadTodo(c, models.ProjectViewer)
+	if !ok {
+		return
+	}
+	if todo.Checklist == nil {
+		todo.Checklist = []models.ChecklistItem{}
+	}
+	c.JSON(http.StatusOK, todo.Checklist)
+}
+
+// CreateChecklistItem 添加检查项
+func CreateChecklistItem(c *gin.Context) {
+	todo, ok := loadTodo(c, models.ProjectEditor)
+	if !ok {
+		return
+	}
+
+	var request models.CreateChecklistItemRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(todo.Checklist) >= maxChecklistItems {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "检查项数量已达上限"})
+		return
+	}
+
+	item := models.ChecklistItem{Title: request.Title, Completed: request.Completed}
+	if err := database.InsertChecklistItem(todo.ID, &item, request.Position); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "添加检查项失败"})
+		return
+	}
+	respondChecklistChange(c, http.StatusCreated, todo)
+}
+
+// UpdateChecklistItem 修改检查项的标题或完成状态
+func UpdateChecklistItem(c *gin.Context) {
+	todo, ok := loadTodo(c, models.ProjectEditor)
+	if !ok {
+		return
+	}
+	item, ok := findChecklistItem(c, todo)
+	if !ok {
+		return
+	}
+
+	var request models.UpdateChecklistItemRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if request.Title != nil {
+		item.Title = *request.Title
+	}
+	if request.Completed != nil {
+		item.Completed = *request.Completed
+	}
+	if err := database.DB.Save(item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新检查项失败"})
+		return
+	}
+	respondChecklistChange(c, http.StatusOK, todo)
+}
+
+// DeleteChecklistItem 删除检查项
+func DeleteChecklistItem(c *gin.Context) {
+	todo, ok := loadTodo(c, models.ProjectEditor)
+	if !ok {
+		return
+	}
+	item, ok := findChecklistItem(c, todo)
+	if !ok {
+		return
+	}
+
+	if err := database.DB.Delete(item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除检查项失败"})
+		return
+	}
+	respondChecklistChange(c, http.StatusOK, todo)
+}
+
+// ReorderChecklist 调整检查项顺序
+func ReorderChecklist(c *gin.Context) {
+	todo, ok := loadTodo(c, models.ProjectEditor)
+	if !ok {
+		return
+	}
+
+	var request models.ReorderChecklistRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := database.ReorderChecklist(todo.ID, request.IDs); err != nil {
+		if errors.Is(err, database.ErrChecklistOrder) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "调整顺序失败"})
+		return
+	}
+	respondChecklistChange(c, http.StatusOK, todo)
+}
+
+// findChecklistItem 在已加载的检查项中查找路径参数 :itemId
+func findChecklistItem(c *gin.Context, todo *models.Todo) (*models.ChecklistItem, bool) {
+	for i := range todo.Checklist {
+		if c.Param("itemId") == strconv.FormatUint(uint64(todo.Checklist[i].ID), 10) {
+			return &todo.Checklist[i], true
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "检查项不存在"})
+	return nil, false
+}
+
+// respondChecklistChange 重新加载检查项，按需同步自动完成状态后返回待办事项
+func respondChecklistChange(c *gin.Context, status int, todo *models.Todo) {
+	if err := database.LoadChecklist(todo); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取检查项失败"})
+		return
+	}
+
+	completed := todo.Completed
+	todo.SyncAutoComplete()
+	if todo.Completed != completed {
+		user, err := todoOwner(c, todo)
+		if err == nil {
+			err = saveTodo(todo, user)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "更新待办事项失败"})
+			return
+		}
+		// 自动完成的重复任务同样生成下一次
+		if todo.Completed {
+			advanceRecurrence(todo.RecurrenceID, user)
+		}
+	}
+	c.JSON(status, todo)
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"todolist/database"
+	"todolist/mail"
+	"todolist/models"
+)
+
+// ResendEmailVerification 重新发送邮箱验证邮件；无论邮箱是否存在都返回相同结果，避免泄露账号信息
+func ResendEmailVerification(c *gin.Context) {
+	var request models.ResendEmailVerificationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	email := strings.ToLower(request.Email)
+
+	// 复用登录限流，按IP和邮箱限制请求频率
+	ip := c.ClientIP()
+	throttleKey := "verify:" + email
+	if !checkLoginThrottle(c, ip, throttleKey) {
+		return
+	}
+	throttleFail(ip, throttleKey)
+
+	response := gin.H{"message": "如果该邮箱已绑定账号且尚未验证，验证邮件已发送，请查收"}
+
+	var user models.User
+	if err := database.DB.Where("LOWER(email) = ?", email).First(&user).Error; err != nil ||
+		user.EmailVerified || user.Status == models.StatusBlocked {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+	if err := sendEmailVerification(&user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "发送验证邮件失败"})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// VerifyEmail 使用邮件中的令牌验证邮箱
+func VerifyEmail(c *gin.Context) {
+	var request models.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := database.VerifyEmail(request.Token)
+	if errors.Is(err, database.ErrEmailVerificationInvalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "验证邮箱失败"})
+		return
+	}
+
+	recordAudit(c, models.AuditEvent{
+		ActorID:       &user.ID,
+		ActorUsername: user.Username,
+		TargetUserID:  &user.ID,
+		Action:        models.AuditEmailVerify,
+	})
+
+	// 邮箱属于白名单域名的待审核用户，确认邮箱归属后激活
+	if activatesOnVerify(user) {
+		result := database.DB.Model(&models.User{}).
+			Where("id = ? AND status = ?", user.ID, models.StatusInactive).
+			Update("status", models.StatusActive)
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "激活账号失败"})
+			return
+		}
+		if result.RowsAffected > 0 {
+			before := *user
+			user.Status = models.StatusActive
+			recordAudit(c, models.AuditEvent{
+				ActorID:       &user.ID,
+				ActorUsername: user.Username,
+				TargetUserID:  &user.ID,
+				Action:        models.AuditUserActivate,
+				Resource:      "user",
+				ResourceID:    fmt.Sprint(user.ID),
+				Changes:       database.AuditDiff(&before, user),
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "邮箱已验证",
+		"email":   user.Email,
+		"status":  user.Status,
+	})
+}
+
+// sendEmailVerification 为用户当前的邮箱签发验证令牌并异步发送验证邮件，用户没有邮箱时不发送
+func sendEmailVerification(user *models.User) error {
+	if user.Email == "" {
+		return nil
+	}
+	raw, err := database.CreateEmailVerification(user, mailConfig.VerifyTokenTTL.Std())
+	if err != nil {
+		return err
+	}
+
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: "验证您的 TodoList 邮箱",
+		Body:    verifyEmailBody(user.Username, verifyEmailLink(raw)),
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := mailer.Send(ctx, msg); err != nil {
+			log.Printf("发送验证邮件失败 (user=%d): %v", user.ID, err)
+		}
+	}()
+	return nil
+}
+
+func verifyEmailLink(token string) string {
+	sep := "?"
+	if strings.Contains(mailConfig.VerifyURL, "?") {
+		sep = "&"
+	}
+	return mailConfig.VerifyURL + sep + "token=" + url.QueryEscape(token)
+}
+
+func verifyEmailBody(username, link string) string {
+	return fmt.Sprintf(`%s，您好：
+
+请在 %s内打开以下链接验证您在 TodoList 使用的邮箱：
+
+%s
+
+链接只能使用一次。如果这不是您本人的操作，请忽略本邮件。
+`, username, formatValidity(mailConfig.VerifyTokenTTL.Std()), link)
+}
+
+// formatValidity 将有效期格式化为整小时或分钟
+func formatValidity(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		return fmt.Sprintf(" %d 小时", int(d.Hours()))
+	}
+	return fmt.Sprintf(" %d 分钟", int(d.Minutes()))
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"todolist/config"
+	"todolist/database"
+	"todolist/mail"
+	"todolist/notify"
+	"todolist/oidc"
+	"todolist/pwpolicy"
+	"todolist/throttle"
+)
+
+var (
+	jwtConfig        config.JWTConfig
+	mfaConfig        config.MFAConfig
+	regConfig        config.RegistrationConfig
+	passwordConfig   config.PasswordConfig
+	passwordPolicy   *pwpolicy.Policy
+	storageConfig    config.StorageConfig
+	accountConfig    config.AccountConfig
+	recurrenceConfig config.RecurrenceConfig
+	notifyConfig     config.NotifyConfig
+	notifiers        map[string]notify.Notifier
+	mailConfig       config.MailConfig
+	mailer           mail.Mailer
+	oidcConfig       config.OIDCConfig
+	oidcProvider     *oidc.Provider
+	difyConfig       config.DifyConfig
+	difyClient       = &http.Client{}
+	loginThrottler   *throttle.Throttler
+)
+
+// Setup 根据配置初始化处理器依赖，需在数据库初始化之后调用
+func Setup(cfg *config.Config) error {
+	jwtConfig = cfg.JWT
+	mfaConfig = cfg.MFA
+	regConfig = cfg.Registration
+	passwordConfig = cfg.Password
+	policy, err := NewPasswordPolicy(cfg.Password)
+	if err != nil {
+		return fmt.Errorf("初始化密码策略失败: %w", err)
+	}
+	passwordPolicy = policy
+	storageConfig = cfg.Storage
+	accountConfig = cfg.Account
+	recurrenceConfig = cfg.Recurrence
+	mailConfig = cfg.Mail
+	switch cfg.Mail.Driver {
+	case "smtp":
+		mailer = &mail.SMTPMailer{
+			Host:     cfg.Mail.SMTPHost,
+			Port:     cfg.Mail.SMTPPort,
+			Username: cfg.Mail.SMTPUsername,
+			Password: cfg.Mail.SMTPPassword,
+			From:     cfg.Mail.From,
+		}
+	case "file":
+		mailer = &mail.FileMailer{Dir: cfg.Mail.Dir, From: cfg.Mail.From}
+	default:
+		mailer = &mail.LogMailer{From: cfg.Mail.From}
+	}
+
+	// 提醒的各发送渠道，Webhook 地址由用户设置，默认不允许访问内网
+	notifyConfig = cfg.Notify
+	notifiers = map[string]notify.Notifier{
+		notify.ChannelInApp:   &notify.InAppNotifier{Inbox: database.Inbox{}},
+		notify.ChannelEmail:   &notify.EmailNotifier{Mailer: mailer},
+		notify.ChannelWebhook: &notify.WebhookNotifier{Client: notify.NewHTTPClient(cfg.Notify.Timeout.Std(), cfg.Notify.AllowPrivateWebhooks)},
+		notify.ChannelNtfy: &notify.NtfyNotifier{
+			Client: &http.Client{Timeout: cfg.Notify.Timeout.Std()},
+			Server: cfg.Notify.NtfyServer,
+			Token:  cfg.Notify.NtfyToken,
+		},
+	}
+
+	oidcConfig = cfg.OIDC
+	if cfg.OIDC.Enabled {
+		oidcProvider = oidc.NewProvider(oidc.Config{
+			Issuer:       cfg.OIDC.Issuer,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+			Scopes:       cfg.OIDC.Scopes,
+		}, nil)
+	}
+
+	var store throttle.Store
+	if cfg.Login.ThrottleStore == "database" {
+		store = throttle.NewDBStore(database.DB)
+	} else {
+		store = throttle.NewMemoryStore(cfg.Login.Window.Std())
+	}
+	loginThrottler = throttle.New(store, throttle.Config{
+		Window:           cfg.Login.Window.Std(),
+		IPMaxFailures:    cfg.Login.IPMaxFailures,
+		BackoffAfter:     cfg.Login.BackoffAfter,
+		BackoffBase:      cfg.Login.BackoffBase.Std(),
+		BackoffMax:       cfg.Login.BackoffMax.Std(),
+		LockoutThreshold: cfg.Login.LockoutThreshold,
+		LockoutDuration:  cfg.Login.LockoutDuration.Std(),
+	})
+	difyConfig = cfg.Dify
+	difyClient = &http.Client{Timeout: cfg.Dify.Timeout.Std()}
+	return nil
+}
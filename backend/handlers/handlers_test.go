@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"todolist/config"
+	"todolist/database"
+	"todolist/mail"
+	"todolist/middleware"
+	"todolist/migrations"
+	"todolist/models"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// setupTest 使用临时 SQLite 数据库和默认配置初始化处理器，configure 可在 Setup 前修改配置
+func setupTest(t *testing.T, configure func(cfg *config.Config)) *config.Config {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Database.DSN = filepath.Join(t.TempDir(), "test.db")
+	if configure != nil {
+		configure(cfg)
+	}
+
+	if err := database.Connect(cfg.Database); err != nil {
+		t.Fatalf("连接数据库失败: %v", err)
+	}
+	t.Cleanup(func() {
+		database.Close()
+		database.DB = nil
+	})
+	if _, err := migrations.Up(database.DB, 0); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+
+	middleware.Setup(cfg)
+	if err := Setup(cfg); err != nil {
+		t.Fatalf("初始化处理器失败: %v", err)
+	}
+	return cfg
+}
+
+// createTestUser 创建已激活的普通用户
+func createTestUser(t *testing.T, username, email string, emailVerified bool) *models.User {
+	t.Helper()
+	user := &models.User{
+		Username:      username,
+		Password:      "Password123",
+		Email:         email,
+		EmailVerified: emailVerified,
+		Role:          models.RoleUser,
+		Status:        models.StatusActive,
+	}
+	if err := user.HashPassword(); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.DB.Create(user).Error; err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	return user
+}
+
+// sessionToken 为用户创建会话并返回访问令牌
+func sessionToken(t *testing.T, user *models.User) string {
+	t.Helper()
+	session := &models.Session{UserID: user.ID, Device: "test"}
+	if _, err := database.CreateSession(session, jwtConfig.RefreshTokenTTL.Std()); err != nil {
+		t.Fatal(err)
+	}
+	token, err := middleware.GenerateToken(user.ID, session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// doJSON 发送 JSON 请求，token 不为空时附带 Bearer 认证
+func doJSON(r http.Handler, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// decodeBody 解析 JSON 响应
+func decodeBody(w *httptest.ResponseRecorder, v interface{}) error {
+	return json.Unmarshal(w.Body.Bytes(), v)
+}
+
+// captureMailer 记录发送的邮件而不真正发送，邮件是异步发送的，通过 next 等待
+type captureMailer struct {
+	sent chan mail.Message
+}
+
+// useCaptureMailer 用 captureMailer 替换处理器使用的邮件发送器
+func useCaptureMailer(t *testing.T) *captureMailer {
+	t.Helper()
+	m := &captureMailer{sent: make(chan mail.Message, 16)}
+	mailer = m
+	return m
+}
+
+func (m *captureMailer) Send(ctx context.Context, msg mail.Message) error {
+	m.sent <- msg
+	return nil
+}
+
+// next 等待下一封邮件
+func (m *captureMailer) next(t *testing.T) mail.Message {
+	t.Helper()
+	select {
+	case msg := <-m.sent:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("等待邮件超时")
+		return mail.Message{}
+	}
+}
+
+// expectNone 检查没有发送邮件
+func (m *captureMailer) expectNone(t *testing.T) {
+	t.Helper()
+	select {
+	case msg := <-m.sent:
+		t.Fatalf("不应发送邮件，实际发送给 %s: %s", msg.To, msg.Subject)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+var mailLinkPattern = regexp.MustCompile(`https?://\S+`)
+
+// mailToken 从邮件正文的链接中取出 token 参数
+func mailToken(t *testing.T, msg mail.Message) string {
+	t.Helper()
+	link, err := url.Parse(mailLinkPattern.FindString(msg.Body))
+	if err != nil || link.Query().Get("token") == "" {
+		t.Fatalf("邮件中没有令牌链接: %s", msg.Body)
+	}
+	return link.Query().Get("token")
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"todolist/middleware"
+	"todolist/models"
+)
+
+// ImpersonateUser 签发以指定用户身份访问系统的短期令牌，用于排查用户反馈的问题。
+// 令牌同时记录管理员和被模拟用户，期间禁止敏感操作，所有请求都写入审计日志
+func ImpersonateUser(c *gin.Context) {
+	actor := c.MustGet("user").(*models.User)
+	sessionID, ok := c.Get("sessionID")
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "请使用账号登录后再模拟用户"})
+		return
+	}
+
+	target, ok := loadTargetUser(c)
+	if !ok {
+		return
+	}
+	if target.ID == actor.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不能模拟自己"})
+		return
+	}
+	if !target.IsActive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "只能模拟已激活的用户"})
+		return
+	}
+
+	// 不能模拟权限超出自己角色的用户
+	if !requireCoversTarget(c, target) {
+		return
+	}
+
+	token, err := middleware.GenerateImpersonationToken(target.ID, actor.ID, sessionID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "token生成失败"})
+		return
+	}
+	recordUserAudit(c, models.AuditUserImpersonate, target, target)
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":         token,
+		"expires_in":    int(middleware.ImpersonationTTL().Seconds()),
+		"impersonation": true,
+		"actor": gin.H{
+			"id":       actor.ID,
+			"username": actor.Username,
+		},
+		"user": gin.H{
+			"id":       target.ID,
+			"username": target.Username,
+			"role":     target.Role,
+			"status":   target.Status,
+		},
+	})
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"todolist/database"
+	"todolist/models"
+)
+
+// CreateInvite 生成邀请码
+func CreateInvite(c *gin.Context) {
+	var request models.CreateInviteRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	roleName := request.Role
+	if roleName == "" {
+		roleName = models.RoleUser
+	}
+	role, err := database.GetRole(roleName)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "角色不存在"})
+		return
+	}
+	// 与修改用户角色相同，不能通过邀请码授予超出自身权限的角色
+	if !c.MustGet("role").(*models.Role).Covers(role.Permissions) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "不能分配超出自身权限的角色"})
+		return
+	}
+
+	invite := &models.Invite{
+		Role:      role.Name,
+		MaxUses:   1,
+		Note:      request.Note,
+		CreatedBy: c.MustGet("userID").(uint),
+	}
+	if request.MaxUses != nil {
+		invite.MaxUses = *request.MaxUses
+	}
+	if request.ExpiresInDays > 0 {
+		expiresAt := models.CustomTime{Time: time.Now().UTC().AddDate(0, 0, request.ExpiresInDays)}
+		invite.ExpiresAt = &expiresAt
+	}
+
+	if err := database.CreateInvite(invite); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建邀请码失败"})
+		return
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:     models.AuditInviteCreate,
+		Resource:   "invite",
+		ResourceID: strconv.FormatUint(uint64(invite.ID), 10),
+		Changes:    database.AuditDiff(nil, invite),
+	})
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "邀请码已创建",
+		"invite":  invite,
+	})
+}
+
+// GetInvites 获取未吊销的邀请码列表
+func GetInvites(c *gin.Context) {
+	invites, err := database.ListInvites()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取邀请码失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invites)
+}
+
+// DeleteInvite 吊销邀请码，已注册的用户不受影响
+func DeleteInvite(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的邀请码ID"})
+		return
+	}
+
+	found, err := database.RevokeInvite(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "吊销邀请码失败"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "邀请码不存在"})
+		return
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:     models.AuditInviteRevoke,
+		Resource:   "invite",
+		ResourceID: c.Param("id"),
+	})
+	c.JSON(http.StatusOK, gin.H{"message": "邀请码已吊销"})
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"todolist/database"
+	"todolist/middleware"
+	"todolist/models"
+	"todolist/totp"
+)
+
+// SetupMFA 生成新的TOTP密钥，返回供认证器App扫描的地址，需调用 EnableMFA 确认后才生效
+func SetupMFA(c *gin.Context) {
+	currentUser, _ := c.Get("user")
+	user := currentUser.(*models.User)
+
+	if user.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "两步验证已开启"})
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成密钥失败"})
+		return
+	}
+	if err := database.DB.Model(user).Update("totp_secret", secret).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成密钥失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":           secret,
+		"provisioning_uri": totp.ProvisioningURI(mfaConfig.Issuer, user.Username, secret),
+	})
+}
+
+// EnableMFA 校验认证器App生成的验证码并开启两步验证，返回一次性恢复码
+func EnableMFA(c *gin.Context) {
+	currentUser, _ := c.Get("user")
+	user := currentUser.(*models.User)
+
+	var request models.MFACodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if user.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "两步验证已开启"})
+		return
+	}
+	if user.TOTPSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请先获取两步验证密钥"})
+		return
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret, request.Code, time.Now())
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证码错误"})
+		return
+	}
+
+	var codes []string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"totp_enabled":   true,
+			"totp_last_step": step,
+		}).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = database.GenerateRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "开启两步验证失败"})
+		return
+	}
+
+	recordAudit(c, models.AuditEvent{Action: models.AuditMFAEnable, TargetUserID: &user.ID})
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "两步验证已开启，请妥善保存恢复码，恢复码只显示一次",
+		"recovery_codes": codes,
+	})
+}
+
+// DisableMFA 校验密码和验证码后关闭两步验证
+func DisableMFA(c *gin.Context) {
+	currentUser, _ := c.Get("user")
+	user := currentUser.(*models.User)
+
+	var request models.MFADisableRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !user.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "两步验证未开启"})
+		return
+	}
+	if err := user.CheckPassword(request.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "密码错误"})
+		return
+	}
+	if !verifyTOTP(user, request.Code) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证码错误"})
+		return
+	}
+
+	if err := database.ResetMFA(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "关闭两步验证失败"})
+		return
+	}
+
+	recordAudit(c, models.AuditEvent{Action: models.AuditMFADisable, TargetUserID: &user.ID})
+	c.JSON(http.StatusOK, gin.H{"message": "两步验证已关闭"})
+}
+
+// RegenerateRecoveryCodes 校验验证码后重新生成恢复码
+func RegenerateRecoveryCodes(c *gin.Context) {
+	currentUser, _ := c.Get("user")
+	user := currentUser.(*models.User)
+
+	var request models.MFACodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !user.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "两步验证未开启"})
+		return
+	}
+	if !verifyTOTP(user, request.Code) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证码错误"})
+		return
+	}
+
+	codes, err := database.GenerateRecoveryCodes(database.DB, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成恢复码失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// VerifyMFA 登录第二步：校验挑战令牌和验证码（或恢复码），通过后创建会话
+func VerifyMFA(c *gin.Context) {
+	var request models.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if request.Code == "" && request.RecoveryCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请提供验证码或恢复码"})
+		return
+	}
+
+	claims, ok := middleware.ParseMFAToken(request.MFAToken)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "验证已过期，请重新登录"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, claims.UserID).Error; err != nil || !user.TOTPEnabled {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "验证已过期，请重新登录"})
+		return
+	}
+	if !user.IsActive() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "账号未激活或已被禁用"})
+		return
+	}
+
+	// 验证码错误同样计入登录限流
+	ip := c.ClientIP()
+	if !checkLoginThrottle(c, ip, user.Username) {
+		return
+	}
+
+	verified := false
+	if request.Code != "" {
+		verified = verifyTOTP(&user, request.Code)
+	} else {
+		used, err := database.UseRecoveryCode(user.ID, request.RecoveryCode)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "验证失败"})
+			return
+		}
+		verified = used
+	}
+	if !verified {
+		throttleFail(ip, user.Username)
+		recordLoginFailure(c, user.Username, &user)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "验证码错误"})
+		return
+	}
+	throttleSucceed(user.Username)
+
+	respondWithSession(c, &user, claims.Device)
+}
+
+// verifyTOTP 校验验证码并记录已使用的步数，同一验证码不能重复使用
+func verifyTOTP(user *models.User, code string) bool {
+	step, ok := totp.Validate(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return false
+	}
+	fresh, err := database.MarkTOTPStepUsed(user.ID, step)
+	return err == nil && fresh
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"todolist/config"
+	"todolist/database"
+	"todolist/middleware"
+	"todolist/models"
+	"todolist/totp"
+)
+
+func setupMFATest(t *testing.T, configure func(cfg *config.Config)) *gin.Engine {
+	t.Helper()
+	setupTest(t, configure)
+	r := gin.New()
+	r.POST("/auth/login", Login)
+	r.POST("/auth/mfa/verify", VerifyMFA)
+	r.POST("/auth/mfa/setup", middleware.AuthMiddleware(), SetupMFA)
+	r.POST("/auth/mfa/enable", middleware.AuthMiddleware(), EnableMFA)
+	r.POST("/auth/mfa/disable", middleware.AuthMiddleware(), DisableMFA)
+	return r
+}
+
+// totpCode 计算当前时间偏移 offset 步的验证码
+func totpCode(t *testing.T, secret string, offset int64) string {
+	t.Helper()
+	code, err := totp.Code(secret, totp.Step(time.Now())+offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// enrollMFA 为用户开启两步验证，返回密钥和恢复码
+func enrollMFA(t *testing.T, r *gin.Engine, user *models.User) (string, []string) {
+	t.Helper()
+	token := sessionToken(t, user)
+	w := doJSON(r, http.MethodPost, "/auth/mfa/setup", token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("获取密钥返回 %d: %s", w.Code, w.Body.String())
+	}
+	var setup struct {
+		Secret          string `json:"secret"`
+		ProvisioningURI string `json:"provisioning_uri"`
+	}
+	if err := decodeBody(w, &setup); err != nil || setup.Secret == "" || setup.ProvisioningURI == "" {
+		t.Fatalf("密钥响应不正确: %s", w.Body.String())
+	}
+
+	if w := doJSON(r, http.MethodPost, "/auth/mfa/enable", token, gin.H{"code": "000000"}); w.Code != http.StatusBadRequest {
+		t.Errorf("错误的验证码应当无法开启: %d", w.Code)
+	}
+	w = doJSON(r, http.MethodPost, "/auth/mfa/enable", token, gin.H{"code": totpCode(t, setup.Secret, 0)})
+	if w.Code != http.StatusOK {
+		t.Fatalf("开启两步验证返回 %d: %s", w.Code, w.Body.String())
+	}
+	var enabled struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	if err := decodeBody(w, &enabled); err != nil || len(enabled.RecoveryCodes) != database.RecoveryCodeCount {
+		t.Fatalf("应当返回 %d 个恢复码: %s", database.RecoveryCodeCount, w.Body.String())
+	}
+	return setup.Secret, enabled.RecoveryCodes
+}
+
+// mfaChallenge 使用密码登录，返回两步验证的挑战令牌
+func mfaChallenge(t *testing.T, r *gin.Engine, username string) string {
+	t.Helper()
+	w := doJSON(r, http.MethodPost, "/auth/login", "", gin.H{"username": username, "password": "Password123"})
+	var body struct {
+		Token       string `json:"token"`
+		MFARequired bool   `json:"mfa_required"`
+		MFAToken    string `json:"mfa_token"`
+	}
+	if w.Code != http.StatusOK || decodeBody(w, &body) != nil {
+		t.Fatalf("登录返回 %d: %s", w.Code, w.Body.String())
+	}
+	if !body.MFARequired || body.MFAToken == "" || body.Token != "" {
+		t.Fatalf("开启两步验证后登录应当只返回挑战令牌: %s", w.Body.String())
+	}
+	return body.MFAToken
+}
+
+func verifyMFA(r *gin.Engine, mfaToken string, body gin.H) int {
+	body["mfa_token"] = mfaToken
+	return doJSON(r, http.MethodPost, "/auth/mfa/verify", "", body).Code
+}
+
+func TestMFALogin(t *testing.T) {
+	r := setupMFATest(t, nil)
+	user := createTestUser(t, "alice", "alice@example.com", true)
+	secret, _ := enrollMFA(t, r, user)
+
+	challenge := mfaChallenge(t, r, "alice")
+	// 开启时使用过的验证码不能再次使用
+	if code := verifyMFA(r, challenge, gin.H{"code": totpCode(t, secret, 0)}); code != http.StatusUnauthorized {
+		t.Errorf("重放的验证码应当返回 401，实际 %d", code)
+	}
+	next := totpCode(t, secret, 1)
+	if code := verifyMFA(r, challenge, gin.H{"code": next}); code != http.StatusOK {
+		t.Fatalf("有效的验证码应当登录成功，实际 %d", code)
+	}
+	// 登录成功后同一验证码也不能再用于下一次登录
+	if code := verifyMFA(r, mfaChallenge(t, r, "alice"), gin.H{"code": next}); code != http.StatusUnauthorized {
+		t.Errorf("已使用的验证码应当返回 401，实际 %d", code)
+	}
+
+	if code := verifyMFA(r, "invalid", gin.H{"code": next}); code != http.StatusUnauthorized {
+		t.Errorf("无效的挑战令牌应当返回 401，实际 %d", code)
+	}
+	// 会话令牌不能当作挑战令牌使用
+	if code := verifyMFA(r, sessionToken(t, user), gin.H{"code": next}); code != http.StatusUnauthorized {
+		t.Errorf("会话令牌不应通过两步验证，实际 %d", code)
+	}
+}
+
+func TestMFARecoveryCode(t *testing.T) {
+	r := setupMFATest(t, nil)
+	user := createTestUser(t, "bob", "bob@example.com", true)
+	_, codes := enrollMFA(t, r, user)
+
+	if code := verifyMFA(r, mfaChallenge(t, r, "bob"), gin.H{"recovery_code": "aaaa-aaaa"}); code != http.StatusUnauthorized {
+		t.Errorf("错误的恢复码应当返回 401，实际 %d", code)
+	}
+	// 恢复码不区分大小写，每个只能使用一次
+	if code := verifyMFA(r, mfaChallenge(t, r, "bob"), gin.H{"recovery_code": " " + codes[0] + " "}); code != http.StatusOK {
+		t.Fatalf("恢复码应当登录成功，实际 %d", code)
+	}
+	if code := verifyMFA(r, mfaChallenge(t, r, "bob"), gin.H{"recovery_code": codes[0]}); code != http.StatusUnauthorized {
+		t.Errorf("已使用的恢复码应当返回 401，实际 %d", code)
+	}
+	if left, err := database.CountRecoveryCodes(user.ID); err != nil || left != int64(len(codes)-1) {
+		t.Errorf("剩余恢复码应为 %d，实际 %d %v", len(codes)-1, left, err)
+	}
+	if code := verifyMFA(r, mfaChallenge(t, r, "bob"), gin.H{}); code != http.StatusBadRequest {
+		t.Errorf("没有验证码和恢复码时应当返回 400，实际 %d", code)
+	}
+}
+
+func TestMFAThrottle(t *testing.T) {
+	r := setupMFATest(t, func(cfg *config.Config) {
+		cfg.Login.BackoffAfter = 100
+		cfg.Login.LockoutThreshold = 3
+	})
+	user := createTestUser(t, "carol", "carol@example.com", true)
+	secret, _ := enrollMFA(t, r, user)
+
+	challenge := mfaChallenge(t, r, "carol")
+	for i := 0; i < 3; i++ {
+		if code := verifyMFA(r, challenge, gin.H{"code": "000000"}); code != http.StatusUnauthorized {
+			t.Fatalf("第 %d 次错误的验证码应当返回 401，实际 %d", i+1, code)
+		}
+	}
+	// 错误的验证码计入登录限流，锁定后正确的验证码也被拒绝
+	if code := verifyMFA(r, challenge, gin.H{"code": totpCode(t, secret, 1)}); code != http.StatusTooManyRequests {
+		t.Errorf("锁定后应当返回 429，实际 %d", code)
+	}
+	w := doJSON(r, http.MethodPost, "/auth/login", "", gin.H{"username": "carol", "password": "Password123"})
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("锁定后密码登录也应当返回 429，实际 %d", w.Code)
+	}
+}
+
+func TestMFADisable(t *testing.T) {
+	r := setupMFATest(t, nil)
+	user := createTestUser(t, "dave", "dave@example.com", true)
+	secret, _ := enrollMFA(t, r, user)
+	token := sessionToken(t, user)
+
+	if w := doJSON(r, http.MethodPost, "/auth/mfa/disable", token, gin.H{"password": "wrong", "code": totpCode(t, secret, 1)}); w.Code != http.StatusBadRequest {
+		t.Errorf("密码错误时应当返回 400，实际 %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodPost, "/auth/mfa/disable", token, gin.H{"password": "Password123", "code": totpCode(t, secret, 1)}); w.Code != http.StatusOK {
+		t.Fatalf("关闭两步验证返回 %d: %s", w.Code, w.Body.String())
+	}
+	var loaded models.User
+	database.DB.First(&loaded, user.ID)
+	if loaded.TOTPEnabled || loaded.TOTPSecret != "" {
+		t.Error("关闭后应当删除密钥")
+	}
+	if left, _ := database.CountRecoveryCodes(user.ID); left != 0 {
+		t.Errorf("关闭后应当删除恢复码，剩余 %d 个", left)
+	}
+	login(t, r, "dave", "Password123")
+}
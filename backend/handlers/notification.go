@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"todolist/database"
+	"todolist/models"
+)
+
+// ntfyTopicPattern ntfy 主题只能包含字母、数字、下划线和连字符
+var ntfyTopicPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// GetNotifications 分页获取当前用户的站内信，按时间倒序，unread=true 时只返回未读
+func GetNotifications(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	query := database.DB.Model(&models.Notification{}).Where("user_id = ?", user.ID)
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total, unread int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取通知失败"})
+		return
+	}
+	if err := database.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", user.ID).Count(&unread).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取通知失败"})
+		return
+	}
+
+	notifications := []models.Notification{}
+	if err := query.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&notifications).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取通知失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":         total,
+		"unread":        unread,
+		"page":          page,
+		"page_size":     pageSize,
+		"notifications": notifications,
+	})
+}
+
+// UpdateNotification 将站内信标记为已读或未读
+func UpdateNotification(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	var notification models.Notification
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), user.ID).First(&notification).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "通知不存在"})
+		return
+	}
+	var request models.UpdateNotificationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !*request.Read {
+		notification.ReadAt = nil
+	} else if notification.ReadAt == nil {
+		notification.ReadAt = &models.CustomTime{Time: time.Now().UTC()}
+	}
+	if err := database.DB.Model(&notification).Update("read_at", notification.ReadAt).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新通知失败"})
+		return
+	}
+	c.JSON(http.StatusOK, notification)
+}
+
+// MarkAllNotificationsRead 将当前用户的全部站内信标记为已读
+func MarkAllNotificationsRead(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	result := database.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", user.ID).
+		Update("read_at", time.Now().UTC())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新通知失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"updated": result.RowsAffected})
+}
+
+// DeleteNotification 删除站内信
+func DeleteNotification(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	result := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), user.ID).Delete(&models.Notification{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除通知失败"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "通知不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// GetNotificationSettings 获取当前用户的通知设置
+func GetNotificationSettings(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	settings, err := database.GetNotificationSettings(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取通知设置失败"})
+		return
+	}
+	c.JSON(http.StatusOK, notificationSettingsResponse(settings))
+}
+
+// UpdateNotificationSettings 修改当前用户的通知设置，字段为空字符串表示清除
+func UpdateNotificationSettings(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	var request models.UpdateNotificationSettingsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	settings, err := database.GetNotificationSettings(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取通知设置失败"})
+		return
+	}
+
+	if request.WebhookURL != nil {
+		if *request.WebhookURL != "" {
+			u, err := url.Parse(*request.WebhookURL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Webhook 地址必须是 http 或 https 地址"})
+				return
+			}
+		}
+		settings.WebhookURL = *request.WebhookURL
+	}
+	if request.WebhookSecret != nil {
+		settings.WebhookSecret = *request.WebhookSecret
+	}
+	if request.NtfyTopic != nil {
+		if *request.NtfyTopic != "" && !ntfyTopicPattern.MatchString(*request.NtfyTopic) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ntfy 主题只能包含字母、数字、下划线和连字符"})
+			return
+		}
+		settings.NtfyTopic = *request.NtfyTopic
+	}
+	if request.DefaultChannels != nil {
+		settings.DefaultChannels = models.StringSlice{}
+		for _, ch := range request.DefaultChannels {
+			if !containsString(settings.DefaultChannels, ch) {
+				settings.DefaultChannels = append(settings.DefaultChannels, ch)
+			}
+		}
+	}
+	for _, ch := range settings.DefaultChannels {
+		if msg := channelUnavailable(ch, user, settings); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+	}
+
+	if err := database.DB.Save(settings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新通知设置失败"})
+		return
+	}
+	c.JSON(http.StatusOK, notificationSettingsResponse(settings))
+}
+
+// notificationSettingsResponse 通知设置的响应，Webhook 密钥只返回是否已设置
+func notificationSettingsResponse(settings *models.NotificationSettings) gin.H {
+	return gin.H{
+		"webhook_url":        settings.WebhookURL,
+		"webhook_secret_set": settings.WebhookSecret != "",
+		"ntfy_topic":         settings.NtfyTopic,
+		"default_channels":   settings.DefaultChannels,
+	}
+}
@@ -0,0 +1,342 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"gorm.io/gorm"
+	"todolist/database"
+	"todolist/middleware"
+	"todolist/models"
+	"todolist/oidc"
+	"todolist/util"
+)
+
+const (
+	oidcStateCookie = "oidc_state"
+	oidcStateTTL    = 10 * time.Minute
+)
+
+// oidcStateClaims 登录发起时保存在 Cookie 中的状态，回调时用于校验 state/nonce 和 PKCE；
+// LinkUserID 不为 0 时表示已登录用户发起的关联，回调时把身份关联到该用户而不是登录
+type oidcStateClaims struct {
+	State      string `json:"state"`
+	Nonce      string `json:"nonce"`
+	Verifier   string `json:"verifier"`
+	LinkUserID uint   `json:"link_user_id,omitempty"`
+	jwt.StandardClaims
+}
+
+// OIDCLogin 跳转到身份提供方进行单点登录
+func OIDCLogin(c *gin.Context) {
+	if oidcProvider == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未启用单点登录"})
+		return
+	}
+	authURL, ok := startOIDC(c, 0)
+	if !ok {
+		return
+	}
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCLink 为当前用户关联身份提供方的账号，返回授权地址，由前端跳转；回调后身份关联到当前用户
+func OIDCLink(c *gin.Context) {
+	if oidcProvider == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未启用单点登录"})
+		return
+	}
+	user := c.MustGet("user").(*models.User)
+	authURL, ok := startOIDC(c, user.ID)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"url": authURL})
+}
+
+// startOIDC 生成 state/nonce/PKCE 并写入状态 Cookie，返回身份提供方的授权地址；失败时写入错误响应
+func startOIDC(c *gin.Context, linkUserID uint) (string, bool) {
+	var values [3]string
+	for i := range values {
+		v, err := database.RandomToken(32)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "发起单点登录失败"})
+			return "", false
+		}
+		values[i] = v
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+
+	authURL, err := oidcProvider.AuthCodeURL(c.Request.Context(), state, nonce, verifier)
+	if err != nil {
+		log.Printf("oidc: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "连接身份提供方失败"})
+		return "", false
+	}
+
+	claims := oidcStateClaims{
+		State:      state,
+		Nonce:      nonce,
+		Verifier:   verifier,
+		LinkUserID: linkUserID,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(oidcStateTTL).Unix(),
+		},
+	}
+	cookie, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(jwtConfig.Secret))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "发起单点登录失败"})
+		return "", false
+	}
+	setOIDCStateCookie(c, cookie, int(oidcStateTTL.Seconds()))
+	return authURL, true
+}
+
+// OIDCCallback 身份提供方回调：校验授权结果，关联或创建用户后跳转回前端
+func OIDCCallback(c *gin.Context) {
+	if oidcProvider == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未启用单点登录"})
+		return
+	}
+
+	cookie, _ := c.Cookie(oidcStateCookie)
+	setOIDCStateCookie(c, "", -1)
+
+	if errCode := c.Query("error"); errCode != "" {
+		redirectAfterOIDC(c, url.Values{"error": {"身份提供方拒绝了登录请求"}})
+		return
+	}
+
+	claims := &oidcStateClaims{}
+	token, err := jwt.ParseWithClaims(cookie, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return []byte(jwtConfig.Secret), nil
+	})
+	if cookie == "" || err != nil || !token.Valid || c.Query("state") != claims.State {
+		redirectAfterOIDC(c, url.Values{"error": {"登录已过期，请重新登录"}})
+		return
+	}
+
+	ctx := c.Request.Context()
+	tokens, err := oidcProvider.Exchange(ctx, c.Query("code"), claims.Verifier)
+	if err != nil {
+		log.Printf("oidc: %v", err)
+		redirectAfterOIDC(c, url.Values{"error": {"单点登录失败"}})
+		return
+	}
+	idToken, err := oidcProvider.VerifyIDToken(ctx, tokens.IDToken, claims.Nonce)
+	if err != nil {
+		log.Printf("oidc: %v", err)
+		redirectAfterOIDC(c, url.Values{"error": {"单点登录失败"}})
+		return
+	}
+
+	if claims.LinkUserID != 0 {
+		if err := linkOIDCIdentity(c, claims.LinkUserID, idToken); err != nil {
+			redirectAfterOIDC(c, url.Values{"error": {err.Error()}})
+			return
+		}
+		redirectAfterOIDC(c, url.Values{"linked": {"true"}})
+		return
+	}
+
+	user, err := resolveOIDCUser(idToken)
+	if err != nil {
+		redirectAfterOIDC(c, url.Values{"error": {err.Error()}})
+		return
+	}
+
+	// 与密码登录一致的审核门槛
+	if user.Status == models.StatusInactive {
+		redirectAfterOIDC(c, url.Values{"error": {"账号未激活，请等待管理员审核"}})
+		return
+	}
+	if user.Status == models.StatusBlocked {
+		redirectAfterOIDC(c, url.Values{"error": {"账号已被禁用，请联系管理员"}})
+		return
+	}
+
+	if user.TOTPEnabled {
+		mfaToken, err := middleware.GenerateMFAToken(user.ID, "sso")
+		if err != nil {
+			redirectAfterOIDC(c, url.Values{"error": {"单点登录失败"}})
+			return
+		}
+		redirectAfterOIDC(c, url.Values{"mfa_required": {"true"}, "mfa_token": {mfaToken}})
+		return
+	}
+
+	accessToken, refreshToken, err := createSession(c, user, "sso")
+	if err != nil {
+		redirectAfterOIDC(c, url.Values{"error": {"单点登录失败"}})
+		return
+	}
+	redirectAfterOIDC(c, url.Values{
+		"token":         {accessToken},
+		"refresh_token": {refreshToken},
+		"expires_in":    {fmt.Sprint(int(middleware.AccessTokenTTL().Seconds()))},
+	})
+}
+
+// linkOIDCIdentity 把身份关联到发起关联的用户，身份已关联其他账号时返回错误
+func linkOIDCIdentity(c *gin.Context, userID uint, idToken *oidc.IDTokenClaims) error {
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil || user.Status == models.StatusBlocked {
+		return errors.New("账号不存在或已被禁用")
+	}
+
+	var identity models.UserIdentity
+	err := database.DB.Where("issuer = ? AND subject = ?", idToken.Issuer, idToken.Subject).Limit(1).Find(&identity).Error
+	if err != nil {
+		return errors.New("关联失败")
+	}
+	if identity.ID != 0 {
+		if identity.UserID != user.ID {
+			return errors.New("该身份已关联其他账号")
+		}
+		return nil
+	}
+
+	identity = models.UserIdentity{
+		UserID:  user.ID,
+		Issuer:  idToken.Issuer,
+		Subject: idToken.Subject,
+		Email:   idToken.Email,
+	}
+	if err := database.DB.Create(&identity).Error; err != nil {
+		return errors.New("关联失败")
+	}
+	recordAudit(c, models.AuditEvent{
+		ActorID:       &user.ID,
+		ActorUsername: user.Username,
+		TargetUserID:  &user.ID,
+		Action:        models.AuditOIDCLink,
+		Resource:      "identity",
+		ResourceID:    fmt.Sprint(identity.ID),
+	})
+	return nil
+}
+
+// resolveOIDCUser 按 (issuer, subject) 查找已关联的用户，其次按双方都已验证的邮箱关联，最后按配置自动创建
+func resolveOIDCUser(idToken *oidc.IDTokenClaims) (*models.User, error) {
+	var identity models.UserIdentity
+	err := database.DB.Where("issuer = ? AND subject = ?", idToken.Issuer, idToken.Subject).First(&identity).Error
+	if err == nil {
+		var user models.User
+		if err := database.DB.First(&user, identity.UserID).Error; err != nil {
+			return nil, errors.New("关联的账号不存在")
+		}
+		return &user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("单点登录失败")
+	}
+
+	var user models.User
+	linked := false
+	if oidcConfig.LinkByEmail && idToken.EmailVerified && idToken.Email != "" {
+		if err := database.DB.Where("LOWER(email) = ?", strings.ToLower(idToken.Email)).First(&user).Error; err == nil {
+			// 本地邮箱未经验证时可能是他人抢先注册的，不能仅凭邮箱关联
+			if !user.EmailVerified {
+				return nil, errors.New("该邮箱已被本地账号使用，请登录该账号后在个人设置中关联单点登录")
+			}
+			linked = true
+		}
+	}
+	if !linked && !oidcConfig.AutoProvision {
+		return nil, errors.New("该身份未关联任何账号，请联系管理员")
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if !linked {
+			username, err := uniqueUsername(tx, idToken)
+			if err != nil {
+				return err
+			}
+			// 单点登录用户不使用本地密码，设置随机密码
+			password, err := database.RandomToken(32)
+			if err != nil {
+				return err
+			}
+			user = models.User{
+				Username: username,
+				Password: password,
+				Role:     models.RoleUser,
+				Status:   models.StatusInactive,
+			}
+			if idToken.EmailVerified {
+				user.Email = idToken.Email
+				user.EmailVerified = true
+				// 已验证邮箱属于白名单域名时直接激活
+				if isAllowedDomain(idToken.Email) {
+					user.Status = models.StatusActive
+				}
+			}
+			if err := user.HashPassword(); err != nil {
+				return err
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&models.UserIdentity{
+			UserID:  user.ID,
+			Issuer:  idToken.Issuer,
+			Subject: idToken.Subject,
+			Email:   idToken.Email,
+		}).Error
+	})
+	if err != nil {
+		return nil, errors.New("单点登录失败")
+	}
+	return &user, nil
+}
+
+var usernameSanitizer = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// uniqueUsername 根据 preferred_username 或邮箱前缀生成不重复的用户名
+func uniqueUsername(tx *gorm.DB, idToken *oidc.IDTokenClaims) (string, error) {
+	base := idToken.PreferredUsername
+	if base == "" && idToken.Email != "" {
+		base = strings.SplitN(idToken.Email, "@", 2)[0]
+	}
+	base = usernameSanitizer.ReplaceAllString(base, "")
+	if len(base) < 3 {
+		base = "sso_" + base
+	}
+	base = util.Truncate(base, 24)
+
+	candidate := base
+	for i := 1; i <= 100; i++ {
+		var count int64
+		if err := tx.Model(&models.User{}).Where("username = ?", candidate).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s_%d", base, i)
+	}
+	return "", errors.New("无法生成用户名")
+}
+
+// redirectAfterOIDC 跳转回前端，结果通过 URL fragment 传递，避免令牌出现在服务器日志中
+func redirectAfterOIDC(c *gin.Context, values url.Values) {
+	c.Redirect(http.StatusFound, oidcConfig.PostLoginRedirect+"#"+values.Encode())
+}
+
+func setOIDCStateCookie(c *gin.Context, value string, maxAge int) {
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, value, maxAge, "/", "", secure, true)
+}
@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"todolist/config"
+	"todolist/database"
+	"todolist/middleware"
+	"todolist/models"
+	"todolist/oidc/oidctest"
+)
+
+const oidcTestRedirect = "http://app.test/auth/oidc/callback"
+
+// setupOIDCTest 启动模拟身份提供方并返回只注册了单点登录路由的引擎
+func setupOIDCTest(t *testing.T, configure func(cfg *config.Config)) (*oidctest.Provider, *gin.Engine) {
+	t.Helper()
+	idp := oidctest.NewProvider("todo-client", "todo-secret")
+	t.Cleanup(idp.Close)
+
+	setupTest(t, func(cfg *config.Config) {
+		cfg.OIDC.Enabled = true
+		cfg.OIDC.Issuer = idp.Issuer()
+		cfg.OIDC.ClientID = idp.ClientID
+		cfg.OIDC.ClientSecret = idp.ClientSecret
+		cfg.OIDC.RedirectURL = oidcTestRedirect
+		cfg.OIDC.PostLoginRedirect = "http://app.test/sso"
+		if configure != nil {
+			configure(cfg)
+		}
+	})
+
+	r := gin.New()
+	r.GET("/auth/oidc/login", OIDCLogin)
+	r.GET("/auth/oidc/callback", OIDCCallback)
+	r.POST("/auth/oidc/link", middleware.AuthMiddleware(), OIDCLink)
+	return idp, r
+}
+
+// completeOIDC 在模拟身份提供方完成授权并请求回调，返回跳转回前端时 fragment 中的结果
+func completeOIDC(t *testing.T, r *gin.Engine, authURL string, cookies []*http.Cookie, editCallback func(q url.Values)) url.Values {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || resp.StatusCode != http.StatusFound {
+		t.Fatalf("授权端点返回 %d: %v", resp.StatusCode, err)
+	}
+
+	q := location.Query()
+	if editCallback != nil {
+		editCallback(q)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/auth/oidc/callback?"+q.Encode(), nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusFound {
+		t.Fatalf("回调返回 %d: %s", w.Code, w.Body.String())
+	}
+	result, err := url.Parse(w.Header().Get("Location"))
+	if err != nil || !strings.HasPrefix(result.String(), "http://app.test/sso#") {
+		t.Fatalf("回调跳转地址不正确: %s", w.Header().Get("Location"))
+	}
+	values, err := url.ParseQuery(result.Fragment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return values
+}
+
+// oidcLogin 发起单点登录并完成整个流程
+func oidcLogin(t *testing.T, r *gin.Engine, editCallback func(q url.Values)) url.Values {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("发起登录返回 %d: %s", w.Code, w.Body.String())
+	}
+	return completeOIDC(t, r, w.Header().Get("Location"), w.Result().Cookies(), editCallback)
+}
+
+func findIdentity(t *testing.T, issuer, subject string) *models.UserIdentity {
+	t.Helper()
+	var identity models.UserIdentity
+	if err := database.DB.Where("issuer = ? AND subject = ?", issuer, subject).Limit(1).Find(&identity).Error; err != nil {
+		t.Fatal(err)
+	}
+	if identity.ID == 0 {
+		return nil
+	}
+	return &identity
+}
+
+func TestOIDCAutoProvision(t *testing.T) {
+	idp, r := setupOIDCTest(t, func(cfg *config.Config) {
+		cfg.OIDC.AutoProvision = true
+		cfg.Registration.AllowedDomains = []string{"example.com"}
+	})
+	idp.SetUser(map[string]interface{}{
+		"sub":                "alice-sub",
+		"email":              "alice@example.com",
+		"email_verified":     true,
+		"preferred_username": "alice",
+	})
+
+	result := oidcLogin(t, r, nil)
+	if result.Get("token") == "" || result.Get("refresh_token") == "" {
+		t.Fatalf("登录应当返回令牌: %v", result)
+	}
+
+	identity := findIdentity(t, idp.Issuer(), "alice-sub")
+	if identity == nil {
+		t.Fatal("应当创建身份关联")
+	}
+	var user models.User
+	if err := database.DB.First(&user, identity.UserID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "alice" || user.Email != "alice@example.com" || !user.EmailVerified ||
+		user.Status != models.StatusActive {
+		t.Errorf("自动创建的用户不正确: %+v", user)
+	}
+
+	// 再次登录使用已关联的账号，不重复创建
+	if result := oidcLogin(t, r, nil); result.Get("token") == "" {
+		t.Fatalf("再次登录失败: %v", result)
+	}
+	var count int64
+	database.DB.Model(&models.User{}).Count(&count)
+	if count != 1 {
+		t.Errorf("用户数为 %d，应当为 1", count)
+	}
+}
+
+func TestOIDCAutoProvisionPending(t *testing.T) {
+	idp, r := setupOIDCTest(t, func(cfg *config.Config) {
+		cfg.OIDC.AutoProvision = true
+	})
+	// 邮箱未经身份提供方验证时不保存邮箱，账号等待审核
+	idp.SetUser(map[string]interface{}{
+		"sub":            "bob-sub",
+		"email":          "bob@example.com",
+		"email_verified": false,
+	})
+
+	result := oidcLogin(t, r, nil)
+	if result.Get("error") != "账号未激活，请等待管理员审核" {
+		t.Fatalf("未激活账号不应登录: %v", result)
+	}
+	identity := findIdentity(t, idp.Issuer(), "bob-sub")
+	if identity == nil {
+		t.Fatal("应当创建身份关联")
+	}
+	var user models.User
+	database.DB.First(&user, identity.UserID)
+	if user.Email != "" || user.EmailVerified || user.Status != models.StatusInactive {
+		t.Errorf("自动创建的用户不正确: %+v", user)
+	}
+}
+
+func TestOIDCUnlinkedWithoutAutoProvision(t *testing.T) {
+	_, r := setupOIDCTest(t, nil)
+	result := oidcLogin(t, r, nil)
+	if result.Get("error") != "该身份未关联任何账号，请联系管理员" {
+		t.Fatalf("未关联的身份不应登录: %v", result)
+	}
+	var count int64
+	database.DB.Model(&models.User{}).Count(&count)
+	if count != 0 {
+		t.Errorf("不应创建用户，实际 %d 个", count)
+	}
+}
+
+func TestOIDCLinkByEmail(t *testing.T) {
+	idp, r := setupOIDCTest(t, func(cfg *config.Config) {
+		cfg.OIDC.LinkByEmail = true
+	})
+	verified := createTestUser(t, "carol", "Carol@example.com", true)
+	createTestUser(t, "mallory", "victim@example.com", false)
+
+	t.Run("verified local email", func(t *testing.T) {
+		idp.SetUser(map[string]interface{}{"sub": "carol-sub", "email": "carol@example.com", "email_verified": true})
+		result := oidcLogin(t, r, nil)
+		if result.Get("token") == "" {
+			t.Fatalf("应当关联并登录: %v", result)
+		}
+		identity := findIdentity(t, idp.Issuer(), "carol-sub")
+		if identity == nil || identity.UserID != verified.ID {
+			t.Fatalf("身份应当关联到 carol: %+v", identity)
+		}
+	})
+
+	t.Run("unverified local email", func(t *testing.T) {
+		idp.SetUser(map[string]interface{}{"sub": "victim-sub", "email": "victim@example.com", "email_verified": true})
+		result := oidcLogin(t, r, nil)
+		if result.Get("token") != "" || !strings.Contains(result.Get("error"), "关联单点登录") {
+			t.Fatalf("本地邮箱未验证时不应关联: %v", result)
+		}
+		if findIdentity(t, idp.Issuer(), "victim-sub") != nil {
+			t.Error("不应创建身份关联")
+		}
+	})
+
+	t.Run("unverified idp email", func(t *testing.T) {
+		idp.SetUser(map[string]interface{}{"sub": "carol-2", "email": "carol@example.com", "email_verified": false})
+		result := oidcLogin(t, r, nil)
+		if result.Get("token") != "" {
+			t.Fatalf("身份提供方未验证邮箱时不应关联: %v", result)
+		}
+		if findIdentity(t, idp.Issuer(), "carol-2") != nil {
+			t.Error("不应创建身份关联")
+		}
+	})
+}
+
+func TestOIDCExplicitLink(t *testing.T) {
+	idp, r := setupOIDCTest(t, nil)
+	user := createTestUser(t, "dave", "dave@example.com", false)
+	other := createTestUser(t, "erin", "erin@example.com", false)
+	idp.SetUser(map[string]interface{}{"sub": "dave-sub", "email": "dave@example.com", "email_verified": true})
+
+	startLink := func(t *testing.T, u *models.User) (string, []*http.Cookie) {
+		t.Helper()
+		w := doJSON(r, http.MethodPost, "/auth/oidc/link", sessionToken(t, u), nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("发起关联返回 %d: %s", w.Code, w.Body.String())
+		}
+		var body struct {
+			URL string `json:"url"`
+		}
+		if err := decodeBody(w, &body); err != nil || body.URL == "" {
+			t.Fatalf("响应中缺少授权地址: %s", w.Body.String())
+		}
+		return body.URL, w.Result().Cookies()
+	}
+
+	if w := doJSON(r, http.MethodPost, "/auth/oidc/link", "", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("未登录时应当拒绝关联，实际 %d", w.Code)
+	}
+
+	authURL, cookies := startLink(t, user)
+	result := completeOIDC(t, r, authURL, cookies, nil)
+	if result.Get("linked") != "true" {
+		t.Fatalf("关联失败: %v", result)
+	}
+	identity := findIdentity(t, idp.Issuer(), "dave-sub")
+	if identity == nil || identity.UserID != user.ID {
+		t.Fatalf("身份应当关联到 dave: %+v", identity)
+	}
+	var audits int64
+	database.DB.Model(&models.AuditEvent{}).Where("action = ? AND target_user_id = ?", models.AuditOIDCLink, user.ID).Count(&audits)
+	if audits != 1 {
+		t.Errorf("应当记录 1 条关联审计日志，实际 %d", audits)
+	}
+
+	// 关联后可以直接单点登录
+	if result := oidcLogin(t, r, nil); result.Get("token") == "" {
+		t.Fatalf("关联后登录失败: %v", result)
+	}
+
+	// 同一身份不能再关联到其他账号
+	authURL, cookies = startLink(t, other)
+	result = completeOIDC(t, r, authURL, cookies, nil)
+	if result.Get("error") != "该身份已关联其他账号" {
+		t.Fatalf("身份已关联时应当拒绝: %v", result)
+	}
+	if identity := findIdentity(t, idp.Issuer(), "dave-sub"); identity.UserID != user.ID {
+		t.Error("身份关联被改到了其他账号")
+	}
+}
+
+func TestOIDCCallbackRejects(t *testing.T) {
+	idp, r := setupOIDCTest(t, func(cfg *config.Config) {
+		cfg.OIDC.AutoProvision = true
+	})
+
+	t.Run("state mismatch", func(t *testing.T) {
+		result := oidcLogin(t, r, func(q url.Values) { q.Set("state", "forged") })
+		if result.Get("error") != "登录已过期，请重新登录" {
+			t.Fatalf("state 不匹配时应当拒绝: %v", result)
+		}
+	})
+
+	t.Run("missing state cookie", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil))
+		result := completeOIDC(t, r, w.Header().Get("Location"), nil, nil)
+		if result.Get("error") != "登录已过期，请重新登录" {
+			t.Fatalf("缺少状态 Cookie 时应当拒绝: %v", result)
+		}
+	})
+
+	t.Run("idp error", func(t *testing.T) {
+		result := oidcLogin(t, r, func(q url.Values) { q.Set("error", "access_denied") })
+		if result.Get("error") != "身份提供方拒绝了登录请求" {
+			t.Fatalf("身份提供方返回错误时应当拒绝: %v", result)
+		}
+	})
+
+	claimCases := map[string]func(claims map[string]interface{}){
+		"bad nonce": func(c map[string]interface{}) { c["nonce"] = "replayed" },
+		"bad iss":   func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" },
+		"bad aud":   func(c map[string]interface{}) { c["aud"] = "other-client" },
+	}
+	for name, edit := range claimCases {
+		t.Run(name, func(t *testing.T) {
+			idp.EditClaims(edit)
+			defer idp.EditClaims(nil)
+			result := oidcLogin(t, r, nil)
+			if result.Get("error") != "单点登录失败" || result.Get("token") != "" {
+				t.Fatalf("应当拒绝该 ID Token: %v", result)
+			}
+		})
+	}
+
+	var count int64
+	database.DB.Model(&models.User{}).Count(&count)
+	if count != 0 {
+		t.Errorf("被拒绝的登录不应创建用户，实际 %d 个", count)
+	}
+}
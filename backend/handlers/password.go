@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"todolist/config"
+	"todolist/database"
+	"todolist/models"
+	"todolist/pwpolicy"
+)
+
+// NewPasswordPolicy 根据配置创建密码策略
+func NewPasswordPolicy(cfg config.PasswordConfig) (*pwpolicy.Policy, error) {
+	return pwpolicy.New(pwpolicy.Config{
+		MinLength:        cfg.MinLength,
+		RequireUpper:     cfg.RequireUpper,
+		RequireLower:     cfg.RequireLower,
+		RequireDigit:     cfg.RequireDigit,
+		RequireSymbol:    cfg.RequireSymbol,
+		DisallowUsername: cfg.DisallowUsername,
+		BreachFile:       cfg.BreachFile,
+	})
+}
+
+// GetPasswordPolicy 获取密码策略，供前端提示
+func GetPasswordPolicy(c *gin.Context) {
+	rules := passwordPolicy.Rules()
+	rules["history_size"] = passwordConfig.HistorySize
+	c.JSON(http.StatusOK, rules)
+}
+
+// validateNewPassword 按密码策略和历史密码检查新密码，不通过时写入响应并返回 false；
+// 新用户（ID 为 0）只检查密码策略
+func validateNewPassword(c *gin.Context, user *models.User, password string) bool {
+	if err := passwordPolicy.Check(password, user.Username); err != nil {
+		var violation *pwpolicy.Violation
+		if errors.As(err, &violation) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": violation.Error()})
+		} else {
+			log.Printf("检查密码策略失败: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "检查密码失败"})
+		}
+		return false
+	}
+
+	reused, err := database.PasswordReused(user, password, passwordConfig.HistorySize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "检查密码失败"})
+		return false
+	}
+	if reused {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("不能使用最近%d次用过的密码", passwordConfig.HistorySize)})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"todolist/database"
+	"todolist/mail"
+	"todolist/models"
+)
+
+// ForgotPassword 向账号邮箱发送重置密码链接；无论邮箱是否存在都返回相同结果，避免泄露账号信息
+func ForgotPassword(c *gin.Context) {
+	var request models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	email := strings.ToLower(request.Email)
+
+	// 复用登录限流，按IP和邮箱限制请求频率
+	ip := c.ClientIP()
+	throttleKey := "reset:" + email
+	if !checkLoginThrottle(c, ip, throttleKey) {
+		return
+	}
+	throttleFail(ip, throttleKey)
+
+	response := gin.H{"message": "如果该邮箱已绑定账号，重置密码邮件已发送，请查收"}
+
+	var user models.User
+	if err := database.DB.Where("LOWER(email) = ?", email).First(&user).Error; err != nil || user.Status == models.StatusBlocked {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	raw, err := database.CreatePasswordReset(user.ID, ip, passwordConfig.ResetTokenTTL.Std())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "发送重置邮件失败"})
+		return
+	}
+	recordAudit(c, models.AuditEvent{Action: models.AuditPasswordForgot, TargetUserID: &user.ID})
+
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: "重置您的 TodoList 密码",
+		Body:    resetPasswordBody(user.Username, resetPasswordLink(raw)),
+	}
+	// 异步发送，避免响应时间暴露邮箱是否存在
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := mailer.Send(ctx, msg); err != nil {
+			log.Printf("发送重置密码邮件失败 (user=%d): %v", user.ID, err)
+		}
+	}()
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ResetPassword 使用邮件中的令牌设置新密码，成功后吊销所有会话并解除登录锁定
+func ResetPassword(c *gin.Context) {
+	var request models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 先找到令牌对应的用户以检查密码策略，使用令牌时会再次校验
+	user, err := database.PasswordResetUser(request.Token)
+	if errors.Is(err, database.ErrPasswordResetInvalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "重置密码失败"})
+		return
+	}
+	if !validateNewPassword(c, user, request.NewPassword) {
+		return
+	}
+
+	hashed := models.User{Password: request.NewPassword}
+	if err := hashed.HashPassword(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "密码加密失败"})
+		return
+	}
+
+	user, err = database.ResetPasswordWithToken(request.Token, hashed.Password, passwordConfig.HistorySize)
+	if errors.Is(err, database.ErrPasswordResetInvalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "重置密码失败"})
+		return
+	}
+
+	if _, err := database.RevokeUserSessions(user.ID, ""); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "吊销会话失败"})
+		return
+	}
+	if err := loginThrottler.Clear(user.Username); err != nil {
+		log.Printf("解除登录锁定失败 (user=%d): %v", user.ID, err)
+	}
+
+	recordAudit(c, models.AuditEvent{
+		ActorID:       &user.ID,
+		ActorUsername: user.Username,
+		TargetUserID:  &user.ID,
+		Action:        models.AuditPasswordReset,
+	})
+	c.JSON(http.StatusOK, gin.H{"message": "密码已重置，请使用新密码登录"})
+}
+
+// UpdateEmail 校验密码后修改当前用户的邮箱，并向新邮箱发送验证邮件
+func UpdateEmail(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	var request models.UpdateEmailRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := user.CheckPassword(request.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "密码错误"})
+		return
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.User{}).
+		Where("LOWER(email) = ? AND id <> ?", strings.ToLower(request.Email), user.ID).
+		Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "修改邮箱失败"})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "邮箱已被使用"})
+		return
+	}
+
+	// 新邮箱需要重新验证
+	before := *user
+	if err := database.DB.Model(user).Updates(map[string]interface{}{
+		"email":          request.Email,
+		"email_verified": false,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "修改邮箱失败"})
+		return
+	}
+	if err := sendEmailVerification(user); err != nil {
+		log.Printf("发送验证邮件失败 (user=%d): %v", user.ID, err)
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:       models.AuditEmailChange,
+		TargetUserID: &user.ID,
+		Changes:      database.AuditDiff(&before, user),
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "邮箱已更新，请查收验证邮件",
+		"email":          user.Email,
+		"email_verified": user.EmailVerified,
+	})
+}
+
+func resetPasswordLink(token string) string {
+	sep := "?"
+	if strings.Contains(passwordConfig.ResetURL, "?") {
+		sep = "&"
+	}
+	return passwordConfig.ResetURL + sep + "token=" + url.QueryEscape(token)
+}
+
+func resetPasswordBody(username, link string) string {
+	return fmt.Sprintf(`%s，您好：
+
+我们收到了重置您 TodoList 账号密码的请求。请在 %d 分钟内打开以下链接设置新密码：
+
+%s
+
+链接只能使用一次。如果这不是您本人的操作，请忽略本邮件，您的密码不会被修改。
+`, username, int(passwordConfig.ResetTokenTTL.Std().Minutes()), link)
+}
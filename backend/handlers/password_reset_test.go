@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"todolist/database"
+	"todolist/middleware"
+	"todolist/models"
+)
+
+func setupPasswordResetTest(t *testing.T) (*gin.Engine, *captureMailer) {
+	t.Helper()
+	setupTest(t, nil)
+	m := useCaptureMailer(t)
+
+	r := gin.New()
+	r.POST("/auth/login", Login)
+	r.POST("/auth/refresh", RefreshToken)
+	r.POST("/auth/password/forgot", ForgotPassword)
+	r.POST("/auth/password/reset", ResetPassword)
+	r.GET("/me", middleware.AuthMiddleware(), GetProfile)
+	return r, m
+}
+
+// login 使用密码登录，返回访问令牌和刷新令牌
+func login(t *testing.T, r *gin.Engine, username, password string) (string, string) {
+	t.Helper()
+	w := doJSON(r, http.MethodPost, "/auth/login", "", gin.H{"username": username, "password": password})
+	if w.Code != http.StatusOK {
+		t.Fatalf("登录返回 %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := decodeBody(w, &body); err != nil {
+		t.Fatal(err)
+	}
+	return body.Token, body.RefreshToken
+}
+
+// requestReset 申请重置密码并返回邮件中的令牌
+func requestReset(t *testing.T, r *gin.Engine, m *captureMailer, email string) string {
+	t.Helper()
+	w := doJSON(r, http.MethodPost, "/auth/password/forgot", "", gin.H{"email": email})
+	if w.Code != http.StatusOK {
+		t.Fatalf("申请重置密码返回 %d: %s", w.Code, w.Body.String())
+	}
+	msg := m.next(t)
+	if !strings.EqualFold(msg.To, email) {
+		t.Fatalf("重置邮件发送到了 %s", msg.To)
+	}
+	return mailToken(t, msg)
+}
+
+func TestPasswordResetFlow(t *testing.T) {
+	r, m := setupPasswordResetTest(t)
+	createTestUser(t, "alice", "alice@example.com", true)
+	token, refreshToken := login(t, r, "alice", "Password123")
+
+	raw := requestReset(t, r, m, "ALICE@example.com")
+	w := doJSON(r, http.MethodPost, "/auth/password/reset", "", gin.H{"token": raw, "new_password": "N3w-password"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("重置密码返回 %d: %s", w.Code, w.Body.String())
+	}
+
+	// 重置前的会话全部失效
+	if w := doJSON(r, http.MethodGet, "/me", token, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("旧访问令牌仍然有效: %d", w.Code)
+	}
+	if w := doJSON(r, http.MethodPost, "/auth/refresh", "", gin.H{"refresh_token": refreshToken}); w.Code == http.StatusOK {
+		t.Error("旧刷新令牌仍然有效")
+	}
+
+	if w := doJSON(r, http.MethodPost, "/auth/login", "", gin.H{"username": "alice", "password": "Password123"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("旧密码仍可登录: %d", w.Code)
+	}
+	token, _ = login(t, r, "alice", "N3w-password")
+	if w := doJSON(r, http.MethodGet, "/me", token, nil); w.Code != http.StatusOK {
+		t.Errorf("新会话无法访问: %d", w.Code)
+	}
+
+	var audits int64
+	database.DB.Model(&models.AuditEvent{}).Where("action = ?", models.AuditPasswordReset).Count(&audits)
+	if audits != 1 {
+		t.Errorf("应当记录 1 条重置密码审计日志，实际 %d", audits)
+	}
+}
+
+func TestPasswordResetTokenReused(t *testing.T) {
+	r, m := setupPasswordResetTest(t)
+	createTestUser(t, "bob", "bob@example.com", true)
+
+	raw := requestReset(t, r, m, "bob@example.com")
+	w := doJSON(r, http.MethodPost, "/auth/password/reset", "", gin.H{"token": raw, "new_password": "N3w-password"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("重置密码返回 %d: %s", w.Code, w.Body.String())
+	}
+	w = doJSON(r, http.MethodPost, "/auth/password/reset", "", gin.H{"token": raw, "new_password": "An0ther-password"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("重复使用令牌应当返回 400，实际 %d", w.Code)
+	}
+	login(t, r, "bob", "N3w-password")
+}
+
+func TestPasswordResetTokenExpired(t *testing.T) {
+	r, m := setupPasswordResetTest(t)
+	user := createTestUser(t, "carol", "carol@example.com", true)
+	token, _ := login(t, r, "carol", "Password123")
+
+	raw := requestReset(t, r, m, "carol@example.com")
+	database.DB.Model(&models.PasswordReset{}).Where("user_id = ?", user.ID).
+		Update("expires_at", time.Now().Add(-time.Minute).UTC())
+
+	w := doJSON(r, http.MethodPost, "/auth/password/reset", "", gin.H{"token": raw, "new_password": "N3w-password"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("过期令牌应当返回 400，实际 %d", w.Code)
+	}
+	// 失败的重置不影响密码和会话
+	login(t, r, "carol", "Password123")
+	if w := doJSON(r, http.MethodGet, "/me", token, nil); w.Code != http.StatusOK {
+		t.Errorf("重置失败后会话被吊销: %d", w.Code)
+	}
+}
+
+func TestForgotPasswordUnknownEmail(t *testing.T) {
+	r, m := setupPasswordResetTest(t)
+
+	w := doJSON(r, http.MethodPost, "/auth/password/forgot", "", gin.H{"email": "nobody@example.com"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("未知邮箱应当返回相同结果，实际 %d", w.Code)
+	}
+	m.expectNone(t)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"todolist/database"
+	"todolist/middleware"
+)
+
+func TestForcedPasswordChange(t *testing.T) {
+	setupTest(t, nil)
+	r := gin.New()
+	r.POST("/auth/login", Login)
+	r.PUT("/auth/password", middleware.AuthMiddleware(), UpdatePassword)
+	r.GET("/me", middleware.AuthMiddleware(), GetProfile)
+	r.PUT("/me", middleware.AuthMiddleware(), UpdateProfile)
+	r.GET("/todos", middleware.AuthMiddleware(), GetTodos)
+
+	user := createTestUser(t, "alice", "alice@example.com", true)
+	if err := database.DB.Model(user).Update("must_change_password", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	token, _ := login(t, r, "alice", "Password123")
+
+	// 修改密码前其他接口都被拒绝
+	for _, req := range []struct{ method, path string }{
+		{http.MethodGet, "/todos"},
+		{http.MethodPut, "/me"},
+	} {
+		w := doJSON(r, req.method, req.path, token, gin.H{"nickname": "A"})
+		var body struct {
+			Code string `json:"code"`
+		}
+		decodeBody(w, &body)
+		if w.Code != http.StatusForbidden || body.Code != "password_change_required" {
+			t.Fatalf("%s %s 应当要求先修改密码，实际 %d: %s", req.method, req.path, w.Code, w.Body.String())
+		}
+	}
+
+	w := doJSON(r, http.MethodGet, "/me", token, nil)
+	var profile struct {
+		MustChangePassword bool `json:"must_change_password"`
+	}
+	decodeBody(w, &profile)
+	if w.Code != http.StatusOK || !profile.MustChangePassword {
+		t.Fatalf("应当能查看个人资料并看到需要修改密码，实际 %d: %s", w.Code, w.Body.String())
+	}
+
+	// 新密码仍需符合策略且不能是当前密码
+	for _, pw := range []string{"Password123", "short", "alice-Password1"} {
+		w = doJSON(r, http.MethodPut, "/auth/password", token, gin.H{"old_password": "Password123", "new_password": pw})
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("新密码 %q 应当被拒绝，实际 %d: %s", pw, w.Code, w.Body.String())
+		}
+	}
+
+	w = doJSON(r, http.MethodPut, "/auth/password", token, gin.H{"old_password": "Password123", "new_password": "Sup3r-secret"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("修改密码返回 %d: %s", w.Code, w.Body.String())
+	}
+	if w = doJSON(r, http.MethodGet, "/todos", token, nil); w.Code != http.StatusOK {
+		t.Fatalf("修改密码后应当能访问其他接口，实际 %d: %s", w.Code, w.Body.String())
+	}
+}
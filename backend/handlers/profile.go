@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"todolist/database"
+	"todolist/models"
+)
+
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// 允许上传的头像格式及对应扩展名
+var avatarTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// GetProfile 获取当前用户的个人资料，模拟登录时附带发起模拟的管理员
+func GetProfile(c *gin.Context) {
+	profile := profileResponse(c.MustGet("user").(*models.User))
+	if v, ok := c.Get("impersonator"); ok {
+		actor := v.(*models.User)
+		profile["impersonated_by"] = gin.H{"id": actor.ID, "username": actor.Username}
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+// UpdateProfile 修改当前用户的显示名称、时区、语言和每周第一天，未提供的字段保持不变
+func UpdateProfile(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	var request models.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if request.DisplayName != nil {
+		updates["display_name"] = strings.TrimSpace(*request.DisplayName)
+	}
+	if request.Timezone != nil {
+		if _, err := time.LoadLocation(*request.Timezone); err != nil || *request.Timezone == "" || *request.Timezone == "Local" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的时区，请使用 IANA 时区名称，例如 Asia/Shanghai"})
+			return
+		}
+		updates["timezone"] = *request.Timezone
+	}
+	if request.Locale != nil {
+		if !localePattern.MatchString(*request.Locale) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的语言，例如 zh-CN、en-US"})
+			return
+		}
+		updates["locale"] = *request.Locale
+	}
+	if request.WeekStart != nil {
+		updates["week_start"] = *request.WeekStart
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusOK, profileResponse(user))
+		return
+	}
+
+	before := *user
+	if err := database.DB.Model(user).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新个人资料失败"})
+		return
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:       models.AuditProfileUpdate,
+		TargetUserID: &user.ID,
+		Changes:      database.AuditDiff(&before, user),
+	})
+	c.JSON(http.StatusOK, profileResponse(user))
+}
+
+// UploadAvatar 上传头像（multipart 表单字段 avatar），支持 PNG、JPEG、GIF、WebP
+func UploadAvatar(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, storageConfig.AvatarMaxBytes+1<<20)
+	fileHeader, err := c.FormFile("avatar")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请选择头像文件"})
+		return
+	}
+	if fileHeader.Size > storageConfig.AvatarMaxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("头像不能超过 %d KB", storageConfig.AvatarMaxBytes>>10)})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取头像失败"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取头像失败"})
+		return
+	}
+
+	// 按文件内容判断类型，不信任客户端提供的 Content-Type
+	ext, ok := avatarTypes[http.DetectContentType(data)]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "头像仅支持 PNG、JPEG、GIF、WebP 格式"})
+		return
+	}
+
+	suffix, err := database.RandomToken(8)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存头像失败"})
+		return
+	}
+	name := fmt.Sprintf("%d-%s%s", user.ID, suffix, ext)
+	dir := avatarDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存头像失败"})
+		return
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存头像失败"})
+		return
+	}
+
+	old := user.Avatar
+	if err := database.DB.Model(user).Update("avatar", "/avatars/"+name).Error; err != nil {
+		os.Remove(filepath.Join(dir, name))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存头像失败"})
+		return
+	}
+	removeAvatarFile(old)
+
+	c.JSON(http.StatusOK, profileResponse(user))
+}
+
+// DeleteAvatar 删除当前用户的头像
+func DeleteAvatar(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	old := user.Avatar
+	if err := database.DB.Model(user).Update("avatar", "").Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除头像失败"})
+		return
+	}
+	removeAvatarFile(old)
+
+	c.JSON(http.StatusOK, profileResponse(user))
+}
+
+// GetAvatar 返回头像图片
+func GetAvatar(c *gin.Context) {
+	name := path.Base(c.Param("file"))
+	if _, ok := avatarExt(name); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "头像不存在"})
+		return
+	}
+	file := filepath.Join(avatarDir(), name)
+	if _, err := os.Stat(file); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "头像不存在"})
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.File(file)
+}
+
+func avatarDir() string {
+	return filepath.Join(storageConfig.UploadDir, "avatars")
+}
+
+func avatarExt(name string) (string, bool) {
+	ext := filepath.Ext(name)
+	for _, e := range avatarTypes {
+		if e == ext {
+			return ext, true
+		}
+	}
+	return "", false
+}
+
+// removeAvatarFile 删除旧头像文件，失败只记录日志
+func removeAvatarFile(avatar string) {
+	if !strings.HasPrefix(avatar, "/avatars/") {
+		return
+	}
+	file := filepath.Join(avatarDir(), path.Base(avatar))
+	if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+		log.Printf("删除旧头像失败: %v", err)
+	}
+}
+
+func profileResponse(user *models.User) gin.H {
+	return gin.H{
+		"id":                   user.ID,
+		"username":             user.Username,
+		"email":                user.Email,
+		"email_verified":       user.EmailVerified,
+		"display_name":         user.DisplayName,
+		"avatar":               user.Avatar,
+		"timezone":             user.Timezone,
+		"locale":               user.Locale,
+		"week_start":           user.WeekStart,
+		"role":                 user.Role,
+		"status":               user.Status,
+		"totp_enabled":         user.TOTPEnabled,
+		"delete_after":         user.DeleteAfter,
+		"must_change_password": user.MustChangePassword,
+		"created_at":           user.CreatedAt,
+	}
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"todolist/database"
+	"todolist/models"
+)
+
+// maxProjectsPerUser 每个用户最多的项目数量
+const maxProjectsPerUser = 200
+
+// hexColorPattern 项目颜色，#RGB 或 #RRGGBB
+var hexColorPattern = regexp.MustCompile(`^#([0-9A-Fa-f]{3}|[0-9A-Fa-f]{6})$`)
+
+// GetProjects 获取当前用户所在的项目列表（包括共享给用户的），按 sort_order 排序；archived=true|false 按是否归档筛选
+func GetProjects(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	var members []models.ProjectMember
+	if err := database.DB.Where("user_id = ?", user.ID).Find(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目失败"})
+		return
+	}
+	roles := make(map[uint]string, len(members))
+	ids := make([]uint, len(members))
+	for i, m := range members {
+		roles[m.ProjectID] = m.Role
+		ids[i] = m.ProjectID
+	}
+
+	projects := []models.Project{}
+	query := database.DB.Where("id IN ?", ids)
+	if archived := c.Query("archived"); archived != "" {
+		query = query.Where("archived = ?", archived == "true")
+	}
+	if len(ids) > 0 {
+		if err := query.Order("sort_order, id").Find(&projects).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目失败"})
+			return
+		}
+	}
+	for i := range projects {
+		projects[i].Role = roles[projects[i].ID]
+	}
+	if err := database.CountOpenTodos(projects); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目失败"})
+		return
+	}
+	c.JSON(http.StatusOK, projects)
+}
+
+// GetProject 获取单个项目
+func GetProject(c *gin.Context) {
+	project, ok := loadProject(c, models.ProjectViewer)
+	if !ok {
+		return
+	}
+	projects := []models.Project{*project}
+	if err := database.CountOpenTodos(projects); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目失败"})
+		return
+	}
+	c.JSON(http.StatusOK, projects[0])
+}
+
+// CreateProject 创建项目，创建者成为所有者，未指定 sort_order 时排在最后
+func CreateProject(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	var request models.CreateProjectRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.Project{}).Where("user_id = ?", user.ID).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建项目失败"})
+		return
+	}
+	if count >= maxProjectsPerUser {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "项目数量已达上限"})
+		return
+	}
+	if !projectNameAvailable(c, user.ID, request.Name, 0) {
+		return
+	}
+
+	project := models.Project{
+		UserID: user.ID,
+		Name:   request.Name,
+		Color:  request.Color,
+		Icon:   request.Icon,
+	}
+	if request.SortOrder != nil {
+		project.SortOrder = *request.SortOrder
+	} else {
+		var last models.Project
+		if err := database.DB.Where("user_id = ?", user.ID).Order("sort_order DESC").Limit(1).Find(&last).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "创建项目失败"})
+			return
+		}
+		if last.ID != 0 {
+			project.SortOrder = last.SortOrder + 1
+		}
+	}
+	if err := database.CreateProject(&project); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建项目失败"})
+		return
+	}
+	c.JSON(http.StatusCreated, project)
+}
+
+// UpdateProject 修改项目的名称、颜色、图标、归档状态或排序，需要所有者权限
+func UpdateProject(c *gin.Context) {
+	project, ok := loadProject(c, models.ProjectOwner)
+	if !ok {
+		return
+	}
+	var request models.UpdateProjectRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if request.Name != nil && *request.Name != project.Name {
+		if !projectNameAvailable(c, project.UserID, *request.Name, project.ID) {
+			return
+		}
+		project.Name = *request.Name
+	}
+	if request.Color != nil {
+		if *request.Color != "" && !hexColorPattern.MatchString(*request.Color) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "颜色必须是十六进制格式，如 #3b82f6"})
+			return
+		}
+		project.Color = *request.Color
+	}
+	if request.Icon != nil {
+		project.Icon = *request.Icon
+	}
+	if request.Archived != nil {
+		project.Archived = *request.Archived
+	}
+	if request.SortOrder != nil {
+		project.SortOrder = *request.SortOrder
+	}
+
+	if err := database.DB.Save(project).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新项目失败"})
+		return
+	}
+	projects := []models.Project{*project}
+	if err := database.CountOpenTodos(projects); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目失败"})
+		return
+	}
+	c.JSON(http.StatusOK, projects[0])
+}
+
+// DeleteProject 删除项目，查询参数 todos 指定其中待办事项的处理方式：
+// keep（默认）移出项目，move 移动到 move_to 指定的项目（需要其编辑者权限），delete 一并删除。需要所有者权限
+func DeleteProject(c *gin.Context) {
+	project, ok := loadProject(c, models.ProjectOwner)
+	if !ok {
+		return
+	}
+
+	mode := c.DefaultQuery("todos", models.ProjectTodosKeep)
+	var target *uint
+	switch mode {
+	case models.ProjectTodosKeep, models.ProjectTodosDelete:
+	case models.ProjectTodosMove:
+		id, err := strconv.ParseUint(c.Query("move_to"), 10, 64)
+		if err != nil || uint(id) == project.ID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "move_to 必须是另一个项目的ID"})
+			return
+		}
+		to, ok := findProject(c, c.MustGet("user").(*models.User), uint(id), models.ProjectEditor)
+		if !ok {
+			return
+		}
+		target = &to.ID
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "todos 只能是 keep、move 或 delete"})
+		return
+	}
+
+	if err := database.DeleteProject(project, mode, target); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除项目失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// loadProject 加载路径参数 :id 对应的项目并检查当前用户的角色是否满足 need，
+// 不是成员时写入 404 响应，权限不足时写入 403 响应
+func loadProject(c *gin.Context, need string) (*models.Project, bool) {
+	user := c.MustGet("user").(*models.User)
+	var project models.Project
+	if err := database.DB.Where("id = ?", c.Param("id")).First(&project).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "项目不存在"})
+		return nil, false
+	}
+	role, err := database.ProjectRole(project.ID, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目失败"})
+		return nil, false
+	}
+	if role == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "项目不存在"})
+		return nil, false
+	}
+	if !models.ProjectRoleAllows(role, need) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "没有权限执行该操作"})
+		return nil, false
+	}
+	project.Role = role
+	return &project, true
+}
+
+// findProject 查找 user 所在的项目 id 并检查角色是否满足 need，不是成员时写入 400 响应，权限不足时写入 403 响应
+func findProject(c *gin.Context, user *models.User, id uint, need string) (*models.Project, bool) {
+	var project models.Project
+	err := database.DB.Where("id = ?", id).First(&project).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目失败"})
+		return nil, false
+	}
+	role := ""
+	if err == nil {
+		if role, err = database.ProjectRole(project.ID, user.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目失败"})
+			return nil, false
+		}
+	}
+	if role == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "项目不存在"})
+		return nil, false
+	}
+	if !models.ProjectRoleAllows(role, need) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "没有权限在该项目中添加待办事项"})
+		return nil, false
+	}
+	project.Role = role
+	return &project, true
+}
+
+// checkTodoProject 校验 user 能否把待办事项放入项目：需要编辑者权限且项目未归档，projectID 为空时不校验；失败时写入错误响应
+func checkTodoProject(c *gin.Context, projectID *uint, user *models.User) bool {
+	if projectID == nil || *projectID == 0 {
+		return true
+	}
+	project, ok := findProject(c, user, *projectID, models.ProjectEditor)
+	if !ok {
+		return false
+	}
+	if project.Archived {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "项目已归档"})
+		return false
+	}
+	return true
+}
+
+// projectNameAvailable 检查项目名称是否与用户的其他项目重复，重复时写入 409 响应
+func projectNameAvailable(c *gin.Context, userID uint, name string, exceptID uint) bool {
+	var count int64
+	if err := database.DB.Model(&models.Project{}).Where("user_id = ? AND name = ? AND id <> ?", userID, name, exceptID).
+		Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目失败"})
+		return false
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "项目名称已存在"})
+		return false
+	}
+	return true
+}
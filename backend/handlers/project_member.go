@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"todolist/database"
+	"todolist/models"
+)
+
+// maxProjectMembers 每个项目最多的成员数量（包括待接受的邀请）
+const maxProjectMembers = 50
+
+// projectRoleNames 角色的中文名称，用于邀请通知
+var projectRoleNames = map[string]string{
+	models.ProjectViewer: "查看者",
+	models.ProjectEditor: "编辑者",
+	models.ProjectOwner:  "所有者",
+}
+
+// GetProjectMembers 获取项目成员列表，成员均可查看
+func GetProjectMembers(c *gin.Context) {
+	project, ok := loadProject(c, models.ProjectViewer)
+	if !ok {
+		return
+	}
+	members := []models.ProjectMember{}
+	if err := database.DB.Where("project_id = ?", project.ID).Order("id").Find(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目成员失败"})
+		return
+	}
+	ids := make([]uint, len(members))
+	for i, m := range members {
+		ids[i] = m.UserID
+	}
+	names, err := usernames(ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目成员失败"})
+		return
+	}
+	for i := range members {
+		members[i].Username = names[members[i].UserID]
+	}
+	c.JSON(http.StatusOK, members)
+}
+
+// UpdateProjectMember 修改成员角色，需要所有者权限，不能把最后一个所有者降级
+func UpdateProjectMember(c *gin.Context) {
+	project, ok := loadProject(c, models.ProjectOwner)
+	if !ok {
+		return
+	}
+	member, ok := findProjectMember(c, project)
+	if !ok {
+		return
+	}
+	var request models.UpdateProjectMemberRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.UpdateProjectMember(member, request.Role); err != nil {
+		if errors.Is(err, database.ErrLastProjectOwner) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新项目成员失败"})
+		return
+	}
+	names, err := usernames([]uint{member.UserID})
+	if err == nil {
+		member.Username = names[member.UserID]
+	}
+	c.JSON(http.StatusOK, member)
+}
+
+// DeleteProjectMember 移除成员，需要所有者权限；成员也可以移除自己（退出项目）。不能移除最后一个所有者
+func DeleteProjectMember(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	need := models.ProjectOwner
+	if c.Param("userId") == fmt.Sprint(user.ID) {
+		need = models.ProjectViewer
+	}
+	project, ok := loadProject(c, need)
+	if !ok {
+		return
+	}
+	member, ok := findProjectMember(c, project)
+	if !ok {
+		return
+	}
+
+	if err := database.RemoveProjectMember(member); err != nil {
+		if errors.Is(err, database.ErrLastProjectOwner) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "移除项目成员失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "移除成功"})
+}
+
+// GetProjectInvitations 获取项目待接受的邀请，需要所有者权限
+func GetProjectInvitations(c *gin.Context) {
+	project, ok := loadProject(c, models.ProjectOwner)
+	if !ok {
+		return
+	}
+	invitations := []models.ProjectInvitation{}
+	if err := database.DB.Where("project_id = ?", project.ID).Order("id").Find(&invitations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取邀请失败"})
+		return
+	}
+	if err := fillInvitations(invitations); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取邀请失败"})
+		return
+	}
+	c.JSON(http.StatusOK, invitations)
+}
+
+// CreateProjectInvitation 邀请用户加入项目，需要所有者权限；被邀请的用户会收到站内通知
+func CreateProjectInvitation(c *gin.Context) {
+	project, ok := loadProject(c, models.ProjectOwner)
+	if !ok {
+		return
+	}
+	var request models.CreateProjectInvitationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var invitee models.User
+	if err := database.DB.Where("username = ? AND status = ?", request.Username, models.StatusActive).First(&invitee).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "用户不存在"})
+		return
+	}
+	if role, err := database.ProjectRole(project.ID, invitee.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "邀请失败"})
+		return
+	} else if role != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "该用户已是项目成员"})
+		return
+	}
+
+	var members, pending int64
+	if err := database.DB.Model(&models.ProjectMember{}).Where("project_id = ?", project.ID).Count(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "邀请失败"})
+		return
+	}
+	if err := database.DB.Model(&models.ProjectInvitation{}).Where("project_id = ?", project.ID).Count(&pending).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "邀请失败"})
+		return
+	}
+	if members+pending >= maxProjectMembers {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "项目成员数量已达上限"})
+		return
+	}
+
+	// 重复邀请时更新角色
+	user := c.MustGet("user").(*models.User)
+	var invitation models.ProjectInvitation
+	if err := database.DB.Where("project_id = ? AND invitee_id = ?", project.ID, invitee.ID).Limit(1).Find(&invitation).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "邀请失败"})
+		return
+	}
+	invitation.ProjectID = project.ID
+	invitation.InviteeID = invitee.ID
+	invitation.InviterID = user.ID
+	invitation.Role = request.Role
+	if err := database.DB.Save(&invitation).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "邀请失败"})
+		return
+	}
+
+	notification := models.Notification{
+		UserID: invitee.ID,
+		Title:  "项目邀请：" + project.Name,
+		Body:   fmt.Sprintf("%s 邀请你以%s身份加入项目「%s」", user.Username, projectRoleNames[request.Role], project.Name),
+	}
+	if err := database.DB.Create(&notification).Error; err != nil {
+		log.Printf("发送项目邀请通知失败 (invitation=%d): %v", invitation.ID, err)
+	}
+
+	invitation.ProjectName = project.Name
+	invitation.InviteeName = invitee.Username
+	invitation.InviterName = user.Username
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// DeleteProjectInvitation 撤销邀请，需要所有者权限
+func DeleteProjectInvitation(c *gin.Context) {
+	project, ok := loadProject(c, models.ProjectOwner)
+	if !ok {
+		return
+	}
+	result := database.DB.Where("id = ? AND project_id = ?", c.Param("invitationId"), project.ID).Delete(&models.ProjectInvitation{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "撤销邀请失败"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "邀请不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "撤销成功"})
+}
+
+// GetMyProjectInvitations 获取当前用户收到的项目邀请
+func GetMyProjectInvitations(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	invitations := []models.ProjectInvitation{}
+	if err := database.DB.Where("invitee_id = ?", user.ID).Order("id DESC").Find(&invitations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取邀请失败"})
+		return
+	}
+	if err := fillInvitations(invitations); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取邀请失败"})
+		return
+	}
+	c.JSON(http.StatusOK, invitations)
+}
+
+// AcceptProjectInvitation 接受项目邀请，成为项目成员
+func AcceptProjectInvitation(c *gin.Context) {
+	invitation, ok := findMyInvitation(c)
+	if !ok {
+		return
+	}
+	member, err := database.AcceptProjectInvitation(invitation)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "接受邀请失败"})
+		return
+	}
+	member.Username = c.MustGet("user").(*models.User).Username
+	c.JSON(http.StatusOK, member)
+}
+
+// DeclineProjectInvitation 拒绝项目邀请
+func DeclineProjectInvitation(c *gin.Context) {
+	invitation, ok := findMyInvitation(c)
+	if !ok {
+		return
+	}
+	if err := database.DB.Delete(invitation).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "拒绝邀请失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "已拒绝"})
+}
+
+// findProjectMember 查找项目中路径参数 :userId 对应的成员，不存在时写入 404 响应
+func findProjectMember(c *gin.Context, project *models.Project) (*models.ProjectMember, bool) {
+	var member models.ProjectMember
+	if err := database.DB.Where("project_id = ? AND user_id = ?", project.ID, c.Param("userId")).First(&member).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "项目成员不存在"})
+		return nil, false
+	}
+	return &member, true
+}
+
+// findMyInvitation 查找当前用户收到的路径参数 :id 对应的邀请，不存在时写入 404 响应
+func findMyInvitation(c *gin.Context) (*models.ProjectInvitation, bool) {
+	userID, _ := c.Get("userID")
+	var invitation models.ProjectInvitation
+	if err := database.DB.Where("id = ? AND invitee_id = ?", c.Param("id"), userID).First(&invitation).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "邀请不存在"})
+		return nil, false
+	}
+	return &invitation, true
+}
+
+// fillInvitations 填充邀请的项目名称和用户名
+func fillInvitations(invitations []models.ProjectInvitation) error {
+	if len(invitations) == 0 {
+		return nil
+	}
+	var userIDs, projectIDs []uint
+	for _, inv := range invitations {
+		userIDs = append(userIDs, inv.InviteeID, inv.InviterID)
+		projectIDs = append(projectIDs, inv.ProjectID)
+	}
+	names, err := usernames(userIDs)
+	if err != nil {
+		return err
+	}
+	var projects []models.Project
+	if err := database.DB.Select("id", "name").Where("id IN ?", projectIDs).Find(&projects).Error; err != nil {
+		return err
+	}
+	projectNames := make(map[uint]string, len(projects))
+	for _, p := range projects {
+		projectNames[p.ID] = p.Name
+	}
+	for i := range invitations {
+		invitations[i].ProjectName = projectNames[invitations[i].ProjectID]
+		invitations[i].InviteeName = names[invitations[i].InviteeID]
+		invitations[i].InviterName = names[invitations[i].InviterID]
+	}
+	return nil
+}
+
+// usernames 查询用户ID对应的用户名
+func usernames(ids []uint) (map[uint]string, error) {
+	names := make(map[uint]string, len(ids))
+	if len(ids) == 0 {
+		return names, nil
+	}
+	var users []models.User
+	if err := database.DB.Select("id", "username").Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		names[u.ID] = u.Username
+	}
+	return names, nil
+}
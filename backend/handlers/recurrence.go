@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"todolist/database"
+	"todolist/models"
+	"todolist/rrule"
+)
+
+const (
+	scopeThis      = "this"      // 只修改或删除这一次
+	scopeFollowing = "following" // 修改或删除这一次及之后
+)
+
+// recurrenceScope 读取查询参数 scope，默认为 this，无效时写入 400 响应
+func recurrenceScope(c *gin.Context) (string, bool) {
+	scope := c.DefaultQuery("scope", scopeThis)
+	if scope != scopeThis && scope != scopeFollowing {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "scope 只能是 this 或 following"})
+		return "", false
+	}
+	return scope, true
+}
+
+// normalizeRRule 校验重复规则并返回规范化的字符串，空字符串原样返回，无效时写入 400 响应
+func normalizeRRule(c *gin.Context, s string) (string, bool) {
+	if s == "" {
+		return "", true
+	}
+	rule, err := rrule.Parse(s)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", false
+	}
+	return rule.String(), true
+}
+
+// recurrenceHorizon 提前生成重复的截止时间，未开启提前生成时为零值
+func recurrenceHorizon() time.Time {
+	if recurrenceConfig.Lookahead <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(recurrenceConfig.Lookahead.Std())
+}
+
+// advanceRecurrence 重复任务完成或删除后按需生成下一次，失败只记录日志，不影响本次请求
+func advanceRecurrence(seriesID *uint, owner *models.User) {
+	if seriesID == nil {
+		return
+	}
+	if _, err := database.AdvanceSeries(*seriesID, owner.Location(), recurrenceHorizon()); err != nil {
+		log.Printf("生成重复任务失败 (recurrence=%d): %v", *seriesID, err)
+	}
+}
+
+// AdvanceRecurrences 为全部重复任务提前生成 lookahead 之内的重复，返回生成的数量，供后台任务定期调用
+func AdvanceRecurrences() (int, error) {
+	return database.AdvanceAllSeries(recurrenceConfig.Lookahead.Std())
+}
+
+// applyTodoUpdate 应用更新请求并保存 owner 的待办事项，scope=following 时修改重复任务的这一次及之后。
+// 失败时写入错误响应并返回 false
+func applyTodoUpdate(c *gin.Context, todo *models.Todo, request *models.UpdateTodoRequest, owner *models.User) bool {
+	scope, ok := recurrenceScope(c)
+	if !ok {
+		return false
+	}
+	var rule *string
+	if request.RRule != nil {
+		normalized, ok := normalizeRRule(c, *request.RRule)
+		if !ok {
+			return false
+		}
+		rule = &normalized
+	}
+	if todo.RecurrenceID != nil && scope == scopeThis && (rule != nil || request.ExDates != nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "修改重复规则或跳过的日期需要使用 scope=following"})
+		return false
+	}
+
+	request.UpdateTodo(todo)
+
+	var err error
+	switch {
+	case todo.RecurrenceID == nil && rule != nil && *rule != "":
+		err = database.MakeRecurring(todo, *rule, request.ExDates, owner.Location())
+	case todo.RecurrenceID != nil && scope == scopeFollowing:
+		err = database.UpdateFollowing(todo, rule, request.ExDates, owner.Location())
+	default:
+		err = saveTodo(todo, owner)
+	}
+	if errors.Is(err, database.ErrRecurrenceEmpty) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新待办事项失败"})
+		return false
+	}
+
+	rescheduleReminders(todo)
+	if todo.Completed {
+		advanceRecurrence(todo.RecurrenceID, owner)
+	}
+	return true
+}
+
+// deleteTodoInScope 删除 owner 的待办事项，重复任务按 scope 删除这一次或这一次及之后。
+// 失败时写入错误响应并返回 false
+func deleteTodoInScope(c *gin.Context, todo *models.Todo, owner *models.User) bool {
+	scope, ok := recurrenceScope(c)
+	if !ok {
+		return false
+	}
+
+	var err error
+	switch {
+	case todo.RecurrenceID == nil:
+		err = database.DeleteTodo(todo)
+	case scope == scopeFollowing:
+		err = database.DeleteFollowing(todo)
+	default:
+		if err = database.DeleteOccurrence(todo); err == nil {
+			advanceRecurrence(todo.RecurrenceID, owner)
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除待办事项失败"})
+		return false
+	}
+	return true
+}
+
+// GetTodoOccurrences 预览重复任务这一次之后的日期（包括尚未生成的），limit 默认 10，最多 100
+func GetTodoOccurrences(c *gin.Context) {
+	todo, ok := loadTodo(c, models.ProjectViewer)
+	if !ok {
+		return
+	}
+	if todo.Recurrence == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "该待办事项不是重复任务"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit 必须在 1 到 100 之间"})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	times, err := database.UpcomingOccurrences(todo.Recurrence, user.Location(), todo.OccurrenceAt.Time, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "计算重复日期失败"})
+		return
+	}
+	occurrences := make([]models.CustomTime, len(times))
+	for i, t := range times {
+		occurrences[i] = models.CustomTime{Time: t}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"rrule":       todo.Recurrence.RRule,
+		"occurrences": occurrences,
+	})
+}
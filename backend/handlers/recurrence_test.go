@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"todolist/database"
+	"todolist/middleware"
+	"todolist/models"
+)
+
+func setupRecurrenceTest(t *testing.T) (*gin.Engine, string) {
+	t.Helper()
+	setupTest(t, nil)
+	r := gin.New()
+	auth := middleware.AuthMiddleware()
+	r.POST("/todos", auth, CreateTodo)
+	r.PUT("/todos/:id", auth, UpdateTodo)
+	r.DELETE("/todos/:id", auth, DeleteTodo)
+	user := createTestUser(t, "alice", "alice@example.com", true)
+	return r, sessionToken(t, user)
+}
+
+// createRecurring 创建每天一次的重复任务并返回它的第一次
+func createRecurring(t *testing.T, r *gin.Engine, token string) *models.Todo {
+	t.Helper()
+	w := doJSON(r, http.MethodPost, "/todos", token, gin.H{
+		"title":      "晨跑",
+		"start_time": "2030-01-07 09:00:00",
+		"rrule":      "FREQ=DAILY;COUNT=5",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("创建重复任务返回 %d: %s", w.Code, w.Body.String())
+	}
+	var todo models.Todo
+	if err := decodeBody(w, &todo); err != nil {
+		t.Fatal(err)
+	}
+	if todo.RecurrenceID == nil {
+		t.Fatalf("应当创建重复系列: %s", w.Body.String())
+	}
+	return &todo
+}
+
+func todoPath(todo *models.Todo) string {
+	return "/todos/" + strconv.Itoa(int(todo.ID))
+}
+
+func TestUpdateRecurringScopeThis(t *testing.T) {
+	r, token := setupRecurrenceTest(t)
+	todo := createRecurring(t, r, token)
+
+	for _, body := range []gin.H{
+		{"rrule": "FREQ=WEEKLY"},
+		{"rrule": ""},
+		{"exdates": []string{"2030-01-09 09:00:00"}},
+	} {
+		for _, path := range []string{todoPath(todo), todoPath(todo) + "?scope=this"} {
+			if w := doJSON(r, http.MethodPut, path, token, body); w.Code != http.StatusBadRequest {
+				t.Errorf("%s %v 应当返回 400，实际 %d", path, body, w.Code)
+			}
+		}
+	}
+	if w := doJSON(r, http.MethodPut, todoPath(todo)+"?scope=all", token, gin.H{"title": "x"}); w.Code != http.StatusBadRequest {
+		t.Errorf("无效的 scope 应当返回 400，实际 %d", w.Code)
+	}
+
+	// 只修改这一次时系列不变
+	w := doJSON(r, http.MethodPut, todoPath(todo), token, gin.H{"title": "慢跑"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("修改这一次返回 %d: %s", w.Code, w.Body.String())
+	}
+	var rec models.Recurrence
+	if err := database.DB.First(&rec, *todo.RecurrenceID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if rec.RRule != "FREQ=DAILY;COUNT=5" || rec.Title != "晨跑" {
+		t.Errorf("只修改这一次不应改变系列: %s %s", rec.RRule, rec.Title)
+	}
+}
+
+func TestUpdateRecurringScopeFollowing(t *testing.T) {
+	r, token := setupRecurrenceTest(t)
+	first := createRecurring(t, r, token)
+	oldID := *first.RecurrenceID
+
+	// 完成第一次后生成第二次
+	if w := doJSON(r, http.MethodPut, todoPath(first), token, gin.H{"completed": true}); w.Code != http.StatusOK {
+		t.Fatalf("完成第一次返回 %d: %s", w.Code, w.Body.String())
+	}
+	var second models.Todo
+	if err := database.DB.Where("recurrence_id = ? AND id <> ?", oldID, first.ID).First(&second).Error; err != nil {
+		t.Fatalf("完成后应当生成下一次: %v", err)
+	}
+
+	w := doJSON(r, http.MethodPut, todoPath(&second)+"?scope=following", token, gin.H{
+		"title": "夜跑",
+		"rrule": "FREQ=WEEKLY;BYDAY=MO,WE",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("修改这一次及之后返回 %d: %s", w.Code, w.Body.String())
+	}
+	var updated models.Todo
+	if err := decodeBody(w, &updated); err != nil {
+		t.Fatal(err)
+	}
+	if updated.RecurrenceID == nil || *updated.RecurrenceID == oldID ||
+		updated.Recurrence == nil || updated.Recurrence.RRule != "FREQ=WEEKLY;BYDAY=MO,WE" {
+		t.Fatalf("应当从这一次开始新的系列: %s", w.Body.String())
+	}
+
+	var old models.Recurrence
+	if err := database.DB.First(&old, oldID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if old.RRule != "FREQ=DAILY;UNTIL=20300108T085959Z" {
+		t.Errorf("原系列应当截止到这一次之前: %s", old.RRule)
+	}
+	var count int64
+	database.DB.Model(&models.Todo{}).Where("recurrence_id = ?", oldID).Count(&count)
+	if count != 1 {
+		t.Errorf("原系列应当只剩第一次，实际 %d 个", count)
+	}
+
+	// 删除这一次及之后，新系列随之删除
+	if w := doJSON(r, http.MethodDelete, todoPath(&second)+"?scope=following", token, nil); w.Code != http.StatusOK {
+		t.Fatalf("删除这一次及之后返回 %d: %s", w.Code, w.Body.String())
+	}
+	if err := database.DB.First(&models.Recurrence{}, *updated.RecurrenceID).Error; err == nil {
+		t.Error("没有待办事项的系列应当删除")
+	}
+}
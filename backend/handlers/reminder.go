@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"todolist/database"
+	"todolist/models"
+	"todolist/notify"
+	"todolist/util"
+)
+
+const (
+	// maxRemindersPerTodo 每个用户在每个待办事项上最多的提醒数量
+	maxRemindersPerTodo = 10
+	// maxReminderAttempts 发送失败后最多尝试的次数，每次重试间隔递增1分钟
+	maxReminderAttempts = 3
+)
+
+// GetReminders 获取当前用户在待办事项上设置的提醒。提醒属于设置它的用户，共享项目中的成员只能看到和修改自己的提醒
+func GetReminders(c *gin.Context) {
+	todo, ok := loadTodo(c, models.ProjectViewer)
+	if !ok {
+		return
+	}
+	user := c.MustGet("user").(*models.User)
+	var reminders []models.Reminder
+	if err := database.DB.Where("todo_id = ? AND user_id = ?", todo.ID, user.ID).Order("fire_at, id").Find(&reminders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取提醒失败"})
+		return
+	}
+	c.JSON(http.StatusOK, reminders)
+}
+
+// CreateReminder 为待办事项添加当前用户的提醒，remind_at 为绝对时间，anchor + offset_minutes 为相对开始或结束时间
+func CreateReminder(c *gin.Context) {
+	todo, ok := loadTodo(c, models.ProjectViewer)
+	if !ok {
+		return
+	}
+	var request models.CreateReminderRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if (request.RemindAt == nil) == (request.Anchor == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "remind_at 和 anchor 必须且只能设置一个"})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	var count int64
+	if err := database.DB.Model(&models.Reminder{}).Where("todo_id = ? AND user_id = ?", todo.ID, user.ID).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "添加提醒失败"})
+		return
+	}
+	if count >= maxRemindersPerTodo {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "提醒数量已达上限"})
+		return
+	}
+
+	reminder := models.Reminder{
+		TodoID:        todo.ID,
+		UserID:        user.ID,
+		RemindAt:      request.RemindAt,
+		Anchor:        request.Anchor,
+		OffsetMinutes: request.OffsetMinutes,
+		Status:        models.ReminderPending,
+		Delivered:     models.StringSlice{},
+	}
+	if !setReminderChannels(c, &reminder, request.Channels, user) || !scheduleReminder(c, &reminder, todo) {
+		return
+	}
+	if err := database.DB.Create(&reminder).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "添加提醒失败"})
+		return
+	}
+	c.JSON(http.StatusCreated, reminder)
+}
+
+// UpdateReminder 修改提醒，修改后重新等待发送（已发送或失败的提醒也会重新发送）
+func UpdateReminder(c *gin.Context) {
+	todo, ok := loadTodo(c, models.ProjectViewer)
+	if !ok {
+		return
+	}
+	reminder, ok := findReminder(c, todo)
+	if !ok {
+		return
+	}
+	var request models.UpdateReminderRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if request.RemindAt != nil && request.Anchor != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "remind_at 和 anchor 只能设置一个"})
+		return
+	}
+
+	// 绝对时间和相对时间互相切换
+	if request.RemindAt != nil {
+		reminder.RemindAt = request.RemindAt
+		reminder.Anchor = ""
+		reminder.OffsetMinutes = 0
+	}
+	if request.Anchor != nil {
+		reminder.Anchor = *request.Anchor
+		reminder.RemindAt = nil
+	}
+	if request.OffsetMinutes != nil {
+		if reminder.Anchor == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset_minutes 只能用于相对提醒"})
+			return
+		}
+		reminder.OffsetMinutes = *request.OffsetMinutes
+	}
+	user := c.MustGet("user").(*models.User)
+	if request.Channels != nil && !setReminderChannels(c, reminder, request.Channels, user) {
+		return
+	}
+	if !scheduleReminder(c, reminder, todo) {
+		return
+	}
+
+	reminder.Status = models.ReminderPending
+	reminder.Delivered = models.StringSlice{}
+	reminder.Attempts = 0
+	reminder.LastError = ""
+	reminder.SentAt = nil
+	if err := database.DB.Save(reminder).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新提醒失败"})
+		return
+	}
+	c.JSON(http.StatusOK, reminder)
+}
+
+// DeleteReminder 删除提醒
+func DeleteReminder(c *gin.Context) {
+	todo, ok := loadTodo(c, models.ProjectViewer)
+	if !ok {
+		return
+	}
+	reminder, ok := findReminder(c, todo)
+	if !ok {
+		return
+	}
+	if err := database.DB.Delete(reminder).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除提醒失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// findReminder 查找当前用户在待办事项中路径参数 :reminderId 对应的提醒
+func findReminder(c *gin.Context, todo *models.Todo) (*models.Reminder, bool) {
+	userID, _ := c.Get("userID")
+	var reminder models.Reminder
+	if err := database.DB.Where("id = ? AND todo_id = ? AND user_id = ?", c.Param("reminderId"), todo.ID, userID).First(&reminder).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "提醒不存在"})
+		return nil, false
+	}
+	return &reminder, true
+}
+
+// setReminderChannels 设置提醒的发送渠道，为空时使用默认渠道；渠道需要的地址未设置时写入 400 响应
+func setReminderChannels(c *gin.Context, reminder *models.Reminder, channels []string, user *models.User) bool {
+	settings, err := database.GetNotificationSettings(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取通知设置失败"})
+		return false
+	}
+	if len(channels) == 0 {
+		channels = settings.DefaultChannels
+	}
+	reminder.Channels = models.StringSlice{}
+	for _, ch := range channels {
+		if containsString(reminder.Channels, ch) {
+			continue
+		}
+		if msg := channelUnavailable(ch, user, settings); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return false
+		}
+		reminder.Channels = append(reminder.Channels, ch)
+	}
+	return true
+}
+
+// channelUnavailable 返回渠道不可用的原因，可用时返回空字符串
+func channelUnavailable(channel string, user *models.User, settings *models.NotificationSettings) string {
+	switch channel {
+	case notify.ChannelEmail:
+		if user.Email == "" {
+			return "请先设置邮箱"
+		}
+	case notify.ChannelWebhook:
+		if settings.WebhookURL == "" {
+			return "请先在通知设置中设置 Webhook 地址"
+		}
+	case notify.ChannelNtfy:
+		if settings.NtfyTopic == "" {
+			return "请先在通知设置中设置 ntfy 主题"
+		}
+	}
+	return ""
+}
+
+// scheduleReminder 计算提醒的发送时间，无法计算或已过时写入 400 响应
+func scheduleReminder(c *gin.Context, reminder *models.Reminder, todo *models.Todo) bool {
+	reminder.Schedule(todo)
+	if reminder.FireAt == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "待办事项没有结束时间"})
+		return false
+	}
+	if reminder.FireAt.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "提醒时间已过"})
+		return false
+	}
+	return true
+}
+
+// rescheduleReminders 待办事项的时间修改后重新计算提醒的发送时间，失败只记录日志
+func rescheduleReminders(todo *models.Todo) {
+	if err := database.RescheduleReminders(todo); err != nil {
+		log.Printf("更新提醒时间失败 (todo=%d): %v", todo.ID, err)
+	}
+}
+
+// DeliverDueReminders 发送已到期的提醒，返回处理的数量，供后台任务定期调用。
+// 每次只领取一个提醒并立即发送，领取到发送完成不超过全部渠道的发送超时
+func DeliverDueReminders() (int, error) {
+	now := time.Now()
+	// 领取后超过全部渠道的发送超时仍未完成的提醒视为进程中断，重新发送
+	stale := time.Duration(len(notify.Channels)+1) * notifyConfig.Timeout.Std()
+	if err := database.ReleaseStaleReminders(now.Add(-stale)); err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for {
+		reminder, err := database.ClaimDueReminder(now)
+		if err != nil || reminder == nil {
+			return total, err
+		}
+		if err := deliverReminder(reminder, now); err != nil {
+			log.Printf("发送提醒失败 (reminder=%d): %v", reminder.ID, err)
+		}
+		total++
+	}
+}
+
+// deliverReminder 通过提醒的各渠道发送通知并保存结果，失败的渠道在之后重试
+func deliverReminder(reminder *models.Reminder, now time.Time) error {
+	var todo models.Todo
+	if err := database.DB.First(&todo, reminder.TodoID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return database.DB.Delete(reminder).Error
+		}
+		return err
+	}
+	var user models.User
+	if err := database.DB.First(&user, reminder.UserID).Error; err != nil {
+		return err
+	}
+
+	switch {
+	case todo.Completed:
+		return finishReminder(reminder, models.ReminderSkipped, "待办事项已完成")
+	case now.Sub(reminder.FireAt.Time) > notifyConfig.MaxDelay.Std():
+		return finishReminder(reminder, models.ReminderSkipped, "超过最长延迟，未发送")
+	}
+
+	settings, err := database.GetNotificationSettings(user.ID)
+	if err != nil {
+		return err
+	}
+	to := notify.Recipient{
+		UserID:        user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		WebhookURL:    settings.WebhookURL,
+		WebhookSecret: settings.WebhookSecret,
+		NtfyTopic:     settings.NtfyTopic,
+	}
+	msg := reminderMessage(&todo, &user, reminder)
+
+	var errs []string
+	for _, ch := range reminder.Channels {
+		if containsString(reminder.Delivered, ch) {
+			continue
+		}
+		notifier, ok := notifiers[ch]
+		if !ok {
+			errs = append(errs, ch+": 不支持的通知渠道")
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), notifyConfig.Timeout.Std())
+		err := notifier.Notify(ctx, to, msg)
+		cancel()
+		if err != nil {
+			errs = append(errs, ch+": "+err.Error())
+			continue
+		}
+		reminder.Delivered = append(reminder.Delivered, ch)
+	}
+
+	reminder.Attempts++
+	if len(errs) == 0 {
+		return finishReminder(reminder, models.ReminderSent, "")
+	}
+	if reminder.Attempts >= maxReminderAttempts {
+		return finishReminder(reminder, models.ReminderFailed, strings.Join(errs, "; "))
+	}
+	retryAt := models.CustomTime{Time: now.Add(time.Duration(reminder.Attempts) * time.Minute).UTC()}
+	reminder.FireAt = &retryAt
+	reminder.Status = models.ReminderPending
+	reminder.LastError = util.Truncate(strings.Join(errs, "; "), 500)
+	return database.DB.Save(reminder).Error
+}
+
+// finishReminder 保存提醒的最终状态
+func finishReminder(reminder *models.Reminder, status, lastError string) error {
+	reminder.Status = status
+	reminder.LastError = util.Truncate(lastError, 500)
+	if status == models.ReminderSent {
+		sentAt := models.CustomTime{Time: time.Now().UTC()}
+		reminder.SentAt = &sentAt
+	}
+	return database.DB.Save(reminder).Error
+}
+
+// reminderMessage 生成提醒内容，时间按用户时区显示
+func reminderMessage(todo *models.Todo, user *models.User, reminder *models.Reminder) notify.Message {
+	loc := user.Location()
+	const layout = "2006-01-02 15:04"
+	var body strings.Builder
+	fmt.Fprintf(&body, "开始时间：%s\n", todo.StartTime.In(loc).Format(layout))
+	if todo.EndTime != nil {
+		fmt.Fprintf(&body, "结束时间：%s\n", todo.EndTime.In(loc).Format(layout))
+	}
+	fmt.Fprintf(&body, "时区：%s\n", loc)
+	if todo.Description != "" {
+		body.WriteString("\n" + todo.Description + "\n")
+	}
+	return notify.Message{
+		Title:      "待办提醒：" + todo.Title,
+		Body:       body.String(),
+		TodoID:     todo.ID,
+		ReminderID: reminder.ID,
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
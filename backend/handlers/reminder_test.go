@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"todolist/database"
+	"todolist/models"
+	"todolist/notify"
+)
+
+// fakeNotifier 记录发送次数，err 不为空时发送失败
+type fakeNotifier struct {
+	calls int
+	err   error
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, to notify.Recipient, msg notify.Message) error {
+	n.calls++
+	return n.err
+}
+
+// setupReminderTest 用 fakeNotifier 替换站内信和 Webhook 渠道，创建一个待办事项
+func setupReminderTest(t *testing.T) (*models.Todo, *fakeNotifier, *fakeNotifier) {
+	t.Helper()
+	setupTest(t, nil)
+	inApp, webhook := &fakeNotifier{}, &fakeNotifier{}
+	notifiers = map[string]notify.Notifier{notify.ChannelInApp: inApp, notify.ChannelWebhook: webhook}
+
+	user := createTestUser(t, "alice", "alice@example.com", true)
+	todo := &models.Todo{Title: "交报告", UserID: user.ID}
+	if err := database.SaveTodo(database.DB, todo, nil); err != nil {
+		t.Fatal(err)
+	}
+	return todo, inApp, webhook
+}
+
+// createDueReminder 创建发送时间为 fireAt 的提醒
+func createDueReminder(t *testing.T, todo *models.Todo, fireAt time.Time, channels ...string) *models.Reminder {
+	t.Helper()
+	at := models.CustomTime{Time: fireAt.UTC()}
+	r := &models.Reminder{
+		TodoID:    todo.ID,
+		UserID:    todo.UserID,
+		RemindAt:  &at,
+		FireAt:    &at,
+		Channels:  channels,
+		Status:    models.ReminderPending,
+		Delivered: models.StringSlice{},
+	}
+	if err := database.DB.Create(r).Error; err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// deliver 执行一次发送并返回提醒的最新状态
+func deliver(t *testing.T, id uint) *models.Reminder {
+	t.Helper()
+	if _, err := DeliverDueReminders(); err != nil {
+		t.Fatal(err)
+	}
+	var r models.Reminder
+	if err := database.DB.First(&r, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &r
+}
+
+// makeDue 把提醒的发送时间改到刚刚过去
+func makeDue(t *testing.T, id uint) {
+	t.Helper()
+	if err := database.DB.Model(&models.Reminder{}).Where("id = ?", id).
+		Update("fire_at", time.Now().Add(-time.Second).UTC()).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeliverReminder(t *testing.T) {
+	todo, inApp, webhook := setupReminderTest(t)
+	r := createDueReminder(t, todo, time.Now().Add(-time.Minute), notify.ChannelInApp, notify.ChannelWebhook)
+
+	r = deliver(t, r.ID)
+	if r.Status != models.ReminderSent || r.SentAt == nil || r.Attempts != 1 {
+		t.Errorf("提醒应当已发送: %+v", r)
+	}
+	if inApp.calls != 1 || webhook.calls != 1 {
+		t.Errorf("每个渠道应当发送 1 次: in_app=%d webhook=%d", inApp.calls, webhook.calls)
+	}
+
+	// 已发送的提醒不会再次发送
+	deliver(t, r.ID)
+	if inApp.calls != 1 || webhook.calls != 1 {
+		t.Errorf("已发送的提醒被重复发送: in_app=%d webhook=%d", inApp.calls, webhook.calls)
+	}
+}
+
+func TestDeliverReminderRetry(t *testing.T) {
+	todo, inApp, webhook := setupReminderTest(t)
+	webhook.err = errors.New("连接被拒绝")
+	r := createDueReminder(t, todo, time.Now().Add(-time.Minute), notify.ChannelInApp, notify.ChannelWebhook)
+
+	for attempt := 1; attempt < maxReminderAttempts; attempt++ {
+		before := time.Now()
+		r = deliver(t, r.ID)
+		if r.Status != models.ReminderPending || r.Attempts != attempt || r.LastError == "" {
+			t.Fatalf("第 %d 次失败后应当等待重试: %+v", attempt, r)
+		}
+		// 重试间隔按次数递增1分钟
+		retryAt := before.Add(time.Duration(attempt) * time.Minute)
+		if r.FireAt == nil || r.FireAt.Before(retryAt.Add(-time.Second)) || r.FireAt.After(retryAt.Add(5*time.Second)) {
+			t.Fatalf("第 %d 次重试时间应约为 %v，实际 %v", attempt, retryAt, r.FireAt)
+		}
+		// 未到重试时间时不发送
+		deliver(t, r.ID)
+		if webhook.calls != attempt {
+			t.Fatalf("未到重试时间就重新发送了: %d", webhook.calls)
+		}
+		makeDue(t, r.ID)
+	}
+
+	r = deliver(t, r.ID)
+	if r.Status != models.ReminderFailed || r.Attempts != maxReminderAttempts {
+		t.Errorf("达到最大次数后应当失败: %+v", r)
+	}
+	// 已发送成功的渠道在重试时跳过
+	if inApp.calls != 1 || webhook.calls != maxReminderAttempts {
+		t.Errorf("发送次数不正确: in_app=%d webhook=%d", inApp.calls, webhook.calls)
+	}
+}
+
+func TestDeliverReminderSkipped(t *testing.T) {
+	todo, inApp, _ := setupReminderTest(t)
+
+	late := createDueReminder(t, todo, time.Now().Add(-notifyConfig.MaxDelay.Std()-time.Minute), notify.ChannelInApp)
+	if r := deliver(t, late.ID); r.Status != models.ReminderSkipped {
+		t.Errorf("超过最长延迟的提醒应当跳过: %+v", r)
+	}
+
+	todo.Completed = true
+	if err := database.SaveTodo(database.DB, todo, nil); err != nil {
+		t.Fatal(err)
+	}
+	done := createDueReminder(t, todo, time.Now().Add(-time.Minute), notify.ChannelInApp)
+	if r := deliver(t, done.ID); r.Status != models.ReminderSkipped {
+		t.Errorf("已完成的待办事项的提醒应当跳过: %+v", r)
+	}
+	if inApp.calls != 0 {
+		t.Errorf("跳过的提醒不应发送: %d", inApp.calls)
+	}
+}
+
+func TestDeliverReleasesStaleReminders(t *testing.T) {
+	todo, inApp, _ := setupReminderTest(t)
+	r := createDueReminder(t, todo, time.Now().Add(-time.Minute), notify.ChannelInApp)
+
+	// 模拟另一个实例领取后中断
+	stale := time.Now().Add(-time.Duration(len(notify.Channels)+2) * notifyConfig.Timeout.Std()).UTC()
+	if err := database.DB.Model(&models.Reminder{}).Where("id = ?", r.ID).
+		Updates(map[string]interface{}{"status": models.ReminderSending, "updated_at": stale}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if r := deliver(t, r.ID); r.Status != models.ReminderSent || inApp.calls != 1 {
+		t.Errorf("中断的提醒应当重新发送: %+v calls=%d", r, inApp.calls)
+	}
+
+	// 刚被其他实例领取的提醒不会重复发送
+	other := createDueReminder(t, todo, time.Now().Add(-time.Minute), notify.ChannelInApp)
+	if _, err := database.ClaimDueReminder(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if r := deliver(t, other.ID); r.Status != models.ReminderSending || inApp.calls != 1 {
+		t.Errorf("已被领取的提醒被重复发送: %+v calls=%d", r, inApp.calls)
+	}
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"todolist/database"
+	"todolist/models"
+)
+
+// GetPermissions 获取所有可分配的权限
+func GetPermissions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"permissions": models.Permissions})
+}
+
+// GetRoles 获取角色列表
+func GetRoles(c *gin.Context) {
+	roles, counts, err := database.ListRoles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取角色列表失败"})
+		return
+	}
+
+	result := make([]gin.H, 0, len(roles))
+	for _, role := range roles {
+		result = append(result, gin.H{
+			"id":          role.ID,
+			"name":        role.Name,
+			"description": role.Description,
+			"permissions": role.Permissions,
+			"built_in":    role.BuiltIn,
+			"user_count":  counts[role.Name],
+			"created_at":  role.CreatedAt,
+			"updated_at":  role.UpdatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"roles": result})
+}
+
+// CreateRole 创建自定义角色
+func CreateRole(c *gin.Context) {
+	var request models.RoleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validateRolePermissions(c, request.Permissions) {
+		return
+	}
+
+	if _, err := database.GetRole(request.Name); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "角色名称已存在"})
+		return
+	}
+
+	role := models.Role{
+		Name:        request.Name,
+		Description: request.Description,
+		Permissions: request.Permissions,
+	}
+	if err := database.DB.Create(&role).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建角色失败"})
+		return
+	}
+	recordRoleAudit(c, models.AuditRoleCreate, nil, &role)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "角色已创建",
+		"role":    role,
+	})
+}
+
+// UpdateRole 修改自定义角色的描述和权限，角色名称创建后不可修改
+func UpdateRole(c *gin.Context) {
+	var request models.RoleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var role models.Role
+	if err := database.DB.First(&role, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "角色不存在"})
+		return
+	}
+	if role.BuiltIn {
+		c.JSON(http.StatusForbidden, gin.H{"error": database.ErrRoleBuiltIn.Error()})
+		return
+	}
+	if request.Name != role.Name {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "角色名称不可修改"})
+		return
+	}
+	// 修改前后的权限都不能超出自身权限
+	if !validateRolePermissions(c, append(request.Permissions, role.Permissions...)) {
+		return
+	}
+
+	before := role
+	role.Description = request.Description
+	role.Permissions = request.Permissions
+	if err := database.DB.Save(&role).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新角色失败"})
+		return
+	}
+	recordRoleAudit(c, models.AuditRoleUpdate, &before, &role)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "角色已更新",
+		"role":    role,
+	})
+}
+
+// DeleteRole 删除自定义角色
+func DeleteRole(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的角色ID"})
+		return
+	}
+
+	role, err := database.DeleteRole(uint(id))
+	switch {
+	case errors.Is(err, database.ErrRoleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, database.ErrRoleBuiltIn):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, database.ErrRoleInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除角色失败"})
+	default:
+		recordRoleAudit(c, models.AuditRoleDelete, role, nil)
+		c.JSON(http.StatusOK, gin.H{
+			"message": "角色已删除",
+			"role":    role,
+		})
+	}
+}
+
+// validateRolePermissions 校验权限名称有效且不超出当前用户的权限，失败时写入响应
+func validateRolePermissions(c *gin.Context, perms []string) bool {
+	for _, p := range perms {
+		if p == models.PermAll || !models.IsValidPermission(p) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的权限: " + p})
+			return false
+		}
+	}
+	if !c.MustGet("role").(*models.Role).Covers(perms) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "不能授予超出自身权限的权限"})
+		return false
+	}
+	return true
+}
+
+// recordRoleAudit 记录角色变更，before 为 nil 表示新建，after 为 nil 表示删除
+func recordRoleAudit(c *gin.Context, action string, before, after *models.Role) {
+	role := after
+	if role == nil {
+		role = before
+	}
+	recordAudit(c, models.AuditEvent{
+		Action:     action,
+		Resource:   "role",
+		ResourceID: role.Name,
+		Changes:    database.AuditDiff(before, after),
+	})
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"todolist/database"
+)
+
+// Logout 退出登录，吊销当前会话
+func Logout(c *gin.Context) {
+	sessionID, _ := c.Get("sessionID")
+
+	if err := database.RevokeSession(sessionID.(string)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "退出登录失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已退出登录"})
+}
+
+// GetSessions 获取当前用户的有效会话列表
+func GetSessions(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	sessionID, _ := c.Get("sessionID")
+
+	sessions, err := database.ListActiveSessions(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取会话列表失败"})
+		return
+	}
+
+	result := make([]gin.H, 0, len(sessions))
+	for _, s := range sessions {
+		result = append(result, gin.H{
+			"id":           s.ID,
+			"device":       s.Device,
+			"ip":           s.IP,
+			"user_agent":   s.UserAgent,
+			"last_used_at": s.LastUsedAt,
+			"created_at":   s.CreatedAt,
+			"current":      s.ID == sessionID.(string),
+		})
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// DeleteSession 吊销当前用户的指定会话
+func DeleteSession(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+
+	session, err := database.GetSession(id)
+	if err != nil || session.UserID != userID.(uint) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "会话不存在"})
+		return
+	}
+
+	if err := database.RevokeSession(session.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "吊销会话失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "会话已吊销"})
+}
@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 	"net/http"
+	"strconv"
 	"todolist/database"
 	"todolist/models"
 	"time"
@@ -10,35 +13,69 @@ import (
 
 // CreateTodo 创建待办事项
 func CreateTodo(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	user := c.MustGet("user").(*models.User)
 	var request models.CreateTodoRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	rule, ok := normalizeRRule(c, request.RRule)
+	if !ok || !checkTodoProject(c, request.ProjectID, user) {
+		return
+	}
+
 	// 使用新的转换方法创建todo
-	todo := request.ToTodo(userID.(uint))
+	todo := request.ToTodo(user.ID)
 
-	if err := database.DB.Create(todo).Error; err != nil {
+	// 默认结束时间按用户时区计算，设置了重复规则时同时创建重复系列
+	var err error
+	if rule != "" {
+		err = database.CreateRecurringTodo(todo, rule, request.ExDates, user.Location())
+	} else {
+		err = database.DB.Set(models.LocationKey, user.Location()).Create(todo).Error
+	}
+	if errors.Is(err, database.ErrRecurrenceEmpty) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建待办事项失败"})
 		return
 	}
+	advanceRecurrence(todo.RecurrenceID, user)
 
 	c.JSON(http.StatusCreated, todo)
 }
 
-// GetTodos 获取所有待办事项
+// GetTodos 获取所有待办事项，包括用户所在项目中其他成员的待办事项
 func GetTodos(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	user := c.MustGet("user").(*models.User)
 	var todos []models.Todo
-	
-	// 构建查询
-	query := database.DB.Where("user_id = ?", userID)
-	
+
+	query := filterTodos(c, database.AccessibleTodos(database.PreloadTodo(database.DB), user.ID), user)
+
+	if err := query.Find(&todos).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取待办事项失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, todos)
+}
+
+// filterTodos 根据查询参数（项目、标签、时间范围、长期、星标、重复、超时、到期）添加筛选条件，
+// user 为待办事项所属用户，"今天"、"本周"按其时区和每周第一天计算
+func filterTodos(c *gin.Context, query *gorm.DB, user *models.User) *gorm.DB {
+	// 项目筛选，none 表示不属于任何项目
+	if projectID := c.Query("project_id"); projectID == "none" {
+		query = query.Where("project_id IS NULL")
+	} else if id, err := strconv.ParseUint(projectID, 10, 64); err == nil {
+		query = query.Where("project_id = ?", id)
+	}
+
 	// 标签筛选
 	if tag := c.Query("tag"); tag != "" {
-		query = query.Where("JSON_CONTAINS(tags, ?)", tag)
+		query = query.Where(database.JSONArrayContains(database.DB, "tags", tag))
 	}
 	
 	// 时间范围筛选
@@ -64,71 +101,169 @@ func GetTodos(c *gin.Context) {
 		query = query.Where("is_starred = ?", isStarred == "true")
 	}
 
-	if err := query.Find(&todos).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取待办事项失败"})
-		return
+	// 重复任务筛选
+	switch c.Query("recurring") {
+	case "true":
+		query = query.Where("recurrence_id IS NOT NULL")
+	case "false":
+		query = query.Where("recurrence_id IS NULL")
 	}
 
-	c.JSON(http.StatusOK, todos)
+	// 超时任务筛选：未完成且已过结束时间
+	now := time.Now().UTC()
+	switch c.Query("overdue") {
+	case "true":
+		query = query.Where("completed = ? AND end_time IS NOT NULL AND end_time < ?", false, now)
+	case "false":
+		query = query.Where("completed = ? OR end_time IS NULL OR end_time >= ?", true, now)
+	}
+
+	// 到期筛选：结束时间落在用户时区的今天或本周内
+	if due := c.Query("due"); due == "today" || due == "week" {
+		from, to := dueRange(now, user, due)
+		query = query.Where("end_time >= ? AND end_time < ?", from, to)
+	}
+
+	return query
+}
+
+// dueRange 返回用户时区下今天或本周的起止时间（UTC，左闭右开）
+func dueRange(now time.Time, user *models.User, due string) (time.Time, time.Time) {
+	local := now.In(user.Location())
+	from := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	if due == "today" {
+		return from.UTC(), from.AddDate(0, 0, 1).UTC()
+	}
+	offset := (int(local.Weekday()) - user.WeekStart + 7) % 7
+	from = from.AddDate(0, 0, -offset)
+	return from.UTC(), from.AddDate(0, 0, 7).UTC()
 }
 
 // GetTodo 获取单个待办事项
 func GetTodo(c *gin.Context) {
-	userID, _ := c.Get("userID")
-	id := c.Param("id")
-	var todo models.Todo
-
-	if err := database.DB.Where("id = ? AND user_id = ?", id, userID).First(&todo).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "待办事项不存在"})
+	todo, ok := loadTodo(c, models.ProjectViewer)
+	if !ok {
 		return
 	}
 
 	c.JSON(http.StatusOK, todo)
 }
 
-// UpdateTodo 更新待办事项
+// UpdateTodo 更新待办事项，项目中的待办事项需要编辑者权限
 func UpdateTodo(c *gin.Context) {
-	userID, _ := c.Get("userID")
-	id := c.Param("id")
-	var todo models.Todo
-	var request models.UpdateTodoRequest
-
-	if err := database.DB.Where("id = ? AND user_id = ?", id, userID).First(&todo).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "待办事项不存在"})
+	todo, ok := loadTodo(c, models.ProjectEditor)
+	if !ok {
 		return
 	}
 
+	var request models.UpdateTodoRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !checkTodoMove(c, todo, request.ProjectID, c.MustGet("user").(*models.User)) {
+		return
+	}
 
-	// 使用新的更新方法
-	request.UpdateTodo(&todo)
-
-	if err := database.DB.Save(&todo).Error; err != nil {
+	owner, err := todoOwner(c, todo)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新待办事项失败"})
 		return
 	}
+	// 使用新的更新方法
+	if !applyTodoUpdate(c, todo, &request, owner) {
+		return
+	}
 
 	c.JSON(http.StatusOK, todo)
 }
 
-// DeleteTodo 删除待办事项
+// DeleteTodo 删除待办事项，项目中的待办事项需要编辑者权限
 func DeleteTodo(c *gin.Context) {
-	userID, _ := c.Get("userID")
-	id := c.Param("id")
-	var todo models.Todo
-
-	if err := database.DB.Where("id = ? AND user_id = ?", id, userID).First(&todo).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "待办事项不存在"})
+	todo, ok := loadTodo(c, models.ProjectEditor)
+	if !ok {
 		return
 	}
 
-	if err := database.DB.Delete(&todo).Error; err != nil {
+	owner, err := todoOwner(c, todo)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除待办事项失败"})
 		return
 	}
+	if !deleteTodoInScope(c, todo, owner) {
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
-} 
\ No newline at end of file
+}
+
+// loadTodo 加载路径参数 :id 对应的待办事项（含检查项和重复系列）并检查当前用户的权限：
+// 不属于项目的待办事项只有创建者可以访问，项目中的待办事项按成员角色判断是否满足 need。
+// 无法访问时写入 404 响应，权限不足时写入 403 响应
+func loadTodo(c *gin.Context, need string) (*models.Todo, bool) {
+	user := c.MustGet("user").(*models.User)
+	var todo models.Todo
+	if err := database.PreloadTodo(database.DB).Where("id = ?", c.Param("id")).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "待办事项不存在"})
+		return nil, false
+	}
+
+	if todo.ProjectID == nil {
+		if todo.UserID != user.ID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "待办事项不存在"})
+			return nil, false
+		}
+		return &todo, true
+	}
+	role, err := database.ProjectRole(*todo.ProjectID, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取待办事项失败"})
+		return nil, false
+	}
+	if role == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "待办事项不存在"})
+		return nil, false
+	}
+	if !models.ProjectRoleAllows(role, need) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "没有权限修改该项目中的待办事项"})
+		return nil, false
+	}
+	return &todo, true
+}
+
+// todoOwner 返回待办事项的创建者，默认结束时间和重复日期按其时区计算
+func todoOwner(c *gin.Context, todo *models.Todo) (*models.User, error) {
+	if user := c.MustGet("user").(*models.User); user.ID == todo.UserID {
+		return user, nil
+	}
+	var owner models.User
+	if err := database.DB.First(&owner, todo.UserID).Error; err != nil {
+		return nil, err
+	}
+	return &owner, nil
+}
+
+// checkTodoMove 校验 user 能否把待办事项移到 projectID 对应的项目（nil 表示不移动，0 表示移出项目）。
+// 项目中的待办事项只有创建者可以移出，移出后只有创建者可以访问；失败时写入错误响应
+func checkTodoMove(c *gin.Context, todo *models.Todo, projectID *uint, user *models.User) bool {
+	if projectID == nil {
+		return true
+	}
+	if *projectID == 0 {
+		if todo.ProjectID != nil && todo.UserID != user.ID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "只有创建者可以把待办事项移出项目"})
+			return false
+		}
+		return true
+	}
+	// 已归档项目中的待办事项仍可修改，只在移动到其他项目时校验
+	if todo.ProjectID != nil && *todo.ProjectID == *projectID {
+		return true
+	}
+	return checkTodoProject(c, projectID, user)
+}
+
+// saveTodo 保存待办事项本身（不保存已加载的检查项和重复系列），默认结束时间按 owner 的时区计算
+func saveTodo(todo *models.Todo, owner *models.User) error {
+	return database.SaveTodo(database.DB, todo, owner.Location())
+}
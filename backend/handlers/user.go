@@ -1,19 +1,39 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 	"net/http"
+	"todolist/config"
 	"todolist/database"
 	"todolist/middleware"
 	"todolist/models"
+	"todolist/util"
 )
 
+// Register 用户注册，按注册策略决定是否需要邀请码以及注册后是否直接激活
 func Register(c *gin.Context) {
+	if regConfig.Mode == config.RegistrationClosed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "系统已关闭注册"})
+		return
+	}
+
 	var request models.UserRegister
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if regConfig.Mode == config.RegistrationInviteOnly && request.InviteCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请填写邀请码"})
+		return
+	}
 
 	// 检查用户名是否已存在
 	var existingUser models.User
@@ -21,26 +41,82 @@ func Register(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "用户名已存在"})
 		return
 	}
+	if request.Email != "" {
+		if err := database.DB.Where("LOWER(email) = ?", strings.ToLower(request.Email)).First(&existingUser).Error; err == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "邮箱已被使用"})
+			return
+		}
+	}
 
 	user := models.User{
 		Username: request.Username,
+		Email:    request.Email,
 		Password: request.Password,
 		Role:     models.RoleUser,
 		Status:   models.StatusInactive,
 	}
+	if !validateNewPassword(c, &user, request.Password) {
+		return
+	}
+	// 白名单域名的邮箱需要先验证，验证后再激活（见 VerifyEmail）
+	if regConfig.Mode == config.RegistrationOpen {
+		user.Status = models.StatusActive
+	}
 
 	if err := user.HashPassword(); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "密码加密失败"})
 		return
 	}
 
-	if err := database.DB.Create(&user).Error; err != nil {
+	// 使用邀请码注册时直接激活，并获得邀请码指定的角色
+	var invite *models.Invite
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if request.InviteCode != "" {
+			var err error
+			if invite, err = database.RedeemInvite(tx, request.InviteCode); err != nil {
+				return err
+			}
+			if err := tx.Where("name = ?", invite.Role).First(&models.Role{}).Error; err != nil {
+				return database.ErrInviteInvalid
+			}
+			user.Role = invite.Role
+			user.Status = models.StatusActive
+		}
+		return tx.Create(&user).Error
+	})
+	if errors.Is(err, database.ErrInviteInvalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "用户创建失败"})
 		return
 	}
 
+	event := models.AuditEvent{
+		ActorID:       &user.ID,
+		ActorUsername: user.Username,
+		TargetUserID:  &user.ID,
+		Action:        models.AuditRegister,
+	}
+	if invite != nil {
+		event.Resource = "invite"
+		event.ResourceID = fmt.Sprint(invite.ID)
+	}
+	recordAudit(c, event)
+
+	if err := sendEmailVerification(&user); err != nil {
+		log.Printf("发送验证邮件失败 (user=%d): %v", user.ID, err)
+	}
+
+	message := "注册成功，请等待管理员审核"
+	if user.IsActive() {
+		message = "注册成功"
+	} else if activatesOnVerify(&user) {
+		message = "注册成功，请查收验证邮件，验证邮箱后即可登录"
+	}
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "注册成功，请等待管理员审核",
+		"message": message,
 		"user": gin.H{
 			"id":       user.ID,
 			"username": user.Username,
@@ -50,6 +126,35 @@ func Register(c *gin.Context) {
 	})
 }
 
+// GetRegistrationPolicy 返回当前注册策略，供前端决定是否展示注册入口和邀请码输入框
+func GetRegistrationPolicy(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"mode":            regConfig.Mode,
+		"invite_required": regConfig.Mode == config.RegistrationInviteOnly,
+	})
+}
+
+// activatesOnVerify 判断待审核的用户验证邮箱后是否自动激活：审核模式下邮箱属于白名单域名
+func activatesOnVerify(user *models.User) bool {
+	return regConfig.Mode == config.RegistrationApproval && user.Status == models.StatusInactive &&
+		isAllowedDomain(user.Email)
+}
+
+// isAllowedDomain 判断邮箱的域名是否在自动激活白名单中
+func isAllowedDomain(address string) bool {
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return false
+	}
+	domain := address[at+1:]
+	for _, allowed := range regConfig.AllowedDomains {
+		if strings.EqualFold(domain, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
 func Login(c *gin.Context) {
 	var request models.UserLogin
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -57,19 +162,34 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	// 登录限流：同一IP和同一用户名的失败次数过多时拒绝尝试
+	ip := c.ClientIP()
+	if !checkLoginThrottle(c, ip, request.Username) {
+		return
+	}
+
 	var user models.User
 	if err := database.DB.Where("username = ?", request.Username).First(&user).Error; err != nil {
+		throttleFail(ip, request.Username)
+		recordLoginFailure(c, request.Username, nil)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户名或密码错误"})
 		return
 	}
 
 	if err := user.CheckPassword(request.Password); err != nil {
+		throttleFail(ip, request.Username)
+		recordLoginFailure(c, request.Username, &user)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户名或密码错误"})
 		return
 	}
+	throttleSucceed(request.Username)
 
 	// 检查用户状态
 	if user.Status == models.StatusInactive {
+		if activatesOnVerify(&user) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "账号未激活，请先验证邮箱"})
+			return
+		}
 		c.JSON(http.StatusForbidden, gin.H{"error": "账号未激活，请等待管理员审核"})
 		return
 	}
@@ -79,23 +199,177 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	token, err := middleware.GenerateToken(user.ID)
+	// 开启了两步验证时先返回挑战令牌，由 /auth/mfa/verify 完成登录
+	if user.TOTPEnabled {
+		mfaToken, err := middleware.GenerateMFAToken(user.ID, request.Device)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "token生成失败"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+		})
+		return
+	}
+
+	respondWithSession(c, &user, request.Device)
+}
+
+// checkLoginThrottle 检查登录限流，被限流时写入429响应并返回 false
+func checkLoginThrottle(c *gin.Context, ip, username string) bool {
+	decision, err := loginThrottler.Check(ip, username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "登录失败"})
+		return false
+	}
+	if !decision.Allowed {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(decision.RetryAfter.Seconds()))))
+		if decision.Locked {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "登录失败次数过多，账号已被临时锁定，请稍后再试或联系管理员"})
+		} else {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "登录尝试过于频繁，请稍后再试"})
+		}
+		return false
+	}
+	return true
+}
+
+// throttleFail 记录一次失败尝试。写入失败会使锁定失效，因此必须记录日志
+func throttleFail(ip, key string) {
+	if err := loginThrottler.Fail(ip, key); err != nil {
+		log.Printf("记录登录失败次数失败 (%s): %v", key, err)
+	}
+}
+
+// throttleSucceed 认证成功后清除用户名的失败记录
+func throttleSucceed(username string) {
+	if err := loginThrottler.Succeed(username); err != nil {
+		log.Printf("清除登录失败次数失败 (%s): %v", username, err)
+	}
+}
+
+// PruneLoginThrottles 删除已过期的登录失败记录，返回删除的数量
+func PruneLoginThrottles() (int, error) {
+	return loginThrottler.Prune()
+}
+
+// recordLoginFailure 记录一次登录失败，用户不存在时只记录尝试的用户名
+func recordLoginFailure(c *gin.Context, username string, user *models.User) {
+	event := models.AuditEvent{
+		Action:        models.AuditLoginFailed,
+		ActorUsername: util.Truncate(username, 50),
+	}
+	if user != nil {
+		event.TargetUserID = &user.ID
+	}
+	recordAudit(c, event)
+}
+
+// respondWithSession 为通过认证的用户创建会话并返回令牌
+func respondWithSession(c *gin.Context, user *models.User, device string) {
+	token, refreshToken, err := createSession(c, user, device)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "token生成失败"})
 		return
 	}
 
+	// 返回角色权限，供前端决定展示哪些管理功能
+	permissions := []string{}
+	if role, err := database.GetRole(user.Role); err == nil {
+		permissions = role.Permissions
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"token": token,
+		"token":         token,
+		"refresh_token": refreshToken,
+		"expires_in":    int(middleware.AccessTokenTTL().Seconds()),
 		"user": gin.H{
-			"id":       user.ID,
-			"username": user.Username,
-			"role":     user.Role,
-			"status":   user.Status,
+			"id":                   user.ID,
+			"username":             user.Username,
+			"role":                 user.Role,
+			"permissions":          permissions,
+			"status":               user.Status,
+			"delete_after":         user.DeleteAfter, // 已申请注销时为彻底删除的时间
+			"must_change_password": user.MustChangePassword,
 		},
 	})
 }
 
+// createSession 创建会话，返回访问令牌和刷新令牌
+func createSession(c *gin.Context, user *models.User, device string) (string, string, error) {
+	session := &models.Session{
+		UserID:    user.ID,
+		Device:    device,
+		IP:        c.ClientIP(),
+		UserAgent: util.Truncate(c.Request.UserAgent(), 255),
+	}
+	refreshToken, err := database.CreateSession(session, jwtConfig.RefreshTokenTTL.Std())
+	if err != nil {
+		return "", "", err
+	}
+
+	token, err := middleware.GenerateToken(user.ID, session.ID)
+	if err != nil {
+		return "", "", err
+	}
+
+	recordAudit(c, models.AuditEvent{
+		ActorID:       &user.ID,
+		ActorUsername: user.Username,
+		TargetUserID:  &user.ID,
+		Action:        models.AuditLogin,
+		Resource:      "session",
+		ResourceID:    session.ID,
+	})
+	return token, refreshToken, nil
+}
+
+// RefreshToken 使用刷新令牌换取新的访问令牌和刷新令牌
+func RefreshToken(c *gin.Context) {
+	var request models.RefreshRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	old, refreshToken, err := database.RotateRefreshToken(request.RefreshToken, jwtConfig.RefreshTokenTTL.Std())
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrRefreshTokenInvalid),
+			errors.Is(err, database.ErrRefreshTokenExpired),
+			errors.Is(err, database.ErrRefreshTokenReused):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "刷新令牌失败"})
+		}
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, old.UserID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户不存在"})
+		return
+	}
+	if !user.IsActive() {
+		database.RevokeSession(old.FamilyID)
+		c.JSON(http.StatusForbidden, gin.H{"error": "账号未激活或已被禁用"})
+		return
+	}
+
+	token, err := middleware.GenerateToken(user.ID, old.FamilyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "token生成失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":         token,
+		"refresh_token": refreshToken,
+		"expires_in":    int(middleware.AccessTokenTTL().Seconds()),
+	})
+}
+
 // UpdatePassword 修改密码
 func UpdatePassword(c *gin.Context) {
 	// 获取当前用户
@@ -114,18 +388,29 @@ func UpdatePassword(c *gin.Context) {
 		return
 	}
 
-	// 更新密码
-	user.Password = request.NewPassword
-	if err := user.HashPassword(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "密码加密失败"})
+	if !validateNewPassword(c, user, request.NewPassword) {
 		return
 	}
 
-	if err := database.DB.Save(user).Error; err != nil {
+	// 更新密码，同时解除"必须修改密码"的要求
+	hashed := models.User{Password: request.NewPassword}
+	if err := hashed.HashPassword(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "密码加密失败"})
+		return
+	}
+	if err := database.SetPassword(database.DB, user, hashed.Password, passwordConfig.HistorySize, false); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新密码失败"})
 		return
 	}
 
+	// 吊销除当前会话外的其他会话
+	sessionID, _ := c.Get("sessionID")
+	if _, err := database.RevokeUserSessions(user.ID, sessionID.(string)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "吊销会话失败"})
+		return
+	}
+
+	recordAudit(c, models.AuditEvent{Action: models.AuditPasswordChange, TargetUserID: &user.ID})
 	c.JSON(http.StatusOK, gin.H{
 		"message": "密码修改成功",
 	})
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"todolist/config"
+	"todolist/database"
+	"todolist/models"
+)
+
+func setupRegisterTest(t *testing.T, mode string) (*gin.Engine, *captureMailer) {
+	t.Helper()
+	setupTest(t, func(cfg *config.Config) {
+		cfg.Registration.Mode = mode
+		cfg.Registration.AllowedDomains = []string{"corp.example.com"}
+	})
+	m := useCaptureMailer(t)
+
+	r := gin.New()
+	r.POST("/auth/register", Register)
+	r.POST("/auth/login", Login)
+	r.POST("/auth/email/verify", VerifyEmail)
+	return r, m
+}
+
+func userStatus(t *testing.T, username string) string {
+	t.Helper()
+	var user models.User
+	if err := database.DB.Where("username = ?", username).First(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user.Status
+}
+
+func TestRegisterAllowedDomainActivatesAfterVerify(t *testing.T) {
+	r, m := setupRegisterTest(t, config.RegistrationApproval)
+
+	w := doJSON(r, http.MethodPost, "/auth/register", "", gin.H{
+		"username": "alice",
+		"password": "Sup3r-secret",
+		"email":    "alice@corp.example.com",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("注册返回 %d: %s", w.Code, w.Body.String())
+	}
+	// 邮箱未经验证前不能凭域名激活
+	if status := userStatus(t, "alice"); status != models.StatusInactive {
+		t.Fatalf("验证邮箱前账号状态为 %s", status)
+	}
+	w = doJSON(r, http.MethodPost, "/auth/login", "", gin.H{"username": "alice", "password": "Sup3r-secret"})
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("验证邮箱前不应能登录，实际 %d", w.Code)
+	}
+
+	msg := m.next(t)
+	if msg.To != "alice@corp.example.com" {
+		t.Fatalf("验证邮件发送到了 %s", msg.To)
+	}
+	w = doJSON(r, http.MethodPost, "/auth/email/verify", "", gin.H{"token": mailToken(t, msg)})
+	if w.Code != http.StatusOK {
+		t.Fatalf("验证邮箱返回 %d: %s", w.Code, w.Body.String())
+	}
+	if status := userStatus(t, "alice"); status != models.StatusActive {
+		t.Fatalf("验证邮箱后账号状态为 %s", status)
+	}
+	w = doJSON(r, http.MethodPost, "/auth/login", "", gin.H{"username": "alice", "password": "Sup3r-secret"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("验证邮箱后登录返回 %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRegisterEmailUsernameNotTrusted(t *testing.T) {
+	r, m := setupRegisterTest(t, config.RegistrationApproval)
+
+	// 邮箱形式的用户名不再作为白名单依据
+	w := doJSON(r, http.MethodPost, "/auth/register", "", gin.H{
+		"username": "bob@corp.example.com",
+		"password": "Sup3r-secret",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("注册返回 %d: %s", w.Code, w.Body.String())
+	}
+	if status := userStatus(t, "bob@corp.example.com"); status != models.StatusInactive {
+		t.Fatalf("账号状态为 %s，应当等待审核", status)
+	}
+	m.expectNone(t)
+}
+
+func TestRegisterOtherDomainStaysPending(t *testing.T) {
+	r, m := setupRegisterTest(t, config.RegistrationApproval)
+
+	w := doJSON(r, http.MethodPost, "/auth/register", "", gin.H{
+		"username": "carol",
+		"password": "Sup3r-secret",
+		"email":    "carol@elsewhere.example.com",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("注册返回 %d: %s", w.Code, w.Body.String())
+	}
+	w = doJSON(r, http.MethodPost, "/auth/email/verify", "", gin.H{"token": mailToken(t, m.next(t))})
+	if w.Code != http.StatusOK {
+		t.Fatalf("验证邮箱返回 %d: %s", w.Code, w.Body.String())
+	}
+	// 非白名单域名验证邮箱后仍需管理员审核
+	if status := userStatus(t, "carol"); status != models.StatusInactive {
+		t.Fatalf("账号状态为 %s，应当等待审核", status)
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"todolist/database"
+	"todolist/models"
+)
+
+// 管理员访问其他用户的待办事项，每次访问都会写入审计日志
+
+// GetUserTodos 获取指定用户的待办事项列表，筛选参数与 GetTodos 相同
+func GetUserTodos(c *gin.Context) {
+	target, ok := loadTargetUser(c)
+	if !ok {
+		return
+	}
+
+	var todos []models.Todo
+	query := filterTodos(c, database.PreloadTodo(database.DB).Where("user_id = ?", target.ID), target)
+	if err := query.Find(&todos).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取待办事项失败"})
+		return
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:       models.AuditTodoList,
+		TargetUserID: &target.ID,
+		Resource:     "todo",
+	})
+	c.JSON(http.StatusOK, todos)
+}
+
+// GetUserTodo 获取指定用户的单个待办事项
+func GetUserTodo(c *gin.Context) {
+	target, todo, ok := loadTargetTodo(c)
+	if !ok {
+		return
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:       models.AuditTodoRead,
+		TargetUserID: &target.ID,
+		Resource:     "todo",
+		ResourceID:   fmt.Sprint(todo.ID),
+	})
+	c.JSON(http.StatusOK, todo)
+}
+
+// UpdateUserTodo 更新指定用户的待办事项
+func UpdateUserTodo(c *gin.Context) {
+	target, todo, ok := loadTargetTodo(c)
+	if !ok {
+		return
+	}
+	if !requireCoversTarget(c, target) {
+		return
+	}
+
+	var request models.UpdateTodoRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !checkTodoMove(c, todo, request.ProjectID, target) {
+		return
+	}
+
+	before := *todo
+	if !applyTodoUpdate(c, todo, &request, target) {
+		return
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:       models.AuditTodoUpdate,
+		TargetUserID: &target.ID,
+		Resource:     "todo",
+		ResourceID:   fmt.Sprint(todo.ID),
+		Changes:      database.AuditDiff(before, todo),
+	})
+	c.JSON(http.StatusOK, todo)
+}
+
+// DeleteUserTodo 删除指定用户的待办事项
+func DeleteUserTodo(c *gin.Context) {
+	target, todo, ok := loadTargetTodo(c)
+	if !ok {
+		return
+	}
+	if !requireCoversTarget(c, target) {
+		return
+	}
+
+	if !deleteTodoInScope(c, todo, target) {
+		return
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:       models.AuditTodoDelete,
+		TargetUserID: &target.ID,
+		Resource:     "todo",
+		ResourceID:   fmt.Sprint(todo.ID),
+		Changes:      database.AuditDiff(todo, nil),
+	})
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// loadTargetUser 加载路径参数 :id 对应的用户，不存在时写入 404 响应
+func loadTargetUser(c *gin.Context) (*models.User, bool) {
+	var user models.User
+	if err := database.DB.First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return nil, false
+	}
+	return &user, true
+}
+
+// loadTargetTodo 加载路径参数 :id 对应用户的 :todoId 待办事项
+func loadTargetTodo(c *gin.Context) (*models.User, *models.Todo, bool) {
+	user, ok := loadTargetUser(c)
+	if !ok {
+		return nil, nil, false
+	}
+	var todo models.Todo
+	if err := database.PreloadTodo(database.DB).Where("id = ? AND user_id = ?", c.Param("todoId"), user.ID).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "待办事项不存在"})
+		return nil, nil, false
+	}
+	return user, &todo, true
+}
@@ -6,31 +6,101 @@ import (
 	"net/http"
 	"strings"
 	"time"
+	"todolist/config"
 	"todolist/database"
 	"todolist/models"
 )
 
-var JWTSecret = []byte("your_jwt_secret_key")
+var (
+	jwtSecret        []byte
+	accessTokenTTL   = 15 * time.Minute
+	impersonationTTL = 15 * time.Minute
+)
+
+// Setup 根据配置初始化认证中间件
+func Setup(cfg *config.Config) {
+	jwtSecret = []byte(cfg.JWT.Secret)
+	accessTokenTTL = cfg.JWT.AccessTokenTTL.Std()
+	impersonationTTL = cfg.JWT.ImpersonationTTL.Std()
+}
+
+// passwordChangeRoutes 必须修改密码的用户仍可访问的接口
+var passwordChangeRoutes = map[string]bool{
+	"PUT /auth/password": true,
+	"POST /auth/logout":  true,
+	"GET /me":            true,
+}
+
+// AccessTokenTTL 返回访问令牌的有效期
+func AccessTokenTTL() time.Duration {
+	return accessTokenTTL
+}
 
 type Claims struct {
 	UserID uint
+	// ActorID 非零时为模拟登录令牌：UserID 为被模拟的用户，ActorID 为发起模拟的管理员，jti 为管理员的会话ID
+	ActorID uint `json:",omitempty"`
 	jwt.StandardClaims
 }
 
-func GenerateToken(userID uint) (string, error) {
+// GenerateToken 为会话签发访问令牌，jti 为会话ID
+func GenerateToken(userID uint, sessionID string) (string, error) {
 	claims := Claims{
 		UserID: userID,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().AddDate(100, 0, 0).Unix(),
+			Id:        sessionID,
+			ExpiresAt: time.Now().Add(accessTokenTTL).Unix(),
 			IssuedAt:  time.Now().Unix(),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(JWTSecret)
+	return token.SignedString(jwtSecret)
 }
 
-func AuthMiddleware() gin.HandlerFunc {
+// mfaTokenTTL 两步验证挑战令牌的有效期
+const mfaTokenTTL = 5 * time.Minute
+
+// MFAClaims 两步验证挑战令牌，密码校验通过后签发，只能用于 /auth/mfa/verify
+type MFAClaims struct {
+	UserID  uint
+	Purpose string
+	Device  string
+	jwt.StandardClaims
+}
+
+// GenerateMFAToken 签发两步验证挑战令牌
+func GenerateMFAToken(userID uint, device string) (string, error) {
+	claims := MFAClaims{
+		UserID:  userID,
+		Purpose: "mfa",
+		Device:  device,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(mfaTokenTTL).Unix(),
+			IssuedAt:  time.Now().Unix(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+// ParseMFAToken 校验两步验证挑战令牌
+func ParseMFAToken(tokenString string) (*MFAClaims, bool) {
+	claims := &MFAClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return jwtSecret, nil
+	})
+	if err != nil || !token.Valid || claims.Purpose != "mfa" {
+		return nil, false
+	}
+	return claims, true
+}
+
+// AuthMiddleware 认证中间件，同时接受会话JWT和个人访问令牌
+//
+// 会话JWT可访问所有接口；个人访问令牌只能访问声明了 scopes 的接口，且必须包含全部 scopes。
+func AuthMiddleware(scopes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -46,20 +116,65 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(parts[1], claims, func(token *jwt.Token) (interface{}, error) {
-			return JWTSecret, nil
-		})
+		var userID uint
+		if strings.HasPrefix(parts[1], models.APITokenPrefix) {
+			apiToken, err := database.FindAPIToken(parts[1])
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的API令牌"})
+				c.Abort()
+				return
+			}
+			if len(scopes) == 0 {
+				c.JSON(http.StatusForbidden, gin.H{"error": "API令牌无权访问该接口"})
+				c.Abort()
+				return
+			}
+			for _, scope := range scopes {
+				if !apiToken.HasScope(scope) {
+					c.JSON(http.StatusForbidden, gin.H{"error": "API令牌缺少权限: " + scope})
+					c.Abort()
+					return
+				}
+			}
+			database.TouchAPIToken(apiToken.ID)
+			userID = apiToken.UserID
+			c.Set("apiTokenID", apiToken.ID)
+		} else {
+			claims := &Claims{}
+			token, err := jwt.ParseWithClaims(parts[1], claims, func(token *jwt.Token) (interface{}, error) {
+				return jwtSecret, nil
+			})
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的认证信息"})
-			c.Abort()
-			return
+			if err != nil || !token.Valid || claims.Id == "" {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的认证信息"})
+				c.Abort()
+				return
+			}
+
+			if claims.ActorID != 0 {
+				actor, ok := authenticateImpersonation(c, claims)
+				if !ok {
+					return
+				}
+				userID = claims.UserID
+				c.Set("impersonator", actor)
+			} else {
+				// 检查会话是否已被吊销
+				session, err := database.GetSession(claims.Id)
+				if err != nil || session.UserID != claims.UserID || !session.IsValid() {
+					c.JSON(http.StatusUnauthorized, gin.H{"error": "会话已失效，请重新登录"})
+					c.Abort()
+					return
+				}
+				database.TouchSession(session.ID, c.ClientIP())
+				userID = claims.UserID
+				c.Set("sessionID", session.ID)
+			}
 		}
 
 		// 从数据库获取完整的用户信息
 		var user models.User
-		if err := database.DB.First(&user, claims.UserID).Error; err != nil {
+		if err := database.DB.First(&user, userID).Error; err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "用户不存在"})
 			c.Abort()
 			return
@@ -72,6 +187,19 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// 模拟登录：禁止账号安全、管理和删除类操作，并记录每个请求
+		if v, ok := c.Get("impersonator"); ok {
+			serveImpersonated(c, v.(*models.User), &user)
+			return
+		}
+
+		// 必须修改密码的用户在修改前只能访问少数接口
+		if user.MustChangePassword && !passwordChangeRoutes[c.Request.Method+" "+c.FullPath()] {
+			c.JSON(http.StatusForbidden, gin.H{"error": "请先修改密码", "code": "password_change_required"})
+			c.Abort()
+			return
+		}
+
 		// 更新用户最后活跃时间
 		now := time.Now().UTC()
 		if err := database.DB.Model(&user).Update("last_active", now).Error; err != nil {
@@ -80,8 +208,8 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 		user.LastActive = models.CustomTime{Time: now}
 
-		c.Set("userID", claims.UserID)
+		c.Set("userID", userID)
 		c.Set("user", &user)
 		c.Next()
 	}
-} 
\ No newline at end of file
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"todolist/database"
+	"todolist/models"
+	"todolist/util"
+)
+
+// ImpersonationTTL 返回模拟登录令牌的有效期
+func ImpersonationTTL() time.Duration {
+	return impersonationTTL
+}
+
+// GenerateImpersonationToken 为管理员签发模拟登录令牌，令牌随管理员会话吊销而失效，不能刷新
+func GenerateImpersonationToken(userID, actorID uint, actorSessionID string) (string, error) {
+	claims := Claims{
+		UserID:  userID,
+		ActorID: actorID,
+		StandardClaims: jwt.StandardClaims{
+			Id:        actorSessionID,
+			Subject:   "impersonation",
+			ExpiresAt: time.Now().Add(impersonationTTL).Unix(),
+			IssuedAt:  time.Now().Unix(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+// authenticateImpersonation 校验模拟登录令牌的发起者：会话有效、账号已激活且仍拥有模拟登录权限
+func authenticateImpersonation(c *gin.Context, claims *Claims) (*models.User, bool) {
+	session, err := database.GetSession(claims.Id)
+	if err != nil || session.UserID != claims.ActorID || !session.IsValid() {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "模拟登录已失效，请重新发起"})
+		c.Abort()
+		return nil, false
+	}
+
+	var actor models.User
+	if err := database.DB.First(&actor, claims.ActorID).Error; err != nil || !actor.IsActive() {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "模拟登录已失效，请重新发起"})
+		c.Abort()
+		return nil, false
+	}
+	role, err := database.GetRole(actor.Role)
+	if err != nil || !role.HasPermission(models.PermUsersImpersonate) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权限模拟登录"})
+		c.Abort()
+		return nil, false
+	}
+	return &actor, true
+}
+
+// impersonationAllowed 模拟登录期间只允许查看和普通的待办事项操作，
+// 账号安全、个人资料、管理接口、项目成员和邀请管理以及所有删除操作一律禁止
+func impersonationAllowed(method, path string) bool {
+	if method == http.MethodDelete {
+		return false
+	}
+	for _, prefix := range []string{"/auth/", "/admin/", "/users/", "/project-invitations/"} {
+		if strings.HasPrefix(path, prefix) {
+			return false
+		}
+	}
+	// 成员和邀请管理会把被模拟用户的项目授权给其他人
+	if strings.HasPrefix(path, "/projects/:id/members") || strings.HasPrefix(path, "/projects/:id/invitations") {
+		return method == http.MethodGet
+	}
+	if path == "/me" {
+		return method == http.MethodGet
+	}
+	return !strings.HasPrefix(path, "/me/")
+}
+
+// serveImpersonated 处理模拟登录的请求：响应头标明模拟者，不更新被模拟用户的活跃时间，
+// 每个请求（包括被拒绝的）都写入审计日志
+func serveImpersonated(c *gin.Context, actor, user *models.User) {
+	c.Header("X-Impersonated-By", actor.Username)
+
+	event := models.AuditEvent{
+		ActorID:       &actor.ID,
+		ActorUsername: actor.Username,
+		TargetUserID:  &user.ID,
+		Action:        models.AuditImpersonationRequest,
+		Resource:      c.Request.Method,
+		ResourceID:    util.Truncate(c.Request.URL.Path, 64),
+		IP:            c.ClientIP(),
+		UserAgent:     util.Truncate(c.Request.UserAgent(), 255),
+	}
+
+	if !impersonationAllowed(c.Request.Method, c.FullPath()) {
+		event.Action = models.AuditImpersonationBlocked
+		recordImpersonation(&event)
+		c.JSON(http.StatusForbidden, gin.H{"error": "模拟登录期间不允许该操作", "code": "impersonation_forbidden"})
+		c.Abort()
+		return
+	}
+
+	c.Set("userID", user.ID)
+	c.Set("user", user)
+	c.Next()
+
+	event.Changes = models.AuditChanges{"status": {After: c.Writer.Status()}}
+	recordImpersonation(&event)
+}
+
+func recordImpersonation(event *models.AuditEvent) {
+	if err := database.RecordAudit(event); err != nil {
+		log.Printf("写入审计日志失败 (%s): %v", event.Action, err)
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestImpersonationAllowed(t *testing.T) {
+	cases := []struct {
+		method, path string
+		want         bool
+	}{
+		{http.MethodGet, "/todos", true},
+		{http.MethodPost, "/todos", true},
+		{http.MethodPut, "/todos/:id", true},
+		{http.MethodDelete, "/todos/:id", false},
+		{http.MethodGet, "/me", true},
+		{http.MethodPut, "/me", false},
+		{http.MethodGet, "/me/export", false},
+		{http.MethodPost, "/auth/tokens", false},
+		{http.MethodGet, "/admin/users", false},
+		{http.MethodPut, "/users/:id/todos/:todoId", false},
+
+		{http.MethodGet, "/projects", true},
+		{http.MethodPost, "/projects", true},
+		{http.MethodPut, "/projects/:id", true},
+		{http.MethodDelete, "/projects/:id", false},
+		{http.MethodGet, "/projects/:id/members", true},
+		{http.MethodPut, "/projects/:id/members/:userId", false},
+		{http.MethodGet, "/projects/:id/invitations", true},
+		{http.MethodPost, "/projects/:id/invitations", false},
+		{http.MethodGet, "/project-invitations", true},
+		{http.MethodPost, "/project-invitations/:id/accept", false},
+		{http.MethodPost, "/project-invitations/:id/decline", false},
+	}
+	for _, tc := range cases {
+		if got := impersonationAllowed(tc.method, tc.path); got != tc.want {
+			t.Errorf("impersonationAllowed(%s %s) = %v，应当为 %v", tc.method, tc.path, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"todolist/database"
+	"todolist/models"
+)
+
+// RequirePermission 要求当前用户的角色拥有指定的全部权限，需放在 AuthMiddleware 之后
+func RequirePermission(perms ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := c.MustGet("user").(*models.User)
+
+		role, err := database.GetRole(user.Role)
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "无权限访问"})
+			c.Abort()
+			return
+		}
+		for _, perm := range perms {
+			if !role.HasPermission(perm) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "无权限访问"})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Set("role", role)
+		c.Next()
+	}
+}
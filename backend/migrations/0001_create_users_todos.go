@@ -0,0 +1,60 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 迁移中使用表结构快照，避免模型后续变更影响历史迁移
+type user0001 struct {
+	ID        uint   `gorm:"primarykey"`
+	Username  string `gorm:"unique;not null"`
+	Password  string `gorm:"not null"`
+	Role      string `gorm:"type:varchar(10);default:'user'"`
+	Status    string `gorm:"type:varchar(10);default:'inactive'"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (user0001) TableName() string { return "users" }
+
+type todo0001 struct {
+	ID          uint   `gorm:"primarykey"`
+	Title       string `gorm:"not null"`
+	Description string
+	Completed   bool       `gorm:"default:false"`
+	CompletedAt *time.Time
+	IsLongTerm  bool      `gorm:"default:false"`
+	IsStarred   bool      `gorm:"default:false"`
+	UserID      uint      `gorm:"not null"`
+	StartTime   time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	EndTime     *time.Time
+	Tags        string `gorm:"type:text"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (todo0001) TableName() string { return "todos" }
+
+func init() {
+	register(Migration{
+		Version: 1,
+		Name:    "create_users_todos",
+		Up: func(tx *gorm.DB) error {
+			// 兼容此前由 AutoMigrate 创建的数据库：表已存在时跳过
+			for _, table := range []interface{}{&user0001{}, &todo0001{}} {
+				if tx.Migrator().HasTable(table) {
+					continue
+				}
+				if err := tx.Migrator().CreateTable(table); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&todo0001{}, &user0001{})
+		},
+	})
+}
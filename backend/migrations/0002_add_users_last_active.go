@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type user0002 struct {
+	LastActive time.Time
+}
+
+func (user0002) TableName() string { return "users" }
+
+func init() {
+	register(Migration{
+		Version: 2,
+		Name:    "add_users_last_active",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&user0002{}, "last_active") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&user0002{}, "LastActive")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&user0002{}, "LastActive")
+		},
+	})
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type session0004 struct {
+	ID         string `gorm:"primaryKey;size:64"`
+	UserID     uint   `gorm:"not null;index"`
+	Device     string `gorm:"size:100"`
+	IP         string `gorm:"size:64"`
+	UserAgent  string `gorm:"size:255"`
+	LastUsedAt time.Time
+	ExpiresAt  time.Time `gorm:"not null"`
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+func (session0004) TableName() string { return "sessions" }
+
+func init() {
+	register(Migration{
+		Version: 4,
+		Name:    "create_sessions",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&session0004{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&session0004{})
+		},
+	})
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type loginThrottle0006 struct {
+	Key         string `gorm:"column:throttle_key;primaryKey;size:191"`
+	Failures    string `gorm:"type:text"`
+	LockedUntil *time.Time
+	UpdatedAt   time.Time
+}
+
+func (loginThrottle0006) TableName() string { return "login_throttles" }
+
+func init() {
+	register(Migration{
+		Version: 6,
+		Name:    "create_login_throttles",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&loginThrottle0006{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&loginThrottle0006{})
+		},
+	})
+}
@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type user0007 struct {
+	TOTPSecret   string `gorm:"column:totp_secret;size:64"`
+	TOTPEnabled  bool   `gorm:"column:totp_enabled;default:false"`
+	TOTPLastStep int64  `gorm:"column:totp_last_step;default:0"`
+}
+
+func (user0007) TableName() string { return "users" }
+
+type recoveryCode0007 struct {
+	ID        uint   `gorm:"primarykey"`
+	UserID    uint   `gorm:"not null;index"`
+	CodeHash  string `gorm:"size:64;not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (recoveryCode0007) TableName() string { return "recovery_codes" }
+
+func init() {
+	register(Migration{
+		Version: 7,
+		Name:    "add_users_totp",
+		Up: func(tx *gorm.DB) error {
+			for _, field := range []string{"TOTPSecret", "TOTPEnabled", "TOTPLastStep"} {
+				if err := tx.Migrator().AddColumn(&user0007{}, field); err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().CreateTable(&recoveryCode0007{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&recoveryCode0007{}); err != nil {
+				return err
+			}
+			for _, field := range []string{"TOTPSecret", "TOTPEnabled", "TOTPLastStep"} {
+				if err := tx.Migrator().DropColumn(&user0007{}, field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,52 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type user0008 struct {
+	Email string `gorm:"size:255;index"`
+}
+
+func (user0008) TableName() string { return "users" }
+
+type userIdentity0008 struct {
+	ID        uint   `gorm:"primarykey"`
+	UserID    uint   `gorm:"not null;index"`
+	Issuer    string `gorm:"size:255;not null;uniqueIndex:idx_identity_issuer_subject"`
+	Subject   string `gorm:"size:255;not null;uniqueIndex:idx_identity_issuer_subject"`
+	Email     string `gorm:"size:255"`
+	CreatedAt time.Time
+}
+
+func (userIdentity0008) TableName() string { return "user_identities" }
+
+func init() {
+	register(Migration{
+		Version: 8,
+		Name:    "create_user_identities",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&user0008{}, "Email"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().CreateIndex(&user0008{}, "Email"); err != nil {
+				return err
+			}
+			return tx.Migrator().CreateTable(&userIdentity0008{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&userIdentity0008{}); err != nil {
+				return err
+			}
+			// SQLite 删除列时会重建表，之后迁移的回滚可能已经连带删除了该索引
+			if tx.Migrator().HasIndex(&user0008{}, "Email") {
+				if err := tx.Migrator().DropIndex(&user0008{}, "Email"); err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().DropColumn(&user0008{}, "Email")
+		},
+	})
+}
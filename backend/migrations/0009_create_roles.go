@@ -0,0 +1,68 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type role0009 struct {
+	ID          uint   `gorm:"primarykey"`
+	Name        string `gorm:"size:50;uniqueIndex;not null"`
+	Description string `gorm:"size:255"`
+	Permissions string `gorm:"type:text"`
+	BuiltIn     bool   `gorm:"default:false"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (role0009) TableName() string { return "roles" }
+
+type user0009 struct {
+	Role string `gorm:"type:varchar(50);default:'user'"`
+}
+
+func (user0009) TableName() string { return "users" }
+
+type user0009Down struct {
+	Role string `gorm:"type:varchar(10);default:'user'"`
+}
+
+func (user0009Down) TableName() string { return "users" }
+
+func init() {
+	register(Migration{
+		Version: 9,
+		Name:    "create_roles",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().CreateTable(&role0009{}); err != nil {
+				return err
+			}
+			// 将原有的 admin/user 取值迁移为内置角色
+			builtIn := []role0009{
+				{Name: "admin", Description: "管理员，拥有全部权限", Permissions: `["*"]`, BuiltIn: true},
+				{Name: "user", Description: "普通用户", Permissions: `[]`, BuiltIn: true},
+			}
+			if err := tx.Create(&builtIn).Error; err != nil {
+				return err
+			}
+			if err := tx.Migrator().AlterColumn(&user0009{}, "Role"); err != nil {
+				return err
+			}
+			return tx.Table("users").
+				Where("role IS NULL OR role NOT IN ?", []string{"admin", "user"}).
+				Update("role", "user").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Table("users").
+				Where("role NOT IN ?", []string{"admin", "user"}).
+				Update("role", "user").Error; err != nil {
+				return err
+			}
+			if err := tx.Migrator().AlterColumn(&user0009Down{}, "Role"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropTable(&role0009{})
+		},
+	})
+}
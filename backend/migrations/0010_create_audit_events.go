@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type auditEvent0010 struct {
+	ID            uint      `gorm:"primarykey"`
+	ActorID       *uint     `gorm:"index"`
+	ActorUsername string    `gorm:"size:50"`
+	TargetUserID  *uint     `gorm:"index"`
+	Action        string    `gorm:"size:50;not null;index"`
+	Resource      string    `gorm:"size:50"`
+	ResourceID    string    `gorm:"size:64"`
+	Changes       string    `gorm:"type:text"`
+	IP            string    `gorm:"size:64"`
+	UserAgent     string    `gorm:"size:255"`
+	CreatedAt     time.Time `gorm:"index"`
+}
+
+func (auditEvent0010) TableName() string { return "audit_events" }
+
+func init() {
+	register(Migration{
+		Version: 10,
+		Name:    "create_audit_events",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&auditEvent0010{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&auditEvent0010{})
+		},
+	})
+}
@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type invite0011 struct {
+	ID        uint   `gorm:"primarykey"`
+	Code      string `gorm:"size:64;not null;uniqueIndex"`
+	Role      string `gorm:"size:50;not null"`
+	MaxUses   int    `gorm:"not null;default:1"`
+	Uses      int    `gorm:"not null;default:0"`
+	Note      string `gorm:"size:255"`
+	CreatedBy uint
+	ExpiresAt *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+func (invite0011) TableName() string { return "invites" }
+
+func init() {
+	register(Migration{
+		Version: 11,
+		Name:    "create_invites",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&invite0011{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&invite0011{})
+		},
+	})
+}
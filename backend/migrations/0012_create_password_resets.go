@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type passwordReset0012 struct {
+	ID        uint      `gorm:"primarykey"`
+	UserID    uint      `gorm:"not null;index"`
+	TokenHash string    `gorm:"size:64;not null;uniqueIndex"`
+	IP        string    `gorm:"size:64"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (passwordReset0012) TableName() string { return "password_resets" }
+
+func init() {
+	register(Migration{
+		Version: 12,
+		Name:    "create_password_resets",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&passwordReset0012{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&passwordReset0012{})
+		},
+	})
+}
@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+type user0013 struct {
+	DisplayName string `gorm:"size:50"`
+	Avatar      string `gorm:"size:255"`
+	Timezone    string `gorm:"size:64;default:'UTC'"`
+	Locale      string `gorm:"size:16;default:'zh-CN'"`
+	WeekStart   int    `gorm:"default:1"`
+}
+
+func (user0013) TableName() string { return "users" }
+
+var profileFields0013 = []string{"DisplayName", "Avatar", "Timezone", "Locale", "WeekStart"}
+
+func init() {
+	register(Migration{
+		Version: 13,
+		Name:    "add_users_profile",
+		Up: func(tx *gorm.DB) error {
+			for _, field := range profileFields0013 {
+				if err := tx.Migrator().AddColumn(&user0013{}, field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, field := range profileFields0013 {
+				if err := tx.Migrator().DropColumn(&user0013{}, field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
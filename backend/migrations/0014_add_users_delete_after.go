@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type user0014 struct {
+	DeleteAfter *time.Time
+}
+
+func (user0014) TableName() string { return "users" }
+
+func init() {
+	register(Migration{
+		Version: 14,
+		Name:    "add_users_delete_after",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().AddColumn(&user0014{}, "DeleteAfter")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&user0014{}, "DeleteAfter")
+		},
+	})
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type passwordHistory0015 struct {
+	ID        uint   `gorm:"primarykey"`
+	UserID    uint   `gorm:"not null;index"`
+	Hash      string `gorm:"size:255;not null"`
+	CreatedAt time.Time
+}
+
+func (passwordHistory0015) TableName() string { return "password_histories" }
+
+func init() {
+	register(Migration{
+		Version: 15,
+		Name:    "create_password_histories",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&passwordHistory0015{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&passwordHistory0015{})
+		},
+	})
+}
@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+type user0016 struct {
+	MustChangePassword bool `gorm:"default:false"`
+}
+
+func (user0016) TableName() string { return "users" }
+
+func init() {
+	register(Migration{
+		Version: 16,
+		Name:    "add_users_must_change_password",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().AddColumn(&user0016{}, "MustChangePassword")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&user0016{}, "MustChangePassword")
+		},
+	})
+}
@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type checklistItem0017 struct {
+	ID          uint   `gorm:"primarykey"`
+	TodoID      uint   `gorm:"not null;index"`
+	Title       string `gorm:"size:200;not null"`
+	Completed   bool   `gorm:"default:false"`
+	CompletedAt *time.Time
+	SortOrder   int `gorm:"not null;default:0"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (checklistItem0017) TableName() string { return "checklist_items" }
+
+func init() {
+	register(Migration{
+		Version: 17,
+		Name:    "create_checklist_items",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&checklistItem0017{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&checklistItem0017{})
+		},
+	})
+}
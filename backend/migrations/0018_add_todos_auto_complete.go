@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+type todo0018 struct {
+	AutoComplete bool `gorm:"default:false"`
+}
+
+func (todo0018) TableName() string { return "todos" }
+
+func init() {
+	register(Migration{
+		Version: 18,
+		Name:    "add_todos_auto_complete",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().AddColumn(&todo0018{}, "AutoComplete")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&todo0018{}, "AutoComplete")
+		},
+	})
+}
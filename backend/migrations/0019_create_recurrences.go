@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type recurrence0019 struct {
+	ID             uint      `gorm:"primarykey"`
+	UserID         uint      `gorm:"not null;index"`
+	RRule          string    `gorm:"column:rrule;size:255;not null"`
+	DTStart        time.Time `gorm:"column:dtstart;not null"`
+	ExDates        string    `gorm:"column:exdates;type:text"`
+	LastOccurrence *time.Time
+	Title          string `gorm:"not null"`
+	Description    string
+	Tags           string `gorm:"type:text"`
+	IsLongTerm     bool
+	IsStarred      bool
+	AutoComplete   bool
+	Checklist      string `gorm:"type:text"`
+	Duration       *int64
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (recurrence0019) TableName() string { return "recurrences" }
+
+func init() {
+	register(Migration{
+		Version: 19,
+		Name:    "create_recurrences",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&recurrence0019{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&recurrence0019{})
+		},
+	})
+}
@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type todo0020 struct {
+	RecurrenceID *uint      `gorm:"uniqueIndex:idx_todos_occurrence"`
+	OccurrenceAt *time.Time `gorm:"uniqueIndex:idx_todos_occurrence"`
+}
+
+func (todo0020) TableName() string { return "todos" }
+
+func init() {
+	register(Migration{
+		Version: 20,
+		Name:    "add_todos_recurrence",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			for _, field := range []string{"RecurrenceID", "OccurrenceAt"} {
+				if err := m.AddColumn(&todo0020{}, field); err != nil {
+					return err
+				}
+			}
+			// 同一系列的同一次只能生成一条，避免并发完成时重复生成
+			return m.CreateIndex(&todo0020{}, "idx_todos_occurrence")
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			// SQLite 删除列时会重建表，之后迁移的回滚可能已经连带删除了该索引
+			if m.HasIndex(&todo0020{}, "idx_todos_occurrence") {
+				if err := m.DropIndex(&todo0020{}, "idx_todos_occurrence"); err != nil {
+					return err
+				}
+			}
+			for _, field := range []string{"OccurrenceAt", "RecurrenceID"} {
+				if err := m.DropColumn(&todo0020{}, field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type reminder0021 struct {
+	ID            uint `gorm:"primarykey"`
+	TodoID        uint `gorm:"not null;index"`
+	UserID        uint `gorm:"not null;index"`
+	RemindAt      *time.Time
+	Anchor        string `gorm:"size:10"`
+	OffsetMinutes int
+	Channels      string     `gorm:"type:text"`
+	FireAt        *time.Time `gorm:"index"`
+	Status        string     `gorm:"size:20;not null;default:pending;index"`
+	Delivered     string     `gorm:"type:text"`
+	Attempts      int        `gorm:"not null;default:0"`
+	LastError     string     `gorm:"size:500"`
+	SentAt        *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (reminder0021) TableName() string { return "reminders" }
+
+func init() {
+	register(Migration{
+		Version: 21,
+		Name:    "create_reminders",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&reminder0021{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&reminder0021{})
+		},
+	})
+}
@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type notification0022 struct {
+	ID         uint `gorm:"primarykey"`
+	UserID     uint `gorm:"not null;index"`
+	TodoID     *uint
+	ReminderID *uint
+	Title      string `gorm:"size:255;not null"`
+	Body       string `gorm:"type:text"`
+	ReadAt     *time.Time
+	CreatedAt  time.Time
+}
+
+func (notification0022) TableName() string { return "notifications" }
+
+type notificationSettings0022 struct {
+	UserID          uint   `gorm:"primarykey;autoIncrement:false"`
+	WebhookURL      string `gorm:"size:500"`
+	WebhookSecret   string `gorm:"size:255"`
+	NtfyTopic       string `gorm:"size:255"`
+	DefaultChannels string `gorm:"type:text"`
+	UpdatedAt       time.Time
+}
+
+func (notificationSettings0022) TableName() string { return "notification_settings" }
+
+func init() {
+	register(Migration{
+		Version: 22,
+		Name:    "create_notifications",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&notification0022{}, &notificationSettings0022{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&notificationSettings0022{}, &notification0022{})
+		},
+	})
+}
@@ -0,0 +1,67 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type project0023 struct {
+	ID        uint   `gorm:"primarykey"`
+	UserID    uint   `gorm:"not null;uniqueIndex:idx_projects_user_name"`
+	Name      string `gorm:"size:100;not null;uniqueIndex:idx_projects_user_name"`
+	Color     string `gorm:"size:7"`
+	Icon      string `gorm:"size:50"`
+	Archived  bool   `gorm:"default:false"`
+	SortOrder int    `gorm:"not null;default:0"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (project0023) TableName() string { return "projects" }
+
+type todo0023 struct {
+	ProjectID *uint `gorm:"index"`
+}
+
+func (todo0023) TableName() string { return "todos" }
+
+type recurrence0023 struct {
+	ProjectID *uint
+}
+
+func (recurrence0023) TableName() string { return "recurrences" }
+
+func init() {
+	register(Migration{
+		Version: 23,
+		Name:    "create_projects",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if err := m.CreateTable(&project0023{}); err != nil {
+				return err
+			}
+			if err := m.AddColumn(&todo0023{}, "ProjectID"); err != nil {
+				return err
+			}
+			if err := m.CreateIndex(&todo0023{}, "ProjectID"); err != nil {
+				return err
+			}
+			// 重复任务生成新的一次时沿用所属项目
+			return m.AddColumn(&recurrence0023{}, "ProjectID")
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if err := m.DropColumn(&recurrence0023{}, "ProjectID"); err != nil {
+				return err
+			}
+			if err := m.DropIndex(&todo0023{}, "ProjectID"); err != nil {
+				return err
+			}
+			if err := m.DropColumn(&todo0023{}, "ProjectID"); err != nil {
+				return err
+			}
+			return m.DropTable(&project0023{})
+		},
+	})
+}
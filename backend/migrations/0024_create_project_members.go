@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type projectMember0024 struct {
+	ID        uint   `gorm:"primarykey"`
+	ProjectID uint   `gorm:"not null;uniqueIndex:idx_project_members_user"`
+	UserID    uint   `gorm:"not null;uniqueIndex:idx_project_members_user;index"`
+	Role      string `gorm:"size:10;not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (projectMember0024) TableName() string { return "project_members" }
+
+type projectInvitation0024 struct {
+	ID        uint   `gorm:"primarykey"`
+	ProjectID uint   `gorm:"not null;uniqueIndex:idx_project_invitations_invitee"`
+	InviteeID uint   `gorm:"not null;uniqueIndex:idx_project_invitations_invitee;index"`
+	InviterID uint   `gorm:"not null"`
+	Role      string `gorm:"size:10;not null"`
+	CreatedAt time.Time
+}
+
+func (projectInvitation0024) TableName() string { return "project_invitations" }
+
+func init() {
+	register(Migration{
+		Version: 24,
+		Name:    "create_project_members",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if err := m.CreateTable(&projectMember0024{}, &projectInvitation0024{}); err != nil {
+				return err
+			}
+			// 已有项目的创建者成为所有者
+			return tx.Exec("INSERT INTO project_members (project_id, user_id, role, created_at, updated_at) " +
+				"SELECT id, user_id, 'owner', created_at, updated_at FROM projects").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&projectInvitation0024{}, &projectMember0024{})
+		},
+	})
+}
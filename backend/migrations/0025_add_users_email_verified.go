@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type user0025 struct {
+	EmailVerified bool `gorm:"default:false"`
+}
+
+func (user0025) TableName() string { return "users" }
+
+type emailVerification0025 struct {
+	ID        uint      `gorm:"primarykey"`
+	UserID    uint      `gorm:"not null;index"`
+	Email     string    `gorm:"size:255;not null"`
+	TokenHash string    `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (emailVerification0025) TableName() string { return "email_verifications" }
+
+func init() {
+	register(Migration{
+		Version: 25,
+		Name:    "add_users_email_verified",
+		Up: func(tx *gorm.DB) error {
+			// 已有用户的邮箱均未经验证，需要重新验证
+			if err := tx.Migrator().AddColumn(&user0025{}, "EmailVerified"); err != nil {
+				return err
+			}
+			return tx.Migrator().CreateTable(&emailVerification0025{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&emailVerification0025{}); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&user0025{}, "EmailVerified")
+		},
+	})
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+    "time"
+)
+
+const (
+    // APITokenPrefix 个人访问令牌前缀，用于与会话JWT区分
+    APITokenPrefix = "tdl_"
+
+    ScopeTodosRead     = "todos:read"
+    ScopeTodosWrite    = "todos:write"
+    ScopeAIProcess     = "ai:process"
+    ScopeProjectsAdmin = "projects:admin" // 删除项目、管理成员和邀请，与待办事项读写分开授予
+)
+
+// APIToken 个人访问令牌，供脚本和集成使用
+type APIToken struct {
+    ID         uint        `json:"id" gorm:"primarykey"`
+    UserID     uint        `json:"user_id" gorm:"not null;index"`
+    Name       string      `json:"name" gorm:"size:100;not null"`
+    Prefix     string      `json:"prefix" gorm:"size:16;not null"` // 令牌前几位，便于用户辨认
+    TokenHash  string      `json:"-" gorm:"size:64;not null;uniqueIndex"`
+    Scopes     StringSlice `json:"scopes" gorm:"type:text"`
+    ExpiresAt  *CustomTime `json:"expires_at"`
+    LastUsedAt *CustomTime `json:"last_used_at"`
+    RevokedAt  *CustomTime `json:"revoked_at,omitempty"`
+    CreatedAt  CustomTime  `json:"created_at"`
+}
+
+// IsValid 令牌未被吊销且未过期
+func (t *APIToken) IsValid() bool {
+    if t.RevokedAt != nil {
+        return false
+    }
+    return t.ExpiresAt == nil || time.Now().Before(t.ExpiresAt.Time)
+}
+
+// HasScope 判断令牌是否包含指定权限范围
+func (t *APIToken) HasScope(scope string) bool {
+    for _, s := range t.Scopes {
+        if s == scope {
+            return true
+        }
+    }
+    return false
+}
+
+type CreateAPITokenRequest struct {
+    Name          string   `json:"name" binding:"required,max=100"`
+    Scopes        []string `json:"scopes" binding:"required,min=1,dive,oneof=todos:read todos:write ai:process projects:admin"`
+    ExpiresInDays int      `json:"expires_in_days" binding:"min=0,max=3650"` // 0 表示永不过期
+}
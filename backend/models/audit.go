@@ -0,0 +1,106 @@
+package models
+
+import (
+    "database/sql/driver"
+    "encoding/json"
+    "fmt"
+)
+
+// 审计事件动作，格式为 "资源.操作"
+const (
+    AuditRegister       = "auth.register"
+    AuditLogin          = "auth.login"
+    AuditLoginFailed    = "auth.login_failed"
+    AuditPasswordChange = "auth.password_change"
+    AuditPasswordForgot = "auth.password_forgot"
+    AuditPasswordReset  = "auth.password_reset"
+    AuditEmailChange    = "auth.email_change"
+    AuditEmailVerify    = "auth.email_verify"
+    AuditOIDCLink       = "auth.oidc_link"
+    AuditProfileUpdate  = "auth.profile_update"
+    AuditDataExport     = "auth.data_export"
+    AuditDeleteRequest  = "auth.delete_request"
+    AuditDeleteCancel   = "auth.delete_cancel"
+    AuditMFAEnable      = "auth.mfa_enable"
+    AuditMFADisable     = "auth.mfa_disable"
+
+    AuditUserActivate       = "user.activate"
+    AuditUserBlock          = "user.block"
+    AuditUserRoleChange     = "user.role_change"
+    AuditUserPasswordReset  = "user.password_reset"
+    AuditUserPasswordExpire = "user.password_expire"
+    AuditUserDelete         = "user.delete"
+    AuditUserPurge          = "user.purge"
+    AuditUserSessionsRevoke = "user.sessions_revoke"
+    AuditUserLockoutClear   = "user.lockout_clear"
+    AuditUserMFAReset       = "user.mfa_reset"
+    AuditUserImpersonate    = "user.impersonate"
+
+    AuditImpersonationRequest = "impersonation.request"
+    AuditImpersonationBlocked = "impersonation.blocked"
+
+    AuditInviteCreate = "invite.create"
+    AuditInviteRevoke = "invite.revoke"
+
+    AuditRoleCreate = "role.create"
+    AuditRoleUpdate = "role.update"
+    AuditRoleDelete = "role.delete"
+
+    AuditTodoList   = "todo.list"
+    AuditTodoRead   = "todo.read"
+    AuditTodoUpdate = "todo.update"
+    AuditTodoDelete = "todo.delete"
+)
+
+// AuditEvent 审计事件，只追加不修改
+type AuditEvent struct {
+    ID            uint         `json:"id" gorm:"primarykey"`
+    ActorID       *uint        `json:"actor_id" gorm:"index"`
+    ActorUsername string       `json:"actor_username" gorm:"size:50"`
+    TargetUserID  *uint        `json:"target_user_id" gorm:"index"`
+    Action        string       `json:"action" gorm:"size:50;not null;index"`
+    Resource      string       `json:"resource,omitempty" gorm:"size:50"`
+    ResourceID    string       `json:"resource_id,omitempty" gorm:"size:64"`
+    Changes       AuditChanges `json:"changes,omitempty" gorm:"type:text"`
+    IP            string       `json:"ip" gorm:"size:64"`
+    UserAgent     string       `json:"user_agent" gorm:"size:255"`
+    CreatedAt     CustomTime   `json:"created_at" gorm:"index"`
+}
+
+// AuditChange 单个字段修改前后的值
+type AuditChange struct {
+    Before interface{} `json:"before"`
+    After  interface{} `json:"after"`
+}
+
+// AuditChanges 字段名到修改内容的映射
+type AuditChanges map[string]AuditChange
+
+// Value 实现 driver.Valuer 接口
+func (c AuditChanges) Value() (driver.Value, error) {
+    if len(c) == 0 {
+        return nil, nil
+    }
+    bytes, err := json.Marshal(c)
+    if err != nil {
+        return nil, err
+    }
+    return string(bytes), nil
+}
+
+// Scan 实现 sql.Scanner 接口
+func (c *AuditChanges) Scan(value interface{}) error {
+    var bytes []byte
+    switch v := value.(type) {
+    case nil:
+        *c = nil
+        return nil
+    case string:
+        bytes = []byte(v)
+    case []byte:
+        bytes = v
+    default:
+        return fmt.Errorf("failed to scan AuditChanges value: %v", value)
+    }
+    return json.Unmarshal(bytes, c)
+}
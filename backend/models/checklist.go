@@ -0,0 +1,45 @@
+package models
+
+import (
+    "time"
+
+    "gorm.io/gorm"
+)
+
+// ChecklistItem 待办事项的检查项（子任务），按 SortOrder 排序
+type ChecklistItem struct {
+    ID          uint        `json:"id" gorm:"primarykey"`
+    TodoID      uint        `json:"todo_id" gorm:"not null;index"`
+    Title       string      `json:"title" gorm:"size:200;not null"`
+    Completed   bool        `json:"completed" gorm:"default:false"`
+    CompletedAt *CustomTime `json:"completed_at,omitempty"`
+    SortOrder   int         `json:"sort_order" gorm:"not null;default:0"`
+    CreatedAt   CustomTime  `json:"created_at"`
+    UpdatedAt   CustomTime  `json:"updated_at"`
+}
+
+type CreateChecklistItemRequest struct {
+    Title     string `json:"title" binding:"required,max=200"`
+    Completed bool   `json:"completed"`
+    Position  *int   `json:"position" binding:"omitempty,min=0"` // 插入位置（从0开始），默认追加到末尾
+}
+
+type UpdateChecklistItemRequest struct {
+    Title     *string `json:"title" binding:"omitempty,min=1,max=200"`
+    Completed *bool   `json:"completed"`
+}
+
+type ReorderChecklistRequest struct {
+    IDs []uint `json:"ids" binding:"required"` // 全部检查项ID，按新的顺序排列
+}
+
+// BeforeSave 勾选时记录完成时间，取消勾选时清空
+func (i *ChecklistItem) BeforeSave(tx *gorm.DB) error {
+    if i.Completed && i.CompletedAt == nil {
+        now := CustomTime{time.Now().UTC()}
+        i.CompletedAt = &now
+    } else if !i.Completed {
+        i.CompletedAt = nil
+    }
+    return nil
+}
@@ -0,0 +1,11 @@
+package models
+
+// UserIdentity 用户在外部身份提供方（OIDC）的身份，(Issuer, Subject) 唯一
+type UserIdentity struct {
+    ID        uint       `json:"id" gorm:"primarykey"`
+    UserID    uint       `json:"user_id" gorm:"not null;index"`
+    Issuer    string     `json:"issuer" gorm:"size:255;not null;uniqueIndex:idx_identity_issuer_subject"`
+    Subject   string     `json:"subject" gorm:"size:255;not null;uniqueIndex:idx_identity_issuer_subject"`
+    Email     string     `json:"email" gorm:"size:255"`
+    CreatedAt CustomTime `json:"created_at"`
+}
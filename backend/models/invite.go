@@ -0,0 +1,37 @@
+package models
+
+import (
+    "time"
+)
+
+// Invite 邀请码，邀请制注册时使用
+type Invite struct {
+    ID        uint        `json:"id" gorm:"primarykey"`
+    Code      string      `json:"code" gorm:"size:64;not null;uniqueIndex"`
+    Role      string      `json:"role" gorm:"size:50;not null"`       // 使用邀请码注册的用户获得的角色
+    MaxUses   int         `json:"max_uses" gorm:"not null;default:1"` // 0 表示不限次数
+    Uses      int         `json:"uses" gorm:"not null;default:0"`
+    Note      string      `json:"note" gorm:"size:255"`
+    CreatedBy uint        `json:"created_by"`
+    ExpiresAt *CustomTime `json:"expires_at"`
+    RevokedAt *CustomTime `json:"revoked_at,omitempty"`
+    CreatedAt CustomTime  `json:"created_at"`
+}
+
+// IsValid 邀请码未被吊销、未过期且仍有剩余次数
+func (i *Invite) IsValid() bool {
+    if i.RevokedAt != nil {
+        return false
+    }
+    if i.ExpiresAt != nil && !time.Now().Before(i.ExpiresAt.Time) {
+        return false
+    }
+    return i.MaxUses == 0 || i.Uses < i.MaxUses
+}
+
+type CreateInviteRequest struct {
+    Role          string `json:"role" binding:"max=50"`                        // 为空时使用 user
+    MaxUses       *int   `json:"max_uses" binding:"omitempty,min=0,max=10000"` // 为空时为一次性邀请码，0 表示不限次数
+    ExpiresInDays int    `json:"expires_in_days" binding:"min=0,max=365"`      // 0 表示永不过期
+    Note          string `json:"note" binding:"max=255"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import (
+    "time"
+)
+
+// RecoveryCode 两步验证的一次性恢复码
+type RecoveryCode struct {
+    ID        uint   `gorm:"primarykey"`
+    UserID    uint   `gorm:"not null;index"`
+    CodeHash  string `gorm:"size:64;not null"`
+    UsedAt    *time.Time
+    CreatedAt time.Time
+}
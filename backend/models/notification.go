@@ -0,0 +1,34 @@
+package models
+
+// Notification 站内信
+type Notification struct {
+    ID         uint        `json:"id" gorm:"primarykey"`
+    UserID     uint        `json:"-" gorm:"not null;index"`
+    TodoID     *uint       `json:"todo_id,omitempty"`
+    ReminderID *uint       `json:"reminder_id,omitempty"`
+    Title      string      `json:"title" gorm:"size:255;not null"`
+    Body       string      `json:"body" gorm:"type:text"`
+    ReadAt     *CustomTime `json:"read_at"`
+    CreatedAt  CustomTime  `json:"created_at"`
+}
+
+// NotificationSettings 用户的通知设置，没有记录时使用默认值（只发送站内信）
+type NotificationSettings struct {
+    UserID          uint        `json:"-" gorm:"primarykey;autoIncrement:false"`
+    WebhookURL      string      `json:"webhook_url" gorm:"size:500"`
+    WebhookSecret   string      `json:"-" gorm:"size:255"` // 用于签名 Webhook 请求体，不返回给前端
+    NtfyTopic       string      `json:"ntfy_topic" gorm:"size:255"`
+    DefaultChannels StringSlice `json:"default_channels" gorm:"type:text"` // 创建提醒未指定渠道时使用
+    UpdatedAt       CustomTime  `json:"updated_at"`
+}
+
+type UpdateNotificationSettingsRequest struct {
+    WebhookURL      *string  `json:"webhook_url" binding:"omitempty,max=500"`
+    WebhookSecret   *string  `json:"webhook_secret" binding:"omitempty,max=255"`
+    NtfyTopic       *string  `json:"ntfy_topic" binding:"omitempty,max=64"`
+    DefaultChannels []string `json:"default_channels" binding:"omitempty,min=1,max=4,dive,oneof=in_app email webhook ntfy"`
+}
+
+type UpdateNotificationRequest struct {
+    Read *bool `json:"read" binding:"required"`
+}
@@ -0,0 +1,9 @@
+package models
+
+// PasswordHistory 用户用过的密码哈希，用于禁止重复使用最近的密码
+type PasswordHistory struct {
+    ID        uint       `gorm:"primarykey"`
+    UserID    uint       `gorm:"not null;index"`
+    Hash      string     `gorm:"size:255;not null"`
+    CreatedAt CustomTime
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+    "time"
+)
+
+// PasswordReset 找回密码令牌，只保存哈希，使用一次后失效
+type PasswordReset struct {
+    ID        uint        `json:"id" gorm:"primarykey"`
+    UserID    uint        `json:"user_id" gorm:"not null;index"`
+    TokenHash string      `json:"-" gorm:"size:64;not null;uniqueIndex"`
+    IP        string      `json:"ip" gorm:"size:64"`
+    ExpiresAt time.Time   `json:"-" gorm:"not null"`
+    UsedAt    *CustomTime `json:"used_at,omitempty"`
+    CreatedAt CustomTime  `json:"created_at"`
+}
+
+// EmailVerification 邮箱验证令牌，只保存哈希，绑定签发时的邮箱，使用一次后失效
+type EmailVerification struct {
+    ID        uint        `json:"id" gorm:"primarykey"`
+    UserID    uint        `json:"user_id" gorm:"not null;index"`
+    Email     string      `json:"email" gorm:"size:255;not null"`
+    TokenHash string      `json:"-" gorm:"size:64;not null;uniqueIndex"`
+    ExpiresAt time.Time   `json:"-" gorm:"not null"`
+    UsedAt    *CustomTime `json:"used_at,omitempty"`
+    CreatedAt CustomTime  `json:"created_at"`
+}
+
+type ForgotPasswordRequest struct {
+    Email string `json:"email" binding:"required,email,max=255"`
+}
+
+type ResetPasswordRequest struct {
+    Token       string `json:"token" binding:"required"`
+    NewPassword string `json:"new_password" binding:"required"`
+}
+
+type UpdateEmailRequest struct {
+    Email    string `json:"email" binding:"required,email,max=255"`
+    Password string `json:"password" binding:"required"`
+}
+
+type ResendEmailVerificationRequest struct {
+    Email string `json:"email" binding:"required,email,max=255"`
+}
+
+type VerifyEmailRequest struct {
+    Token string `json:"token" binding:"required"`
+}
@@ -0,0 +1,91 @@
+package models
+
+// Project 项目（清单），用于给待办事项分组，按 SortOrder 排序。项目可以共享给其他用户，
+// 访问权限由 ProjectMember 决定，UserID 只记录创建者
+type Project struct {
+    ID        uint       `json:"id" gorm:"primarykey"`
+    UserID    uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_projects_user_name"`
+    Name      string     `json:"name" gorm:"size:100;not null;uniqueIndex:idx_projects_user_name"`
+    Color     string     `json:"color" gorm:"size:7"` // 十六进制颜色，如 #3b82f6
+    Icon      string     `json:"icon" gorm:"size:50"`
+    Archived  bool       `json:"archived" gorm:"default:false"` // 已归档的项目不能再添加待办事项
+    SortOrder int        `json:"sort_order" gorm:"not null;default:0"`
+    TodoCount int64      `json:"todo_count" gorm:"-"` // 未完成的待办事项数量
+    Role      string     `json:"role,omitempty" gorm:"-"` // 当前用户在项目中的角色
+    CreatedAt CustomTime `json:"created_at"`
+    UpdatedAt CustomTime `json:"updated_at"`
+}
+
+type CreateProjectRequest struct {
+    Name      string `json:"name" binding:"required,max=100"`
+    Color     string `json:"color" binding:"omitempty,hexcolor"`
+    Icon      string `json:"icon" binding:"max=50"`
+    SortOrder *int   `json:"sort_order"` // 可选，默认排在最后
+}
+
+type UpdateProjectRequest struct {
+    Name      *string `json:"name" binding:"omitempty,min=1,max=100"`
+    Color     *string `json:"color" binding:"omitempty,max=7"` // 空字符串表示清除
+    Icon      *string `json:"icon" binding:"omitempty,max=50"`
+    Archived  *bool   `json:"archived"`
+    SortOrder *int    `json:"sort_order"`
+}
+
+// 删除项目时对其中待办事项的处理方式
+const (
+    ProjectTodosKeep   = "keep"   // 保留待办事项，移出项目
+    ProjectTodosMove   = "move"   // 移动到另一个项目
+    ProjectTodosDelete = "delete" // 一并删除
+)
+
+// 项目成员角色，权限依次递增
+const (
+    ProjectViewer = "viewer" // 查看项目和其中的待办事项
+    ProjectEditor = "editor" // 另外可以添加、修改和删除待办事项
+    ProjectOwner  = "owner"  // 另外可以修改和删除项目、管理成员和邀请
+)
+
+// projectRoleRanks 角色的权限等级
+var projectRoleRanks = map[string]int{
+    ProjectViewer: 1,
+    ProjectEditor: 2,
+    ProjectOwner:  3,
+}
+
+// ProjectRoleAllows 判断角色是否拥有 required 角色的全部权限
+func ProjectRoleAllows(role, required string) bool {
+    return projectRoleRanks[role] > 0 && projectRoleRanks[role] >= projectRoleRanks[required]
+}
+
+// ProjectMember 项目成员，创建项目时创建者成为所有者
+type ProjectMember struct {
+    ID        uint       `json:"-" gorm:"primarykey"`
+    ProjectID uint       `json:"project_id" gorm:"not null;uniqueIndex:idx_project_members_user"`
+    UserID    uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_project_members_user;index"`
+    Role      string     `json:"role" gorm:"size:10;not null"`
+    Username  string     `json:"username" gorm:"-"`
+    CreatedAt CustomTime `json:"created_at"`
+    UpdatedAt CustomTime `json:"updated_at"`
+}
+
+// ProjectInvitation 项目邀请，被邀请的用户接受后成为成员，接受或拒绝后删除
+type ProjectInvitation struct {
+    ID          uint       `json:"id" gorm:"primarykey"`
+    ProjectID   uint       `json:"project_id" gorm:"not null;uniqueIndex:idx_project_invitations_invitee"`
+    InviteeID   uint       `json:"invitee_id" gorm:"not null;uniqueIndex:idx_project_invitations_invitee;index"`
+    InviterID   uint       `json:"inviter_id" gorm:"not null"`
+    Role        string     `json:"role" gorm:"size:10;not null"`
+    ProjectName string     `json:"project_name" gorm:"-"`
+    InviteeName string     `json:"invitee_name" gorm:"-"`
+    InviterName string     `json:"inviter_name" gorm:"-"`
+    CreatedAt   CustomTime `json:"created_at"`
+}
+
+type CreateProjectInvitationRequest struct {
+    Username string `json:"username" binding:"required"`
+    Role     string `json:"role" binding:"required,oneof=viewer editor owner"`
+}
+
+type UpdateProjectMemberRequest struct {
+    Role string `json:"role" binding:"required,oneof=viewer editor owner"`
+}
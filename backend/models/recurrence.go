@@ -0,0 +1,97 @@
+package models
+
+import (
+    "time"
+)
+
+// Recurrence 重复任务系列。每一次重复都是一条独立的待办事项，按模板字段生成，
+// 待办事项的 OccurrenceAt 记录它在规则中的原始开始时间
+type Recurrence struct {
+    ID     uint   `json:"id" gorm:"primarykey"`
+    UserID uint   `json:"-" gorm:"not null;index"`
+    RRule  string `json:"rrule" gorm:"column:rrule;size:255;not null"` // RFC 5545 重复规则，如 FREQ=WEEKLY;BYDAY=MO,WE
+    // DTStart 规则的开始时间，即系列第一次的原始开始时间
+    DTStart CustomTime `json:"dtstart" gorm:"column:dtstart;not null"`
+    // ExDates 跳过的日期（原始开始时间，UTC，TimeFormat 格式）
+    ExDates StringSlice `json:"exdates" gorm:"column:exdates;type:text"`
+    // LastOccurrence 已生成的最后一次的原始开始时间，之后的日期尚未生成
+    LastOccurrence CustomTime `json:"-"`
+
+    // 以下为生成新的一次时使用的模板
+    Title        string      `json:"-" gorm:"not null"`
+    Description  string      `json:"-"`
+    Tags         StringSlice `json:"-" gorm:"type:text"`
+    ProjectID    *uint       `json:"-"`
+    IsLongTerm   bool        `json:"-"`
+    IsStarred    bool        `json:"-"`
+    AutoComplete bool        `json:"-"`
+    Checklist    StringSlice `json:"-" gorm:"type:text"` // 检查项标题，生成时均为未完成
+    Duration     *int64      `json:"-"`                  // 结束时间相对开始时间的秒数，为空时按默认规则设置结束时间
+
+    CreatedAt CustomTime `json:"created_at"`
+    UpdatedAt CustomTime `json:"updated_at"`
+}
+
+// SetTemplate 用待办事项的当前内容更新模板
+func (r *Recurrence) SetTemplate(t *Todo) {
+    r.Title = t.Title
+    r.Description = t.Description
+    r.Tags = t.Tags
+    r.ProjectID = t.ProjectID
+    r.IsLongTerm = t.IsLongTerm
+    r.IsStarred = t.IsStarred
+    r.AutoComplete = t.AutoComplete
+    r.Checklist = StringSlice{}
+    for _, item := range t.Checklist {
+        r.Checklist = append(r.Checklist, item.Title)
+    }
+    r.Duration = nil
+    if t.EndTime != nil && !t.StartTime.IsZero() {
+        seconds := int64(t.EndTime.Sub(t.StartTime.Time) / time.Second)
+        r.Duration = &seconds
+    }
+}
+
+// NewOccurrence 按模板创建原始开始时间为 start 的一次重复
+func (r *Recurrence) NewOccurrence(start time.Time) *Todo {
+    start = start.UTC()
+    todo := &Todo{
+        Title:        r.Title,
+        Description:  r.Description,
+        UserID:       r.UserID,
+        Tags:         append(StringSlice{}, r.Tags...),
+        ProjectID:    r.ProjectID,
+        IsLongTerm:   r.IsLongTerm,
+        IsStarred:    r.IsStarred,
+        AutoComplete: r.AutoComplete,
+        StartTime:    CustomTime{start},
+        RecurrenceID: &r.ID,
+        OccurrenceAt: &CustomTime{start},
+    }
+    if r.Duration != nil {
+        todo.EndTime = &CustomTime{start.Add(time.Duration(*r.Duration) * time.Second)}
+    }
+    for i, title := range r.Checklist {
+        todo.Checklist = append(todo.Checklist, ChecklistItem{Title: title, SortOrder: i})
+    }
+    return todo
+}
+
+// IsExcluded 判断原始开始时间为 t 的一次是否已被跳过
+func (r *Recurrence) IsExcluded(t time.Time) bool {
+    key := t.UTC().Format(TimeFormat)
+    for _, d := range r.ExDates {
+        if d == key {
+            return true
+        }
+    }
+    return false
+}
+
+// SetExDates 设置跳过的日期
+func (r *Recurrence) SetExDates(dates []CustomTime) {
+    r.ExDates = StringSlice{}
+    for _, d := range dates {
+        r.ExDates = append(r.ExDates, d.UTC().Format(TimeFormat))
+    }
+}
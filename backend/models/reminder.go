@@ -0,0 +1,78 @@
+package models
+
+import (
+    "time"
+)
+
+// 提醒的发送状态
+const (
+    ReminderPending = "pending" // 等待到期
+    ReminderSending = "sending" // 正在发送
+    ReminderSent    = "sent"    // 已发送
+    ReminderFailed  = "failed"  // 多次重试后仍发送失败
+    ReminderSkipped = "skipped" // 待办事项已完成或超过最长延迟，不再发送
+)
+
+// 相对提醒的基准时间
+const (
+    ReminderAnchorStart = "start"
+    ReminderAnchorEnd   = "end"
+)
+
+// Reminder 待办事项的提醒，RemindAt 为绝对时间；Anchor 不为空时为相对开始或结束时间的提醒。
+// FireAt 为计算出的发送时间，修改待办事项的时间后重新计算，没有可用的基准时间时为空
+type Reminder struct {
+    ID            uint        `json:"id" gorm:"primarykey"`
+    TodoID        uint        `json:"todo_id" gorm:"not null;index"`
+    UserID        uint        `json:"-" gorm:"not null;index"`
+    RemindAt      *CustomTime `json:"remind_at,omitempty"`
+    Anchor        string      `json:"anchor,omitempty" gorm:"size:10"`
+    OffsetMinutes int         `json:"offset_minutes"` // 相对基准时间的分钟数，负数表示之前
+    Channels      StringSlice `json:"channels" gorm:"type:text"`
+    FireAt        *CustomTime `json:"fire_at" gorm:"index"`
+    Status        string      `json:"status" gorm:"size:20;not null;default:pending;index"`
+    Delivered     StringSlice `json:"delivered" gorm:"type:text"` // 已发送成功的渠道，重试时跳过
+    Attempts      int         `json:"attempts" gorm:"not null;default:0"`
+    LastError     string      `json:"last_error,omitempty" gorm:"size:500"`
+    SentAt        *CustomTime `json:"sent_at,omitempty"`
+    CreatedAt     CustomTime  `json:"created_at"`
+    UpdatedAt     CustomTime  `json:"updated_at"`
+}
+
+// Schedule 按待办事项的时间计算发送时间
+func (r *Reminder) Schedule(todo *Todo) {
+    var base *time.Time
+    switch r.Anchor {
+    case ReminderAnchorStart:
+        if !todo.StartTime.IsZero() {
+            base = &todo.StartTime.Time
+        }
+    case ReminderAnchorEnd:
+        if todo.EndTime != nil {
+            base = &todo.EndTime.Time
+        }
+    default:
+        r.FireAt = r.RemindAt
+        return
+    }
+    if base == nil {
+        r.FireAt = nil
+        return
+    }
+    r.FireAt = &CustomTime{base.Add(time.Duration(r.OffsetMinutes) * time.Minute).UTC()}
+}
+
+// CreateReminderRequest remind_at 与 anchor 二选一
+type CreateReminderRequest struct {
+    RemindAt      *CustomTime `json:"remind_at"`
+    Anchor        string      `json:"anchor" binding:"omitempty,oneof=start end"`
+    OffsetMinutes int         `json:"offset_minutes" binding:"min=-525600,max=525600"`
+    Channels      []string    `json:"channels" binding:"max=4,dive,oneof=in_app email webhook ntfy"` // 为空时使用通知设置中的默认渠道
+}
+
+type UpdateReminderRequest struct {
+    RemindAt      *CustomTime `json:"remind_at"`
+    Anchor        *string     `json:"anchor" binding:"omitempty,oneof=start end"`
+    OffsetMinutes *int        `json:"offset_minutes" binding:"omitempty,min=-525600,max=525600"`
+    Channels      []string    `json:"channels" binding:"omitempty,max=4,dive,oneof=in_app email webhook ntfy"`
+}
@@ -0,0 +1,96 @@
+package models
+
+// 权限名称，格式为 "资源.操作"
+const (
+    PermUsersRead           = "users.read"
+    PermUsersActivate       = "users.activate"
+    PermUsersBlock          = "users.block"
+    PermUsersDelete         = "users.delete"
+    PermUsersResetPassword  = "users.reset_password"
+    PermUsersAssignRole     = "users.assign_role"
+    PermUsersInvite         = "users.invite"
+    PermUsersManageSecurity = "users.manage_security" // 吊销会话、解除锁定、重置两步验证
+    PermUsersImpersonate    = "users.impersonate"     // 以用户身份查看系统（模拟登录）
+    PermRolesManage         = "roles.manage"
+    PermTodosReadAny        = "todos.read_any"
+    PermTodosWriteAny       = "todos.write_any"
+    PermAuditRead           = "audit.read"
+
+    // PermAll 拥有全部权限，仅内置管理员角色使用
+    PermAll = "*"
+)
+
+// Permissions 所有可分配的权限及说明
+var Permissions = []PermissionInfo{
+    {PermUsersRead, "查看用户列表"},
+    {PermUsersActivate, "激活用户"},
+    {PermUsersBlock, "禁用用户"},
+    {PermUsersDelete, "删除用户"},
+    {PermUsersResetPassword, "重置用户密码"},
+    {PermUsersAssignRole, "修改用户角色"},
+    {PermUsersInvite, "管理邀请码"},
+    {PermUsersManageSecurity, "管理用户会话、登录锁定和两步验证"},
+    {PermUsersImpersonate, "模拟用户登录"},
+    {PermRolesManage, "管理角色"},
+    {PermTodosReadAny, "查看任意用户的待办事项"},
+    {PermTodosWriteAny, "修改任意用户的待办事项"},
+    {PermAuditRead, "查看和导出审计日志"},
+}
+
+type PermissionInfo struct {
+    Name        string `json:"name"`
+    Description string `json:"description"`
+}
+
+// IsValidPermission 判断权限名称是否存在
+func IsValidPermission(name string) bool {
+    if name == PermAll {
+        return true
+    }
+    for _, p := range Permissions {
+        if p.Name == name {
+            return true
+        }
+    }
+    return false
+}
+
+// Role 角色，由一组权限组成；用户通过 User.Role 引用角色名称
+type Role struct {
+    ID          uint        `json:"id" gorm:"primarykey"`
+    Name        string      `json:"name" gorm:"size:50;uniqueIndex;not null"`
+    Description string      `json:"description" gorm:"size:255"`
+    Permissions StringSlice `json:"permissions" gorm:"type:text"`
+    BuiltIn     bool        `json:"built_in" gorm:"default:false"` // 内置角色不可修改或删除
+    CreatedAt   CustomTime  `json:"created_at"`
+    UpdatedAt   CustomTime  `json:"updated_at"`
+}
+
+// HasPermission 判断角色是否拥有指定权限
+func (r *Role) HasPermission(perm string) bool {
+    for _, p := range r.Permissions {
+        if p == PermAll || p == perm {
+            return true
+        }
+    }
+    return false
+}
+
+// Covers 判断角色是否拥有另一组权限中的全部权限，用于防止越权分配
+func (r *Role) Covers(perms []string) bool {
+    if r.HasPermission(PermAll) {
+        return true
+    }
+    for _, p := range perms {
+        if p == PermAll || !r.HasPermission(p) {
+            return false
+        }
+    }
+    return true
+}
+
+type RoleRequest struct {
+    Name        string   `json:"name" binding:"required,min=2,max=50"`
+    Description string   `json:"description" binding:"max=255"`
+    Permissions []string `json:"permissions" binding:"required"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+    "time"
+)
+
+// Session 一次登录产生的会话，ID 即访问令牌中的 jti，同时也是刷新令牌族的 FamilyID
+type Session struct {
+    ID         string      `json:"id" gorm:"primaryKey;size:64"`
+    UserID     uint        `json:"user_id" gorm:"not null;index"`
+    Device     string      `json:"device" gorm:"size:100"`
+    IP         string      `json:"ip" gorm:"size:64"`
+    UserAgent  string      `json:"user_agent" gorm:"size:255"`
+    LastUsedAt CustomTime  `json:"last_used_at"`
+    ExpiresAt  time.Time   `json:"-" gorm:"not null"`
+    RevokedAt  *CustomTime `json:"revoked_at,omitempty"`
+    CreatedAt  CustomTime  `json:"created_at"`
+}
+
+// IsValid 会话未被吊销且未过期
+func (s *Session) IsValid() bool {
+    return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
@@ -2,6 +2,8 @@ package models
 
 import (
     "time"
+
+    "gorm.io/gorm"
 )
 
 type Todo struct {
@@ -9,37 +11,63 @@ type Todo struct {
     Title       string      `json:"title" binding:"required" gorm:"not null"`
     Description string      `json:"description"`
     Completed   bool        `json:"completed" gorm:"default:false"`
-    CompletedAt *CustomTime `json:"completed_at,omitempty" gorm:"type:datetime"`
+    CompletedAt *CustomTime `json:"completed_at,omitempty"`
     IsLongTerm  bool        `json:"is_long_term" gorm:"default:false"`
     IsStarred   bool        `json:"is_starred" gorm:"default:false"`
     UserID      uint        `json:"user_id" gorm:"not null"`
-    StartTime   CustomTime  `json:"start_time" gorm:"type:datetime;default:CURRENT_TIMESTAMP"`
-    EndTime     *CustomTime `json:"end_time,omitempty" gorm:"type:datetime"`
+    ProjectID   *uint       `json:"project_id" gorm:"index"` // 所属项目，为空时不属于任何项目
+    StartTime   CustomTime  `json:"start_time" gorm:"default:CURRENT_TIMESTAMP"`
+    EndTime     *CustomTime `json:"end_time,omitempty"`
     Tags        StringSlice `json:"tags" gorm:"type:text"`
+    AutoComplete bool       `json:"auto_complete" gorm:"default:false"` // 检查项全部完成时自动完成
+    Checklist   []ChecklistItem `json:"checklist" gorm:"foreignKey:TodoID"`
+    Progress    *int        `json:"progress" gorm:"-"`   // 检查项完成百分比，没有检查项时为 null
+    IsOverdue   bool        `json:"is_overdue" gorm:"-"` // 未完成且已过结束时间
+    // 重复任务所属的系列和这一次在规则中的原始开始时间，修改开始时间不会改变 OccurrenceAt
+    RecurrenceID *uint       `json:"recurrence_id,omitempty" gorm:"uniqueIndex:idx_todos_occurrence"`
+    OccurrenceAt *CustomTime `json:"occurrence_at,omitempty" gorm:"uniqueIndex:idx_todos_occurrence"`
+    Recurrence   *Recurrence `json:"recurrence,omitempty"`
     CreatedAt   CustomTime  `json:"created_at"`
     UpdatedAt   CustomTime  `json:"updated_at"`
 }
 
+// LocationKey 通过 db.Set(LocationKey, *time.Location) 传入当前用户时区，供钩子计算默认时间
+const LocationKey = "todolist:location"
+
+func locationFrom(tx *gorm.DB) *time.Location {
+    if v, ok := tx.Get(LocationKey); ok {
+        if loc, ok := v.(*time.Location); ok && loc != nil {
+            return loc
+        }
+    }
+    return time.UTC
+}
+
+// DefaultEndTime 非长期任务的默认结束时间：用户时区下开始时间的次日同一时刻（夏令时切换日不是24小时）
+func DefaultEndTime(start time.Time, loc *time.Location) time.Time {
+    return start.In(loc).AddDate(0, 0, 1).UTC()
+}
+
 // BeforeCreate 在创建记录前设置默认值
-func (t *Todo) BeforeCreate() error {
+func (t *Todo) BeforeCreate(tx *gorm.DB) error {
     // 如果开始时间为空，设置为当前时间
     if t.StartTime.IsZero() {
-        t.StartTime = CustomTime{time.Now()}
+        t.StartTime = CustomTime{time.Now().UTC()}
     }
-    
-    // 如果不长期任务且结束时间为空，设置为开始时间后24小时
+
+    // 如果不长期任务且结束时间为空，设置为开始时间后一天
     if !t.IsLongTerm && t.EndTime == nil {
-        endTime := CustomTime{t.StartTime.Add(24 * time.Hour)}
+        endTime := CustomTime{DefaultEndTime(t.StartTime.Time, locationFrom(tx))}
         t.EndTime = &endTime
     }
-    
+
     return nil
 }
 
-// BeforeSave 在保存记录前处理完成时间
-func (t *Todo) BeforeSave() error {
+// BeforeSave 在保存记录前处理完成时间，创建时先于 BeforeCreate 执行
+func (t *Todo) BeforeSave(tx *gorm.DB) error {
     if t.Completed && t.CompletedAt == nil {
-        now := CustomTime{time.Now()}
+        now := CustomTime{time.Now().UTC()}
         t.CompletedAt = &now
     } else if !t.Completed {
         t.CompletedAt = nil
@@ -51,16 +79,51 @@ func (t *Todo) BeforeSave() error {
         if t.EndTime != nil && t.EndTime.IsZero() {
             t.EndTime = nil
         }
-    } else {
-        // 如果不是长期任务且结束时间为空，设置为开始时间后24小时
-        if t.EndTime == nil {
-            endTime := CustomTime{t.StartTime.Add(24 * time.Hour)}
-            t.EndTime = &endTime
-        }
+    } else if t.EndTime == nil && !t.StartTime.IsZero() {
+        // 如果不是长期任务且结束时间为空，设置为开始时间后一天
+        endTime := CustomTime{DefaultEndTime(t.StartTime.Time, locationFrom(tx))}
+        t.EndTime = &endTime
     }
     return nil
 }
 
+// AfterFind 计算是否已超时和检查项进度（预加载在 AfterFind 之前完成）
+func (t *Todo) AfterFind(tx *gorm.DB) error {
+    t.IsOverdue = !t.Completed && t.EndTime != nil && t.EndTime.Before(time.Now())
+    t.UpdateProgress()
+    return nil
+}
+
+// AfterSave 创建或更新后同样计算是否已超时和检查项进度
+func (t *Todo) AfterSave(tx *gorm.DB) error {
+    return t.AfterFind(tx)
+}
+
+// UpdateProgress 根据已加载的检查项计算完成百分比
+func (t *Todo) UpdateProgress() {
+    if len(t.Checklist) == 0 {
+        t.Progress = nil
+        return
+    }
+    done := 0
+    for _, item := range t.Checklist {
+        if item.Completed {
+            done++
+        }
+    }
+    progress := done * 100 / len(t.Checklist)
+    t.Progress = &progress
+}
+
+// SyncAutoComplete 开启自动完成时按检查项设置完成状态：全部完成则完成，否则取消完成；需先加载检查项
+func (t *Todo) SyncAutoComplete() {
+    if !t.AutoComplete || len(t.Checklist) == 0 {
+        return
+    }
+    t.UpdateProgress()
+    t.Completed = *t.Progress == 100
+}
+
 type CreateTodoRequest struct {
     Title       string      `json:"title" binding:"required"`
     Description string      `json:"description"`
@@ -69,6 +132,11 @@ type CreateTodoRequest struct {
     StartTime   *CustomTime `json:"start_time,omitempty"`
     EndTime     *CustomTime `json:"end_time,omitempty"`
     Tags        []string    `json:"tags"`
+    ProjectID   *uint       `json:"project_id"` // 可选，所属项目
+    AutoComplete bool       `json:"auto_complete"`
+    Checklist   []string    `json:"checklist" binding:"max=100,dive,required,max=200"` // 可选，检查项标题
+    RRule       string       `json:"rrule" binding:"max=255"` // 可选，RFC 5545 重复规则
+    ExDates     []CustomTime `json:"exdates"`                 // 可选，跳过的日期（原始开始时间）
 }
 
 // ToTodo 将请求转换为Todo模型，并设置默认值
@@ -80,6 +148,11 @@ func (r *CreateTodoRequest) ToTodo(userID uint) *Todo {
         Tags:        r.Tags,
     }
 
+    // 项目ID为0表示不属于任何项目
+    if r.ProjectID != nil && *r.ProjectID != 0 {
+        todo.ProjectID = r.ProjectID
+    }
+
     // 设置是否为长期任务的默认值
     if r.IsLongTerm != nil {
         todo.IsLongTerm = bool(*r.IsLongTerm)
@@ -100,6 +173,12 @@ func (r *CreateTodoRequest) ToTodo(userID uint) *Todo {
         todo.EndTime = r.EndTime
     }
 
+    // 检查项随待办事项一起创建
+    todo.AutoComplete = r.AutoComplete
+    for i, title := range r.Checklist {
+        todo.Checklist = append(todo.Checklist, ChecklistItem{Title: title, SortOrder: i})
+    }
+
     return todo
 }
 
@@ -112,6 +191,11 @@ type UpdateTodoRequest struct {
     StartTime   *CustomTime `json:"start_time,omitempty"`
     EndTime     *CustomTime `json:"end_time,omitempty"`
     Tags        []string    `json:"tags"`
+    ProjectID   *uint       `json:"project_id,omitempty"` // 0 表示移出项目
+    AutoComplete *bool      `json:"auto_complete,omitempty"`
+    // 重复规则和跳过的日期属于整个系列，只能配合 scope=following 修改，空字符串表示从这一次起不再重复
+    RRule       *string      `json:"rrule,omitempty" binding:"omitempty,max=255"`
+    ExDates     []CustomTime `json:"exdates"`
 }
 
 // UpdateTodo 更新Todo模型
@@ -136,13 +220,8 @@ func (r *UpdateTodoRequest) UpdateTodo(todo *Todo) {
     }
     if r.EndTime != nil {
         if r.EndTime.IsZero() {
-            // 如果提供了空的结束时间
-            if todo.IsLongTerm {
-                todo.EndTime = nil // 长期任务允许结束时间为空
-            } else {
-                endTime := CustomTime{todo.StartTime.Add(24 * time.Hour)}
-                todo.EndTime = &endTime // 非长期任务设置为开始时间后24小时
-            }
+            // 如果提供了空的结束时间：长期任务允许为空，非长期任务由 BeforeSave 按用户时区设置默认值
+            todo.EndTime = nil
         } else {
             todo.EndTime = r.EndTime
         }
@@ -150,4 +229,16 @@ func (r *UpdateTodoRequest) UpdateTodo(todo *Todo) {
     if r.Tags != nil {
         todo.Tags = r.Tags
     }
+    if r.ProjectID != nil {
+        if *r.ProjectID == 0 {
+            todo.ProjectID = nil
+        } else {
+            todo.ProjectID = r.ProjectID
+        }
+    }
+    if r.AutoComplete != nil {
+        todo.AutoComplete = *r.AutoComplete
+        // 开启自动完成时立即按检查项同步一次
+        todo.SyncAutoComplete()
+    }
 } 
\ No newline at end of file
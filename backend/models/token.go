@@ -0,0 +1,22 @@
+package models
+
+import (
+    "time"
+)
+
+// RefreshToken 服务端保存的刷新令牌，同一次登录轮换出的令牌属于同一个 Family
+type RefreshToken struct {
+    ID        uint       `gorm:"primarykey"`
+    UserID    uint       `gorm:"not null;index"`
+    FamilyID  string     `gorm:"size:64;not null;index"`
+    TokenHash string     `gorm:"size:64;not null;uniqueIndex"` // 令牌的 SHA-256，原文不落库
+    ExpiresAt time.Time  `gorm:"not null"`
+    UsedAt    *time.Time // 已被轮换
+    RevokedAt *time.Time // 已被吊销
+    CreatedAt time.Time
+}
+
+// RefreshRequest 刷新令牌请求
+type RefreshRequest struct {
+    RefreshToken string `json:"refresh_token" binding:"required"`
+}
@@ -6,6 +6,8 @@ import (
     "encoding/json"
     "fmt"
     "strconv"
+
+    "gorm.io/gorm/schema"
 )
 
 const (
@@ -46,6 +48,11 @@ func (t *CustomTime) UnmarshalJSON(data []byte) error {
     return nil
 }
 
+// GormDataType 让GORM按各数据库方言映射为对应的时间类型
+func (CustomTime) GormDataType() string {
+    return string(schema.Time)
+}
+
 // Value 实现 driver.Valuer 接口
 func (t CustomTime) Value() (driver.Value, error) {
     if t.Time.IsZero() {
@@ -60,13 +67,30 @@ func (t *CustomTime) Scan(value interface{}) error {
         t.Time = time.Time{}
         return nil
     }
-    if v, ok := value.(time.Time); ok {
+    switch v := value.(type) {
+    case time.Time:
         t.Time = v
-        return nil
+    case []byte:
+        return t.parseDBString(string(v))
+    case string:
+        return t.parseDBString(v)
+    default:
+        return fmt.Errorf("failed to scan CustomTime value: %v", value)
     }
     return nil
 }
 
+// parseDBString 解析驱动以文本形式返回的时间（如未开启parseTime的MySQL）
+func (t *CustomTime) parseDBString(s string) error {
+    for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05.999999999", TimeFormat} {
+        if parsed, err := time.Parse(layout, s); err == nil {
+            t.Time = parsed
+            return nil
+        }
+    }
+    return fmt.Errorf("failed to parse CustomTime value: %s", s)
+}
+
 // StringSlice 用于处理字符串数组的数据库序列化
 type StringSlice []string
 
@@ -1,6 +1,8 @@
 package models
 
 import (
+    "time"
+
     "golang.org/x/crypto/bcrypt"
 )
 
@@ -16,36 +18,79 @@ const (
 type User struct {
     ID        uint       `json:"id" gorm:"primarykey"`
     Username  string     `json:"username" gorm:"unique;not null"`
+    Email     string     `json:"email" gorm:"size:255;index"`
+    EmailVerified bool   `json:"email_verified" gorm:"default:false"` // 邮箱已通过邮件中的链接验证，修改邮箱后重置
     Password  string     `json:"-" gorm:"not null"`  // json:"-" 确保密码不会在JSON响应中返回
-    Role      string     `json:"role" gorm:"type:varchar(10);default:'user'"`
+    Role      string     `json:"role" gorm:"type:varchar(50);default:'user'"` // 角色名称，对应 roles.name
     Status    string     `json:"status" gorm:"type:varchar(10);default:'inactive'"`
     LastActive CustomTime `json:"last_active"`
+    LockedUntil *CustomTime `json:"locked_until,omitempty" gorm:"-"` // 登录失败过多导致的临时锁定，由限流器填充
+    TOTPSecret  string     `json:"-" gorm:"column:totp_secret;size:64"`
+    TOTPEnabled bool       `json:"totp_enabled" gorm:"column:totp_enabled;default:false"`
+    TOTPLastStep int64     `json:"-" gorm:"column:totp_last_step;default:0"` // 最近一次使用的验证码步数，防止重放
+    DisplayName string     `json:"display_name" gorm:"size:50"`
+    Avatar      string     `json:"avatar" gorm:"size:255"`                    // 头像地址，例如 /avatars/1-xxxx.png
+    Timezone    string     `json:"timezone" gorm:"size:64;default:'UTC'"`     // IANA 时区，例如 Asia/Shanghai
+    Locale      string     `json:"locale" gorm:"size:16;default:'zh-CN'"`     // BCP 47 语言标签
+    WeekStart   int        `json:"week_start" gorm:"default:1"`               // 每周第一天，0 表示周日，1 表示周一
+    DeleteAfter *CustomTime `json:"delete_after,omitempty"`                   // 申请注销后的彻底删除时间，为空表示未申请
+    MustChangePassword bool `json:"must_change_password" gorm:"default:false"` // 下次登录后必须先修改密码
     Todos     []Todo     `json:"todos"`
     CreatedAt CustomTime `json:"created_at"`
     UpdatedAt CustomTime `json:"updated_at"`
 }
 
 type UserRegister struct {
-    Username string `json:"username" binding:"required,min=3,max=30"`
-    Password string `json:"password" binding:"required,min=6"`
+    Username   string `json:"username" binding:"required,min=3,max=30"`
+    Password   string `json:"password" binding:"required"` // 长度等要求由密码策略检查
+    Email      string `json:"email" binding:"omitempty,email,max=255"`
+    InviteCode string `json:"invite_code" binding:"max=64"` // 邀请制注册时必填
 }
 
 type UserLogin struct {
     Username string `json:"username" binding:"required"`
     Password string `json:"password" binding:"required"`
+    Device   string `json:"device" binding:"max=100"` // 可选，设备名称，用于会话列表展示
+}
+
+type MFAVerifyRequest struct {
+    MFAToken     string `json:"mfa_token" binding:"required"`
+    Code         string `json:"code"`
+    RecoveryCode string `json:"recovery_code"`
+}
+
+type MFACodeRequest struct {
+    Code string `json:"code" binding:"required"`
+}
+
+type MFADisableRequest struct {
+    Password string `json:"password" binding:"required"`
+    Code     string `json:"code" binding:"required"`
 }
 
 type UpdateUserRole struct {
-    Role string `json:"role" binding:"required,oneof=admin user"`
+    Role string `json:"role" binding:"required,max=50"`
+}
+
+type UpdateProfileRequest struct {
+    DisplayName *string `json:"display_name" binding:"omitempty,max=50"`
+    Timezone    *string `json:"timezone" binding:"omitempty,max=64"`
+    Locale      *string `json:"locale" binding:"omitempty,max=16"`
+    WeekStart   *int    `json:"week_start" binding:"omitempty,min=0,max=6"`
+}
+
+type DeleteAccountRequest struct {
+    Password string `json:"password" binding:"required"`
 }
 
 type UpdatePassword struct {
     OldPassword string `json:"old_password" binding:"required"`
-    NewPassword string `json:"new_password" binding:"required,min=6"`
+    NewPassword string `json:"new_password" binding:"required"`
 }
 
 type AdminUpdateUserPassword struct {
-    Password string `json:"password" binding:"required,min=6"`
+    Password   string `json:"password" binding:"required"`
+    MustChange bool   `json:"must_change"` // 用户下次登录后必须修改密码
 }
 
 func (u *User) HashPassword() error {
@@ -67,4 +112,16 @@ func (u *User) IsAdmin() bool {
 
 func (u *User) IsActive() bool {
     return u.Status == StatusActive
-} 
\ No newline at end of file
+}
+
+// Location 返回用户时区，未设置或无效时使用 UTC
+func (u *User) Location() *time.Location {
+    if u.Timezone == "" {
+        return time.UTC
+    }
+    loc, err := time.LoadLocation(u.Timezone)
+    if err != nil {
+        return time.UTC
+    }
+    return loc
+}
@@ -0,0 +1,19 @@
+package notify
+
+import (
+	"context"
+
+	"todolist/mail"
+)
+
+// EmailNotifier 通过邮件发送通知
+type EmailNotifier struct {
+	Mailer mail.Mailer
+}
+
+func (n *EmailNotifier) Notify(ctx context.Context, to Recipient, msg Message) error {
+	if to.Email == "" {
+		return ErrNotConfigured
+	}
+	return n.Mailer.Send(ctx, mail.Message{To: to.Email, Subject: msg.Title, Body: msg.Body})
+}
@@ -0,0 +1,17 @@
+package notify
+
+import "context"
+
+// Inbox 站内信存储
+type Inbox interface {
+	Add(ctx context.Context, to Recipient, msg Message) error
+}
+
+// InAppNotifier 将通知写入用户的站内信收件箱
+type InAppNotifier struct {
+	Inbox Inbox
+}
+
+func (n *InAppNotifier) Notify(ctx context.Context, to Recipient, msg Message) error {
+	return n.Inbox.Add(ctx, to, msg)
+}
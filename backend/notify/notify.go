@@ -0,0 +1,44 @@
+// Package notify 提供提醒通知的发送接口及站内信、邮件、Webhook、ntfy 四种实现
+package notify
+
+import (
+	"context"
+	"errors"
+)
+
+// 通知渠道
+const (
+	ChannelInApp   = "in_app"
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelNtfy    = "ntfy"
+)
+
+// Channels 全部通知渠道
+var Channels = []string{ChannelInApp, ChannelEmail, ChannelWebhook, ChannelNtfy}
+
+// ErrNotConfigured 接收人没有配置该渠道（如未设置邮箱或 Webhook 地址）
+var ErrNotConfigured = errors.New("接收人未配置该通知渠道")
+
+// Recipient 通知接收人及其各渠道的地址
+type Recipient struct {
+	UserID        uint
+	Username      string
+	Email         string
+	WebhookURL    string
+	WebhookSecret string
+	NtfyTopic     string
+}
+
+// Message 一条通知
+type Message struct {
+	Title      string
+	Body       string
+	TodoID     uint
+	ReminderID uint
+}
+
+// Notifier 通知发送接口
+type Notifier interface {
+	Notify(ctx context.Context, to Recipient, msg Message) error
+}
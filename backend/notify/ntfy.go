@@ -0,0 +1,35 @@
+package notify
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// NtfyNotifier 通过 ntfy 协议推送：向 Server/<topic> 发送 POST 请求，请求体为正文，标题放在 Title 头。
+// 服务器由管理员配置，用户只设置主题
+type NtfyNotifier struct {
+	Client *http.Client
+	Server string
+	// Token 服务器需要认证时使用的访问令牌
+	Token string
+}
+
+func (n *NtfyNotifier) Notify(ctx context.Context, to Recipient, msg Message) error {
+	if to.NtfyTopic == "" {
+		return ErrNotConfigured
+	}
+	url := strings.TrimRight(n.Server, "/") + "/" + to.NtfyTopic
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(msg.Body))
+	if err != nil {
+		return err
+	}
+	// HTTP 头只能是 ASCII，ntfy 支持 RFC 2047 编码的标题
+	req.Header.Set("Title", mime.BEncoding.Encode("UTF-8", msg.Title))
+	req.Header.Set("Tags", "alarm_clock")
+	if n.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.Token)
+	}
+	return do(n.Client, req)
+}
@@ -0,0 +1,114 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// SignatureHeader Webhook 请求体的 HMAC-SHA256 签名，格式为 sha256=<hex>，只在设置了密钥时发送
+const SignatureHeader = "X-Todolist-Signature"
+
+var errPrivateAddress = errors.New("不允许访问内网地址")
+
+// WebhookNotifier 以 JSON 格式向用户设置的地址发送 POST 请求
+type WebhookNotifier struct {
+	Client *http.Client
+}
+
+type webhookPayload struct {
+	Event      string    `json:"event"`
+	UserID     uint      `json:"user_id"`
+	TodoID     uint      `json:"todo_id,omitempty"`
+	ReminderID uint      `json:"reminder_id,omitempty"`
+	Title      string    `json:"title"`
+	Body       string    `json:"body"`
+	SentAt     time.Time `json:"sent_at"`
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, to Recipient, msg Message) error {
+	if to.WebhookURL == "" {
+		return ErrNotConfigured
+	}
+	body, err := json.Marshal(webhookPayload{
+		Event:      "reminder",
+		UserID:     to.UserID,
+		TodoID:     msg.TodoID,
+		ReminderID: msg.ReminderID,
+		Title:      msg.Title,
+		Body:       msg.Body,
+		SentAt:     time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, to.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if to.WebhookSecret != "" {
+		mac := hmac.New(sha256.New, []byte(to.WebhookSecret))
+		mac.Write(body)
+		req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	return do(n.Client, req)
+}
+
+// do 发送请求，非 2xx 响应视为失败
+func do(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s 返回状态码 %d", req.URL.Host, resp.StatusCode)
+	}
+	return nil
+}
+
+// NewHTTPClient 创建发送通知用的 HTTP 客户端。地址由用户设置，allowPrivate 为 false 时
+// 拒绝连接回环、内网和链路本地地址（在解析域名之后检查，防止通过域名绕过）
+func NewHTTPClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+				ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+				return errPrivateAddress
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		// 重定向同样经过 Dialer 检查，但限制次数
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 3 {
+				return errors.New("重定向次数过多")
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPClientRefusesPrivateAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	client := NewHTTPClient(time.Second, false)
+	urls := []string{
+		srv.URL, // 127.0.0.1
+		"http://localhost:1/",
+		"http://[::1]:1/",
+		"http://0.0.0.0:1/",
+		"http://10.0.0.1:1/",
+		"http://172.16.0.1:1/",
+		"http://192.168.1.1:1/",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://[fe80::1]:1/",
+		"http://[fd00::1]:1/",
+	}
+	for _, u := range urls {
+		resp, err := client.Get(u)
+		if err == nil {
+			resp.Body.Close()
+			t.Errorf("%s: 应当拒绝连接", u)
+			continue
+		}
+		if !errors.Is(err, errPrivateAddress) {
+			t.Errorf("%s: 应当因内网地址被拒绝，实际 %v", u, err)
+		}
+	}
+
+	// 开启 allowPrivate 后可以访问
+	resp, err := NewHTTPClient(time.Second, true).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("允许内网地址时请求失败: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var got webhookPayload
+	var signature string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write(body)
+		signature = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		if r.Header.Get(SignatureHeader) != signature {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.Unmarshal(body, &got)
+	}))
+	defer srv.Close()
+
+	n := &WebhookNotifier{Client: NewHTTPClient(time.Second, true)}
+	to := Recipient{UserID: 7, WebhookURL: srv.URL, WebhookSecret: "secret"}
+	if err := n.Notify(context.Background(), to, Message{Title: "标题", Body: "正文", TodoID: 3, ReminderID: 5}); err != nil {
+		t.Fatalf("发送失败: %v", err)
+	}
+	if got.Event != "reminder" || got.UserID != 7 || got.TodoID != 3 || got.ReminderID != 5 || got.Title != "标题" {
+		t.Errorf("请求体不正确: %+v", got)
+	}
+
+	to.WebhookSecret = "wrong"
+	if err := n.Notify(context.Background(), to, Message{Title: "标题"}); err == nil {
+		t.Error("非 2xx 响应应当返回错误")
+	}
+	if err := n.Notify(context.Background(), Recipient{}, Message{}); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("未设置地址时应当返回 ErrNotConfigured，实际 %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package oidc_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"todolist/oidc"
+	"todolist/oidc/oidctest"
+)
+
+const redirectURL = "http://app.test/auth/oidc/callback"
+
+func newProvider(t *testing.T) (*oidctest.Provider, *oidc.Provider) {
+	t.Helper()
+	mock := oidctest.NewProvider("todo-client", "todo-secret")
+	t.Cleanup(mock.Close)
+	p := oidc.NewProvider(oidc.Config{
+		Issuer:       mock.Issuer(),
+		ClientID:     mock.ClientID,
+		ClientSecret: mock.ClientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"profile", "email"},
+	}, nil)
+	return mock, p
+}
+
+// authorize 访问授权地址，返回模拟身份提供方跳转回来时携带的参数
+func authorize(t *testing.T, authURL string) url.Values {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("授权端点返回 %d", resp.StatusCode)
+	}
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(location.String(), redirectURL+"?") {
+		t.Fatalf("跳转地址不正确: %s", location)
+	}
+	return location.Query()
+}
+
+func TestPKCEExchange(t *testing.T) {
+	mock, p := newProvider(t)
+	mock.SetUser(map[string]interface{}{
+		"sub":                "alice-1",
+		"email":              "alice@example.com",
+		"email_verified":     "true",
+		"preferred_username": "alice",
+	})
+	ctx := context.Background()
+
+	authURL, err := p.AuthCodeURL(ctx, "state-1", "nonce-1", "verifier-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(authURL)
+	q := u.Query()
+	if q.Get("code_challenge") != oidc.CodeChallenge("verifier-1") || q.Get("code_challenge_method") != "S256" {
+		t.Errorf("授权地址缺少 PKCE 参数: %s", authURL)
+	}
+	if q.Get("scope") != "openid profile email" {
+		t.Errorf("scope 应当包含 openid: %q", q.Get("scope"))
+	}
+
+	callback := authorize(t, authURL)
+	if callback.Get("state") != "state-1" {
+		t.Errorf("state 不正确: %q", callback.Get("state"))
+	}
+
+	tokens, err := p.Exchange(ctx, callback.Get("code"), "verifier-1")
+	if err != nil {
+		t.Fatalf("换取令牌失败: %v", err)
+	}
+	claims, err := p.VerifyIDToken(ctx, tokens.IDToken, "nonce-1")
+	if err != nil {
+		t.Fatalf("校验 ID Token 失败: %v", err)
+	}
+	if claims.Subject != "alice-1" || claims.Email != "alice@example.com" || !claims.EmailVerified ||
+		claims.PreferredUsername != "alice" || claims.Issuer != mock.Issuer() {
+		t.Errorf("ID Token 声明不正确: %+v", claims)
+	}
+
+	// 授权码只能使用一次
+	if _, err := p.Exchange(ctx, callback.Get("code"), "verifier-1"); err == nil {
+		t.Error("重复使用授权码应当失败")
+	}
+}
+
+func TestPKCEExchangeWrongVerifier(t *testing.T) {
+	_, p := newProvider(t)
+	ctx := context.Background()
+
+	authURL, err := p.AuthCodeURL(ctx, "state", "nonce", "right-verifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	callback := authorize(t, authURL)
+	if _, err := p.Exchange(ctx, callback.Get("code"), "wrong-verifier"); err == nil {
+		t.Error("PKCE verifier 不匹配时应当换取失败")
+	}
+}
+
+func TestVerifyIDTokenRejects(t *testing.T) {
+	mock, p := newProvider(t)
+	ctx := context.Background()
+	user := map[string]interface{}{"sub": "bob-1"}
+
+	if _, err := p.VerifyIDToken(ctx, mock.SignIDToken(mock.Claims(user, "n")), "n"); err != nil {
+		t.Fatalf("有效的 ID Token 校验失败: %v", err)
+	}
+
+	cases := []struct {
+		name  string
+		nonce string
+		edit  func(map[string]interface{})
+	}{
+		{"bad iss", "n", func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" }},
+		{"bad aud", "n", func(c map[string]interface{}) { c["aud"] = "other-client" }},
+		{"aud list without client", "n", func(c map[string]interface{}) { c["aud"] = []string{"a", "b"} }},
+		{"bad azp", "n", func(c map[string]interface{}) {
+			c["aud"] = []string{"todo-client", "other-client"}
+			c["azp"] = "other-client"
+		}},
+		{"bad nonce", "expected", func(c map[string]interface{}) {}},
+		{"missing nonce", "n", func(c map[string]interface{}) { delete(c, "nonce") }},
+		{"expired", "n", func(c map[string]interface{}) { c["exp"] = time.Now().Add(-2 * time.Minute).Unix() }},
+		{"missing exp", "n", func(c map[string]interface{}) { delete(c, "exp") }},
+		{"issued in future", "n", func(c map[string]interface{}) { c["iat"] = time.Now().Add(time.Hour).Unix() }},
+		{"missing sub", "n", func(c map[string]interface{}) { delete(c, "sub") }},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			claims := mock.Claims(user, "n")
+			tc.edit(claims)
+			if _, err := p.VerifyIDToken(ctx, mock.SignIDToken(claims), tc.nonce); err == nil {
+				t.Error("应当拒绝该 ID Token")
+			}
+		})
+	}
+
+	t.Run("bad signature", func(t *testing.T) {
+		other := oidctest.NewProvider("todo-client", "")
+		defer other.Close()
+		claims := mock.Claims(user, "n")
+		if _, err := p.VerifyIDToken(ctx, other.SignIDToken(claims), "n"); err == nil {
+			t.Error("其他密钥签名的 ID Token 应当被拒绝")
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package pwpolicy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BreachList 本地泄露密码库，使用 SHA-1 哈希，支持两种格式：
+//   - 目录：按 k-anonymity 方式以哈希前5位分片，每个文件名为前缀（可带 .txt 后缀），
+//     每行为 "剩余35位:次数"，与 Pwned Passwords 范围接口的返回格式相同
+//   - 单个文件：每行为 "完整40位哈希:次数"，按哈希升序排列，查询时二分查找
+type BreachList struct {
+	path string
+	dir  bool
+}
+
+// OpenBreachList 打开本地泄露密码库
+func OpenBreachList(path string) (*BreachList, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return &BreachList{path: path, dir: info.IsDir()}, nil
+}
+
+// Contains 判断密码是否在泄露密码库中
+func (b *BreachList) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	if b.dir {
+		return b.lookupRange(hash[:5], hash[5:])
+	}
+	return b.lookupSorted(hash)
+}
+
+// lookupRange 在前缀对应的分片文件中查找剩余部分
+func (b *BreachList) lookupRange(prefix, suffix string) (bool, error) {
+	var f *os.File
+	for _, name := range []string{prefix, prefix + ".txt", strings.ToLower(prefix), strings.ToLower(prefix) + ".txt"} {
+		var err error
+		f, err = os.Open(filepath.Join(b.path, name))
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return false, err
+		}
+	}
+	if f == nil {
+		return false, nil
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if strings.EqualFold(hashField(scanner.Text()), suffix) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// lookupSorted 在按哈希排序的文件中二分查找，始终保持目标行的起始位置在 [lo, hi) 内
+func (b *BreachList) lookupSorted(hash string) (bool, error) {
+	f, err := os.Open(b.path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	lo, hi := int64(0), info.Size()
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		start, line, err := lineAfter(f, mid)
+		if err != nil {
+			return false, err
+		}
+		if start >= hi || line == "" {
+			hi = mid
+			continue
+		}
+		switch cmp := strings.Compare(strings.ToUpper(hashField(line)), hash); {
+		case cmp == 0:
+			return true, nil
+		case cmp < 0:
+			lo = start + int64(len(line))
+		default:
+			hi = mid
+		}
+	}
+	return false, nil
+}
+
+// lineAfter 返回起始位置不小于 off 的第一行（包含换行符）及其起始位置，没有时返回空行
+func lineAfter(f *os.File, off int64) (int64, string, error) {
+	start := off
+	if off > 0 {
+		// 从前一个字节开始读，前一个字节是换行符时 off 本身就是行首
+		start = off - 1
+	}
+	if _, err := f.Seek(start, io.SeekStart); err != nil {
+		return 0, "", err
+	}
+	r := bufio.NewReader(f)
+	if off > 0 {
+		skipped, err := r.ReadString('\n')
+		if err == io.EOF {
+			return start + int64(len(skipped)), "", nil
+		}
+		if err != nil {
+			return 0, "", err
+		}
+		start += int64(len(skipped))
+	}
+	line, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return 0, "", err
+	}
+	return start, line, nil
+}
+
+// hashField 取出 "哈希:次数" 行中的哈希部分
+func hashField(line string) string {
+	line = strings.TrimSpace(line)
+	if i := strings.IndexByte(line, ':'); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
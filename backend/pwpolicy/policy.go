@@ -0,0 +1,116 @@
+package pwpolicy
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// MaxLength bcrypt 只使用密码的前 72 个字节，超出部分会被拒绝
+const MaxLength = 72
+
+// Config 密码策略
+type Config struct {
+	// MinLength 最小长度（按字符计）
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// DisallowUsername 密码中不能包含用户名（忽略大小写）
+	DisallowUsername bool
+	// BreachFile 本地泄露密码库路径，为空时不检查，格式见 BreachList
+	BreachFile string
+}
+
+// Violation 密码不符合策略，Error 返回可直接展示给用户的说明
+type Violation struct {
+	Message string
+}
+
+func (v *Violation) Error() string { return v.Message }
+
+// Policy 密码策略检查器
+type Policy struct {
+	cfg    Config
+	breach *BreachList
+}
+
+// New 创建密码策略，配置了泄露密码库时会检查路径是否可用
+func New(cfg Config) (*Policy, error) {
+	p := &Policy{cfg: cfg}
+	if cfg.BreachFile != "" {
+		breach, err := OpenBreachList(cfg.BreachFile)
+		if err != nil {
+			return nil, err
+		}
+		p.breach = breach
+	}
+	return p, nil
+}
+
+// Check 检查密码是否符合策略，不符合时返回 *Violation，读取泄露密码库失败时返回其他错误
+func (p *Policy) Check(password, username string) error {
+	if len(password) > MaxLength {
+		return &Violation{fmt.Sprintf("密码不能超过%d个字节", MaxLength)}
+	}
+	if n := len([]rune(password)); n < p.cfg.MinLength {
+		return &Violation{fmt.Sprintf("密码长度不能少于%d位", p.cfg.MinLength)}
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.cfg.RequireUpper && !upper {
+		return &Violation{"密码必须包含大写字母"}
+	}
+	if p.cfg.RequireLower && !lower {
+		return &Violation{"密码必须包含小写字母"}
+	}
+	if p.cfg.RequireDigit && !digit {
+		return &Violation{"密码必须包含数字"}
+	}
+	if p.cfg.RequireSymbol && !symbol {
+		return &Violation{"密码必须包含特殊字符"}
+	}
+
+	if p.cfg.DisallowUsername && username != "" &&
+		strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		return &Violation{"密码不能包含用户名"}
+	}
+
+	if p.breach != nil {
+		breached, err := p.breach.Contains(password)
+		if err != nil {
+			return fmt.Errorf("检查泄露密码库失败: %w", err)
+		}
+		if breached {
+			return &Violation{"该密码已出现在公开泄露的密码库中，请更换"}
+		}
+	}
+	return nil
+}
+
+// Rules 返回策略说明，供前端展示
+func (p *Policy) Rules() map[string]interface{} {
+	return map[string]interface{}{
+		"min_length":        p.cfg.MinLength,
+		"max_bytes":         MaxLength,
+		"require_upper":     p.cfg.RequireUpper,
+		"require_lower":     p.cfg.RequireLower,
+		"require_digit":     p.cfg.RequireDigit,
+		"require_symbol":    p.cfg.RequireSymbol,
+		"disallow_username": p.cfg.DisallowUsername,
+		"breach_check":      p.breach != nil,
+	}
+}
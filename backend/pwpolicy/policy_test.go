@@ -0,0 +1,162 @@
+package pwpolicy
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func TestCheck(t *testing.T) {
+	strict := Config{
+		MinLength:        8,
+		RequireUpper:     true,
+		RequireLower:     true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+		DisallowUsername: true,
+	}
+	tests := []struct {
+		name     string
+		cfg      Config
+		password string
+		username string
+		want     string // 期望的违规说明，为空表示通过
+	}{
+		{"通过", strict, "Sup3r-secret", "alice", ""},
+		{"过短", strict, "S3r-ab", "alice", "密码长度不能少于8位"},
+		{"按字符计长度", Config{MinLength: 4}, "密码密码", "", ""},
+		{"多字节字符不足", Config{MinLength: 5}, "密码密码", "", "密码长度不能少于5位"},
+		{"超过72字节", Config{MinLength: 1}, strings.Repeat("a", 73), "", "密码不能超过72个字节"},
+		{"恰好72字节", Config{MinLength: 1}, strings.Repeat("a", 72), "", ""},
+		{"缺少大写", strict, "sup3r-secret", "alice", "密码必须包含大写字母"},
+		{"缺少小写", strict, "SUP3R-SECRET", "alice", "密码必须包含小写字母"},
+		{"缺少数字", strict, "Super-secret", "alice", "密码必须包含数字"},
+		{"缺少特殊字符", strict, "Sup3rsecret", "alice", "密码必须包含特殊字符"},
+		{"空格不算特殊字符", strict, "Sup3r secret", "alice", "密码必须包含特殊字符"},
+		{"包含用户名", strict, "Alice-Sup3r", "alice", "密码不能包含用户名"},
+		{"未启用用户名检查", Config{MinLength: 8}, "alice-password", "alice", ""},
+		{"新用户没有用户名", strict, "Alice-Sup3r", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = p.Check(tt.password, tt.username)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("应当通过，实际: %v", err)
+				}
+				return
+			}
+			var violation *Violation
+			if !errors.As(err, &violation) {
+				t.Fatalf("应当返回 *Violation，实际: %v", err)
+			}
+			if violation.Message != tt.want {
+				t.Fatalf("违规说明为 %q，期望 %q", violation.Message, tt.want)
+			}
+		})
+	}
+}
+
+var breached = []string{"123456", "password", "qwerty", "Sup3r-secret", "letmein", "dragon"}
+
+// writeRangeDir 按 k-anonymity 分片写入泄露密码库，upper 为 false 时文件名使用小写并带 .txt 后缀
+func writeRangeDir(t *testing.T, upper bool) string {
+	t.Helper()
+	dir := t.TempDir()
+	shards := make(map[string][]string)
+	for _, pw := range breached {
+		hash := sha1Hex(pw)
+		shards[hash[:5]] = append(shards[hash[:5]], hash[5:]+":42")
+	}
+	for prefix, lines := range shards {
+		name := prefix
+		if !upper {
+			name = strings.ToLower(prefix) + ".txt"
+		}
+		// 同一分片中的其他哈希不应命中
+		lines = append([]string{strings.Repeat("0", 35) + ":1"}, lines...)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// writeSortedFile 写入按哈希排序的泄露密码库，填充若干条记录使二分查找经过多轮
+func writeSortedFile(t *testing.T) string {
+	t.Helper()
+	var lines []string
+	for _, pw := range breached {
+		lines = append(lines, sha1Hex(pw)+":42")
+	}
+	for i := 0; i < 200; i++ {
+		lines = append(lines, sha1Hex("filler-"+strings.Repeat("x", i))+":1")
+	}
+	sort.Strings(lines)
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBreachList(t *testing.T) {
+	sources := map[string]string{
+		"分片目录":    writeRangeDir(t, true),
+		"小写分片文件名": writeRangeDir(t, false),
+		"排序文件":    writeSortedFile(t),
+	}
+	for name, path := range sources {
+		t.Run(name, func(t *testing.T) {
+			list, err := OpenBreachList(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, pw := range breached {
+				if ok, err := list.Contains(pw); err != nil || !ok {
+					t.Errorf("%q 应当命中泄露密码库: ok=%v err=%v", pw, ok, err)
+				}
+			}
+			for _, pw := range []string{"Correct-Horse-9", "PASSWORD", "filler"} {
+				if ok, err := list.Contains(pw); err != nil || ok {
+					t.Errorf("%q 不应命中泄露密码库: ok=%v err=%v", pw, ok, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckBreach(t *testing.T) {
+	if _, err := New(Config{BreachFile: filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Fatal("泄露密码库不存在时应当返回错误")
+	}
+
+	p, err := New(Config{MinLength: 8, BreachFile: writeSortedFile(t)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var violation *Violation
+	if err := p.Check("Sup3r-secret", "alice"); !errors.As(err, &violation) {
+		t.Fatalf("泄露的密码应当被拒绝，实际: %v", err)
+	}
+	if err := p.Check("Correct-Horse-9", "alice"); err != nil {
+		t.Fatalf("未泄露的密码应当通过，实际: %v", err)
+	}
+	if rules := p.Rules(); rules["breach_check"] != true {
+		t.Fatalf("策略说明应当包含泄露检查: %v", rules)
+	}
+}
@@ -0,0 +1,196 @@
+package router
+
+import (
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"todolist/handlers"
+	"todolist/middleware"
+	"todolist/models"
+)
+
+// New 创建并注册所有路由的 Gin 引擎
+func New() *gin.Engine {
+	// 创建 Gin 引擎
+	r := gin.Default()
+
+	// 添加 CORS 中间件
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowAllOrigins = true
+	corsConfig.AllowCredentials = true
+	corsConfig.AllowHeaders = []string{
+		"Origin",
+		"Content-Length",
+		"Content-Type",
+		"Authorization",
+		"Accept",
+		"X-Requested-With",
+		"Access-Control-Allow-Origin",
+		"Access-Control-Allow-Headers",
+		"Access-Control-Allow-Methods",
+	}
+	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+	corsConfig.ExposeHeaders = []string{"Content-Length"}
+	r.Use(cors.New(corsConfig))
+
+	// 使用日志中间件
+	r.Use(middleware.Logger())
+
+	// 用户相关路由
+	auth := r.Group("/auth")
+	{
+		auth.GET("/registration", handlers.GetRegistrationPolicy)
+		auth.POST("/register", handlers.Register)
+		auth.POST("/login", handlers.Login)
+		auth.POST("/refresh", handlers.RefreshToken)
+		auth.PUT("/password", middleware.AuthMiddleware(), handlers.UpdatePassword)
+		auth.GET("/password/policy", handlers.GetPasswordPolicy)
+		auth.POST("/password/forgot", handlers.ForgotPassword)
+		auth.POST("/password/reset", handlers.ResetPassword)
+		auth.PUT("/email", middleware.AuthMiddleware(), handlers.UpdateEmail)
+		auth.POST("/email/verify", handlers.VerifyEmail)
+		auth.POST("/email/verify/resend", handlers.ResendEmailVerification)
+		auth.POST("/logout", middleware.AuthMiddleware(), handlers.Logout)
+		auth.GET("/sessions", middleware.AuthMiddleware(), handlers.GetSessions)
+		auth.DELETE("/sessions/:id", middleware.AuthMiddleware(), handlers.DeleteSession)
+		auth.GET("/oidc/login", handlers.OIDCLogin)
+		auth.GET("/oidc/callback", handlers.OIDCCallback)
+		auth.POST("/oidc/link", middleware.AuthMiddleware(), handlers.OIDCLink)
+		auth.POST("/mfa/verify", handlers.VerifyMFA)
+		auth.POST("/mfa/setup", middleware.AuthMiddleware(), handlers.SetupMFA)
+		auth.POST("/mfa/enable", middleware.AuthMiddleware(), handlers.EnableMFA)
+		auth.POST("/mfa/disable", middleware.AuthMiddleware(), handlers.DisableMFA)
+		auth.POST("/mfa/recovery-codes", middleware.AuthMiddleware(), handlers.RegenerateRecoveryCodes)
+		auth.POST("/tokens", middleware.AuthMiddleware(), handlers.CreateAPIToken)
+		auth.GET("/tokens", middleware.AuthMiddleware(), handlers.GetAPITokens)
+		auth.DELETE("/tokens/:id", middleware.AuthMiddleware(), handlers.DeleteAPIToken)
+	}
+
+	// 个人资料路由（需要认证）
+	me := r.Group("/me")
+	me.Use(middleware.AuthMiddleware())
+	{
+		me.GET("", handlers.GetProfile)
+		me.PUT("", handlers.UpdateProfile)
+		me.DELETE("", handlers.DeleteAccount)
+		me.DELETE("/deletion", handlers.CancelAccountDeletion)
+		me.GET("/export", handlers.ExportData)
+		me.POST("/avatar", handlers.UploadAvatar)
+		me.DELETE("/avatar", handlers.DeleteAvatar)
+		me.GET("/notification-settings", handlers.GetNotificationSettings)
+		me.PUT("/notification-settings", handlers.UpdateNotificationSettings)
+	}
+	r.GET("/avatars/:file", handlers.GetAvatar)
+
+	// 管理员路由（需要认证，各接口按角色权限控制）
+	perm := middleware.RequirePermission
+	admin := r.Group("/admin")
+	admin.Use(middleware.AuthMiddleware())
+	{
+		admin.GET("/users", perm(models.PermUsersRead), handlers.GetUsers)
+		admin.POST("/users/:id/activate", perm(models.PermUsersActivate), handlers.ActivateUser)
+		admin.POST("/users/:id/block", perm(models.PermUsersBlock), handlers.BlockUser)
+		admin.PUT("/users/:id/role", perm(models.PermUsersAssignRole), handlers.UpdateUserRole)
+		admin.PUT("/users/:id/password", perm(models.PermUsersResetPassword), handlers.AdminUpdateUserPassword)
+		admin.POST("/users/:id/password/expire", perm(models.PermUsersResetPassword), handlers.ExpireUserPassword)
+		admin.POST("/users/:id/impersonate", perm(models.PermUsersImpersonate), handlers.ImpersonateUser)
+		admin.DELETE("/users/:id", perm(models.PermUsersDelete), handlers.DeleteUser)
+		admin.POST("/users/:id/sessions/revoke", perm(models.PermUsersManageSecurity), handlers.RevokeUserSessions)
+		admin.DELETE("/users/:id/lockout", perm(models.PermUsersManageSecurity), handlers.ClearUserLockout)
+		admin.DELETE("/users/:id/mfa", perm(models.PermUsersManageSecurity), handlers.ResetUserMFA)
+
+		admin.GET("/invites", perm(models.PermUsersInvite), handlers.GetInvites)
+		admin.POST("/invites", perm(models.PermUsersInvite), handlers.CreateInvite)
+		admin.DELETE("/invites/:id", perm(models.PermUsersInvite), handlers.DeleteInvite)
+
+		admin.GET("/permissions", perm(models.PermRolesManage), handlers.GetPermissions)
+		admin.GET("/roles", perm(models.PermRolesManage), handlers.GetRoles)
+		admin.POST("/roles", perm(models.PermRolesManage), handlers.CreateRole)
+		admin.PUT("/roles/:id", perm(models.PermRolesManage), handlers.UpdateRole)
+		admin.DELETE("/roles/:id", perm(models.PermRolesManage), handlers.DeleteRole)
+
+		admin.GET("/audit", perm(models.PermAuditRead), handlers.GetAuditEvents)
+		admin.GET("/audit/export", perm(models.PermAuditRead), handlers.ExportAuditEvents)
+	}
+
+	// Todo相关路由（需要认证，个人访问令牌需要对应的 scope）
+	todosRead := middleware.AuthMiddleware(models.ScopeTodosRead)
+	todosWrite := middleware.AuthMiddleware(models.ScopeTodosWrite)
+	todos := r.Group("/todos")
+	{
+		todos.POST("", todosWrite, handlers.CreateTodo)
+		todos.GET("", todosRead, handlers.GetTodos)
+		todos.GET("/:id", todosRead, handlers.GetTodo)
+		todos.PUT("/:id", todosWrite, handlers.UpdateTodo)
+		todos.DELETE("/:id", todosWrite, handlers.DeleteTodo)
+
+		todos.GET("/:id/checklist", todosRead, handlers.GetChecklist)
+		todos.POST("/:id/checklist", todosWrite, handlers.CreateChecklistItem)
+		todos.PUT("/:id/checklist/order", todosWrite, handlers.ReorderChecklist)
+		todos.PUT("/:id/checklist/:itemId", todosWrite, handlers.UpdateChecklistItem)
+		todos.DELETE("/:id/checklist/:itemId", todosWrite, handlers.DeleteChecklistItem)
+
+		todos.GET("/:id/occurrences", todosRead, handlers.GetTodoOccurrences)
+
+		todos.GET("/:id/reminders", todosRead, handlers.GetReminders)
+		todos.POST("/:id/reminders", todosWrite, handlers.CreateReminder)
+		todos.PUT("/:id/reminders/:reminderId", todosWrite, handlers.UpdateReminder)
+		todos.DELETE("/:id/reminders/:reminderId", todosWrite, handlers.DeleteReminder)
+	}
+
+	// 项目路由（需要认证，查看和修改项目的权限同待办事项的读写；删除项目、成员和邀请管理
+	// 会把项目授权给其他人，个人访问令牌需要单独的 projects:admin；项目内的操作另按成员角色控制）
+	projectsAdmin := middleware.AuthMiddleware(models.ScopeProjectsAdmin)
+	projects := r.Group("/projects")
+	{
+		projects.GET("", todosRead, handlers.GetProjects)
+		projects.POST("", todosWrite, handlers.CreateProject)
+		projects.GET("/:id", todosRead, handlers.GetProject)
+		projects.PUT("/:id", todosWrite, handlers.UpdateProject)
+		projects.DELETE("/:id", projectsAdmin, handlers.DeleteProject)
+
+		projects.GET("/:id/members", todosRead, handlers.GetProjectMembers)
+		projects.PUT("/:id/members/:userId", projectsAdmin, handlers.UpdateProjectMember)
+		projects.DELETE("/:id/members/:userId", projectsAdmin, handlers.DeleteProjectMember)
+
+		projects.GET("/:id/invitations", todosRead, handlers.GetProjectInvitations)
+		projects.POST("/:id/invitations", projectsAdmin, handlers.CreateProjectInvitation)
+		projects.DELETE("/:id/invitations/:invitationId", projectsAdmin, handlers.DeleteProjectInvitation)
+	}
+
+	// 当前用户收到的项目邀请
+	invitations := r.Group("/project-invitations")
+	{
+		invitations.GET("", todosRead, handlers.GetMyProjectInvitations)
+		invitations.POST("/:id/accept", projectsAdmin, handlers.AcceptProjectInvitation)
+		invitations.POST("/:id/decline", projectsAdmin, handlers.DeclineProjectInvitation)
+	}
+
+	// 站内通知路由（需要认证）
+	notifications := r.Group("/notifications")
+	notifications.Use(middleware.AuthMiddleware())
+	{
+		notifications.GET("", handlers.GetNotifications)
+		notifications.POST("/read-all", handlers.MarkAllNotificationsRead)
+		notifications.PUT("/:id", handlers.UpdateNotification)
+		notifications.DELETE("/:id", handlers.DeleteNotification)
+	}
+
+	// 访问其他用户的待办事项（需要对应权限，所有访问记录审计日志）
+	users := r.Group("/users")
+	users.Use(middleware.AuthMiddleware())
+	{
+		users.GET("/:id/todos", perm(models.PermTodosReadAny), handlers.GetUserTodos)
+		users.GET("/:id/todos/:todoId", perm(models.PermTodosReadAny), handlers.GetUserTodo)
+		users.PUT("/:id/todos/:todoId", perm(models.PermTodosWriteAny), handlers.UpdateUserTodo)
+		users.DELETE("/:id/todos/:todoId", perm(models.PermTodosWriteAny), handlers.DeleteUserTodo)
+	}
+
+	// AI识别路由（需要认证）
+	ai := r.Group("/ai")
+	ai.Use(middleware.AuthMiddleware(models.ScopeAIProcess))
+	{
+		ai.POST("/process", handlers.ProcessAI)
+	}
+
+	return r
+}
@@ -0,0 +1,449 @@
+// Package rrule 解析和展开 RFC 5545 重复规则（RRULE）的常用子集：
+// FREQ=DAILY/WEEKLY/MONTHLY、INTERVAL、BYDAY、BYMONTHDAY、BYMONTH、COUNT、UNTIL 和 WKST
+package rrule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency 重复频率
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+// maxEmptyPeriods 连续这么多个周期没有符合规则的日期时停止展开，避免 BYMONTHDAY=30 配合 2 月这类规则死循环
+const maxEmptyPeriods = 1000
+
+var weekdayNames = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// WeekdayNum BYDAY 的一项，N 为月内第几个（负数从月末倒数），0 表示每一个
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+func (w WeekdayNum) String() string {
+	if w.N == 0 {
+		return weekdayNames[w.Weekday]
+	}
+	return strconv.Itoa(w.N) + weekdayNames[w.Weekday]
+}
+
+// Rule 重复规则
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	// ByMonth 只保留这些月份的日期，为空时不限
+	ByMonth []time.Month
+	// Count 总次数（包含开始时间本身），0 表示不限
+	Count int
+	// Until 最后一次的时间（包含），零值表示不限
+	Until time.Time
+	// UntilDate UNTIL 只有日期时为 true，按开始时间所在时区包含当天
+	UntilDate bool
+	WeekStart time.Weekday
+}
+
+// Parse 解析 RRULE 字符串，允许带 "RRULE:" 前缀，错误信息可直接展示给用户
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	r := &Rule{Interval: 1, WeekStart: time.Monday}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || value == "" {
+			return nil, fmt.Errorf("重复规则格式无效: %s", part)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("重复规则中 %s 重复出现", key)
+		}
+		seen[key] = true
+
+		var err error
+		switch key {
+		case "FREQ":
+			switch f := Frequency(value); f {
+			case Daily, Weekly, Monthly:
+				r.Freq = f
+			default:
+				return nil, fmt.Errorf("不支持的重复频率: %s", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = parsePositive(key, value)
+		case "COUNT":
+			r.Count, err = parsePositive(key, value)
+		case "UNTIL":
+			err = r.parseUntil(value)
+		case "BYDAY":
+			r.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseByMonthDay(value)
+		case "BYMONTH":
+			r.ByMonth, err = parseByMonth(value)
+		case "WKST":
+			var wd WeekdayNum
+			if wd, err = parseWeekday(value); err == nil && wd.N != 0 {
+				err = fmt.Errorf("WKST 无效: %s", value)
+			}
+			r.WeekStart = wd.Weekday
+		default:
+			return nil, fmt.Errorf("不支持的重复规则项: %s", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Rule) validate() error {
+	if r.Freq == "" {
+		return fmt.Errorf("重复规则缺少 FREQ")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return fmt.Errorf("COUNT 和 UNTIL 不能同时使用")
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq != Monthly {
+		return fmt.Errorf("BYMONTHDAY 只能用于 FREQ=MONTHLY")
+	}
+	for _, wd := range r.ByDay {
+		if wd.N != 0 && r.Freq != Monthly {
+			return fmt.Errorf("BYDAY 带序号只能用于 FREQ=MONTHLY")
+		}
+	}
+	return nil
+}
+
+func parsePositive(key, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s 必须是正整数", key)
+	}
+	return n, nil
+}
+
+func (r *Rule) parseUntil(value string) error {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		r.Until = t
+		return nil
+	}
+	if t, err := time.Parse("20060102", value); err == nil {
+		r.Until = t
+		r.UntilDate = true
+		return nil
+	}
+	return fmt.Errorf("UNTIL 格式无效，应为 YYYYMMDD 或 YYYYMMDDTHHMMSSZ")
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, item := range strings.Split(value, ",") {
+		wd, err := parseWeekday(item)
+		if err != nil {
+			return nil, err
+		}
+		days = append(days, wd)
+	}
+	return days, nil
+}
+
+func parseWeekday(s string) (WeekdayNum, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 {
+		return WeekdayNum{}, fmt.Errorf("星期无效: %s", s)
+	}
+	name := s[len(s)-2:]
+	for i, n := range weekdayNames {
+		if n != name {
+			continue
+		}
+		wd := WeekdayNum{Weekday: time.Weekday(i)}
+		if prefix := s[:len(s)-2]; prefix != "" {
+			num, err := strconv.Atoi(prefix)
+			if err != nil || num == 0 || num < -5 || num > 5 {
+				return WeekdayNum{}, fmt.Errorf("星期序号无效: %s", s)
+			}
+			wd.N = num
+		}
+		return wd, nil
+	}
+	return WeekdayNum{}, fmt.Errorf("星期无效: %s", s)
+}
+
+func parseByMonthDay(value string) ([]int, error) {
+	var days []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || n == 0 || n < -31 || n > 31 {
+			return nil, fmt.Errorf("BYMONTHDAY 无效: %s", item)
+		}
+		days = append(days, n)
+	}
+	return days, nil
+}
+
+func parseByMonth(value string) ([]time.Month, error) {
+	var months []time.Month
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || n < 1 || n > 12 {
+			return nil, fmt.Errorf("BYMONTH 无效: %s", item)
+		}
+		months = append(months, time.Month(n))
+	}
+	return months, nil
+}
+
+// String 返回规范化的 RRULE 字符串（不带 "RRULE:" 前缀）
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, wd := range r.ByDay {
+			days[i] = wd.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, d := range r.ByMonthDay {
+			days[i] = strconv.Itoa(d)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonth) > 0 {
+		months := make([]string, len(r.ByMonth))
+		for i, m := range r.ByMonth {
+			months[i] = strconv.Itoa(int(m))
+		}
+		parts = append(parts, "BYMONTH="+strings.Join(months, ","))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayNames[r.WeekStart])
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		if r.UntilDate {
+			parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// Iterator 按时间顺序逐个返回重复日期
+type Iterator struct {
+	rule    *Rule
+	dtstart time.Time
+	until   time.Time
+	period  int
+	buf     []time.Time
+	n       int
+	empty   int
+	started bool
+	done    bool
+}
+
+// Iter 从 dtstart 开始展开规则，按 dtstart 所在时区计算星期、日期和夏令时。
+// 与 RFC 5545 一致，dtstart 本身总是第一次，即使它不符合规则
+func (r *Rule) Iter(dtstart time.Time) *Iterator {
+	it := &Iterator{rule: r, dtstart: dtstart, until: r.Until}
+	if r.UntilDate {
+		// 只有日期的 UNTIL 包含当天，换算为次日零点之前
+		it.until = time.Date(r.Until.Year(), r.Until.Month(), r.Until.Day()+1, 0, 0, 0, 0, dtstart.Location()).Add(-time.Nanosecond)
+	}
+	return it
+}
+
+// Next 返回下一次的时间，规则结束时返回 false
+func (it *Iterator) Next() (time.Time, bool) {
+	for !it.done {
+		if it.rule.Count > 0 && it.n >= it.rule.Count {
+			it.done = true
+			break
+		}
+		if !it.started {
+			it.started = true
+			return it.emit(it.dtstart)
+		}
+		if len(it.buf) == 0 {
+			if it.empty >= maxEmptyPeriods {
+				it.done = true
+				break
+			}
+			it.buf = it.expand(it.period)
+			it.period++
+			if len(it.buf) == 0 {
+				it.empty++
+			} else {
+				it.empty = 0
+			}
+			continue
+		}
+		t := it.buf[0]
+		it.buf = it.buf[1:]
+		if t.After(it.dtstart) {
+			return it.emit(t)
+		}
+	}
+	return time.Time{}, false
+}
+
+func (it *Iterator) emit(t time.Time) (time.Time, bool) {
+	if !it.until.IsZero() && t.After(it.until) {
+		it.done = true
+		return time.Time{}, false
+	}
+	it.n++
+	return t, true
+}
+
+// expand 返回第 k 个周期内符合规则的全部时间，按时间排序
+func (it *Iterator) expand(k int) []time.Time {
+	times := it.expandPeriod(k)
+	if len(it.rule.ByMonth) == 0 {
+		return times
+	}
+	kept := times[:0]
+	for _, t := range times {
+		if it.rule.hasMonth(t.Month()) {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// expandPeriod 返回第 k 个周期内符合 BYDAY、BYMONTHDAY 的全部时间
+func (it *Iterator) expandPeriod(k int) []time.Time {
+	r := it.rule
+	start := it.dtstart
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	}
+
+	switch r.Freq {
+	case Daily:
+		t := at(start.Year(), start.Month(), start.Day()+k*r.Interval)
+		if len(r.ByDay) > 0 && !r.hasWeekday(t.Weekday()) {
+			return nil
+		}
+		return []time.Time{t}
+
+	case Weekly:
+		offset := (int(start.Weekday()) - int(r.WeekStart) + 7) % 7
+		first := start.Day() - offset + 7*k*r.Interval
+		var times []time.Time
+		for i := 0; i < 7; i++ {
+			t := at(start.Year(), start.Month(), first+i)
+			if len(r.ByDay) == 0 && t.Weekday() == start.Weekday() || r.hasWeekday(t.Weekday()) {
+				times = append(times, t)
+			}
+		}
+		return times
+
+	case Monthly:
+		month := time.Date(start.Year(), start.Month()+time.Month(k*r.Interval), 1, 0, 0, 0, 0, start.Location())
+		var times []time.Time
+		for _, day := range r.monthDays(month, start.Day()) {
+			times = append(times, at(month.Year(), month.Month(), day))
+		}
+		return times
+	}
+	return nil
+}
+
+func (r *Rule) hasMonth(m time.Month) bool {
+	for _, month := range r.ByMonth {
+		if month == m {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) hasWeekday(wd time.Weekday) bool {
+	for _, d := range r.ByDay {
+		if d.Weekday == wd {
+			return true
+		}
+	}
+	return false
+}
+
+// monthDays 返回 month 所在月份中符合 BYMONTHDAY 和 BYDAY 的日期，两者同时存在时取交集；
+// 都没有时使用开始时间的日期，该月没有这一天则跳过
+func (r *Rule) monthDays(month time.Time, startDay int) []int {
+	dim := time.Date(month.Year(), month.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		if startDay > dim {
+			return nil
+		}
+		return []int{startDay}
+	}
+
+	var byMonthDay, byDay map[int]bool
+	if len(r.ByMonthDay) > 0 {
+		byMonthDay = map[int]bool{}
+		for _, d := range r.ByMonthDay {
+			if d < 0 {
+				d = dim + d + 1
+			}
+			if d >= 1 && d <= dim {
+				byMonthDay[d] = true
+			}
+		}
+	}
+	if len(r.ByDay) > 0 {
+		byDay = map[int]bool{}
+		firstWeekday := month.Weekday()
+		for _, wd := range r.ByDay {
+			first := 1 + (int(wd.Weekday)-int(firstWeekday)+7)%7
+			switch {
+			case wd.N == 0:
+				for d := first; d <= dim; d += 7 {
+					byDay[d] = true
+				}
+			case wd.N > 0:
+				if d := first + 7*(wd.N-1); d <= dim {
+					byDay[d] = true
+				}
+			default:
+				last := first + 7*((dim-first)/7)
+				if d := last + 7*(wd.N+1); d >= 1 {
+					byDay[d] = true
+				}
+			}
+		}
+	}
+
+	var days []int
+	for d := 1; d <= dim; d++ {
+		if (byMonthDay == nil || byMonthDay[d]) && (byDay == nil || byDay[d]) {
+			days = append(days, d)
+		}
+	}
+	return days
+}
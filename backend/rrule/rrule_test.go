@@ -0,0 +1,157 @@
+package rrule
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// take 展开规则，返回至多 n 个时间（RFC 3339 格式，带时区偏移）
+func take(t *testing.T, rule string, dtstart time.Time, n int) []string {
+	t.Helper()
+	r, err := Parse(rule)
+	if err != nil {
+		t.Fatalf("解析 %s 失败: %v", rule, err)
+	}
+	got := []string{}
+	it := r.Iter(dtstart)
+	for v, ok := it.Next(); ok && len(got) < n; v, ok = it.Next() {
+		got = append(got, v.Format(time.RFC3339))
+	}
+	return got
+}
+
+func TestIter(t *testing.T) {
+	cases := []struct {
+		name    string
+		rule    string
+		dtstart string
+		// ends 规则自身会结束，检查展开的全部结果；否则只检查前 len(want) 个
+		ends bool
+		want []string
+	}{
+		{"daily count", "FREQ=DAILY;COUNT=3", "2024-01-30T09:00:00Z", true,
+			[]string{"2024-01-30T09:00:00Z", "2024-01-31T09:00:00Z", "2024-02-01T09:00:00Z"}},
+		{"interval until date", "FREQ=DAILY;INTERVAL=2;UNTIL=20240205", "2024-01-30T09:00:00Z", true,
+			[]string{"2024-01-30T09:00:00Z", "2024-02-01T09:00:00Z", "2024-02-03T09:00:00Z", "2024-02-05T09:00:00Z"}},
+		{"until datetime", "FREQ=DAILY;UNTIL=20240201T085959Z", "2024-01-30T09:00:00Z", true,
+			[]string{"2024-01-30T09:00:00Z", "2024-01-31T09:00:00Z"}},
+		{"weekly byday", "FREQ=WEEKLY;BYDAY=MO,WE,FR", "2024-01-01T10:00:00Z", false,
+			[]string{"2024-01-01T10:00:00Z", "2024-01-03T10:00:00Z", "2024-01-05T10:00:00Z", "2024-01-08T10:00:00Z"}},
+		{"biweekly count", "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH;COUNT=5", "2024-01-02T10:00:00Z", true,
+			[]string{"2024-01-02T10:00:00Z", "2024-01-04T10:00:00Z", "2024-01-16T10:00:00Z", "2024-01-18T10:00:00Z", "2024-01-30T10:00:00Z"}},
+		{"week start sunday", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,SU;WKST=SU", "2024-01-01T10:00:00Z", false,
+			[]string{"2024-01-01T10:00:00Z", "2024-01-14T10:00:00Z", "2024-01-15T10:00:00Z", "2024-01-28T10:00:00Z"}},
+		{"dtstart outside rule", "FREQ=WEEKLY;BYDAY=MO", "2024-01-03T10:00:00Z", false,
+			[]string{"2024-01-03T10:00:00Z", "2024-01-08T10:00:00Z", "2024-01-15T10:00:00Z"}},
+		{"last friday", "FREQ=MONTHLY;BYDAY=-1FR", "2024-01-26T18:00:00Z", false,
+			[]string{"2024-01-26T18:00:00Z", "2024-02-23T18:00:00Z", "2024-03-29T18:00:00Z", "2024-04-26T18:00:00Z"}},
+		{"second tuesday", "FREQ=MONTHLY;BYDAY=2TU;COUNT=3", "2024-01-09T08:00:00Z", true,
+			[]string{"2024-01-09T08:00:00Z", "2024-02-13T08:00:00Z", "2024-03-12T08:00:00Z"}},
+		{"last day of month", "FREQ=MONTHLY;BYMONTHDAY=-1", "2024-01-31T12:00:00Z", false,
+			[]string{"2024-01-31T12:00:00Z", "2024-02-29T12:00:00Z", "2024-03-31T12:00:00Z", "2024-04-30T12:00:00Z"}},
+		{"monthly skips short months", "FREQ=MONTHLY", "2024-01-31T12:00:00Z", false,
+			[]string{"2024-01-31T12:00:00Z", "2024-03-31T12:00:00Z", "2024-05-31T12:00:00Z"}},
+		{"friday the 13th", "FREQ=MONTHLY;BYMONTHDAY=13;BYDAY=FR", "2024-09-13T00:00:00Z", false,
+			[]string{"2024-09-13T00:00:00Z", "2024-12-13T00:00:00Z", "2025-06-13T00:00:00Z"}},
+		{"bymonth monthly", "FREQ=MONTHLY;BYMONTH=1,7;BYMONTHDAY=1", "2024-01-01T09:00:00Z", false,
+			[]string{"2024-01-01T09:00:00Z", "2024-07-01T09:00:00Z", "2025-01-01T09:00:00Z", "2025-07-01T09:00:00Z"}},
+		{"bymonth daily", "FREQ=DAILY;BYMONTH=2;COUNT=3", "2024-02-28T09:00:00Z", true,
+			[]string{"2024-02-28T09:00:00Z", "2024-02-29T09:00:00Z", "2025-02-01T09:00:00Z"}},
+		{"impossible rule stops", "FREQ=MONTHLY;BYMONTH=2;BYMONTHDAY=30", "2024-01-30T09:00:00Z", true,
+			[]string{"2024-01-30T09:00:00Z"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dtstart, err := time.Parse(time.RFC3339, tc.dtstart)
+			if err != nil {
+				t.Fatal(err)
+			}
+			n := len(tc.want)
+			if tc.ends {
+				n = 100
+			}
+			if got := take(t, tc.rule, dtstart, n); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("\n得到 %v\n期望 %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// 按开始时间所在时区展开，跨越夏令时切换时保持当地的同一时刻
+func TestIterDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("缺少时区数据: %v", err)
+	}
+	cases := []struct {
+		rule    string
+		dtstart time.Time
+		want    []string
+	}{
+		// 3 月 10 日开始夏令时
+		{"FREQ=DAILY", time.Date(2024, 3, 9, 9, 0, 0, 0, loc),
+			[]string{"2024-03-09T09:00:00-05:00", "2024-03-10T09:00:00-04:00", "2024-03-11T09:00:00-04:00"}},
+		// 11 月 3 日结束夏令时
+		{"FREQ=WEEKLY;BYDAY=SA,SU", time.Date(2024, 10, 26, 23, 30, 0, 0, loc),
+			[]string{"2024-10-26T23:30:00-04:00", "2024-10-27T23:30:00-04:00", "2024-11-02T23:30:00-04:00", "2024-11-03T23:30:00-05:00"}},
+		// 只有日期的 UNTIL 按开始时间的时区包含当天
+		{"FREQ=DAILY;UNTIL=20241104", time.Date(2024, 11, 2, 22, 0, 0, 0, loc),
+			[]string{"2024-11-02T22:00:00-04:00", "2024-11-03T22:00:00-05:00", "2024-11-04T22:00:00-05:00"}},
+	}
+	for _, tc := range cases {
+		if got := take(t, tc.rule, tc.dtstart, 100); !reflect.DeepEqual(got[:min(len(got), len(tc.want))], tc.want) {
+			t.Errorf("%s\n得到 %v\n期望 %v", tc.rule, got, tc.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, rule := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=-1",
+		"FREQ=DAILY;COUNT=3;UNTIL=20240101",
+		"FREQ=DAILY;UNTIL=2024-01-01",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;BYMONTH=13",
+		"FREQ=MONTHLY;BYMONTH=0",
+		"FREQ=DAILY;WKST=1MO",
+		"FREQ=DAILY;BYSETPOS=1",
+		"FREQ",
+	} {
+		if _, err := Parse(rule); err == nil {
+			t.Errorf("%q 应当解析失败", rule)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	cases := map[string]string{
+		"RRULE:freq=weekly;byday=mo,we":              "FREQ=WEEKLY;BYDAY=MO,WE",
+		"FREQ=MONTHLY;INTERVAL=2;BYDAY=-1FR;COUNT=4": "FREQ=MONTHLY;INTERVAL=2;BYDAY=-1FR;COUNT=4",
+		"FREQ=MONTHLY;BYMONTH=1,7;BYMONTHDAY=15":     "FREQ=MONTHLY;BYMONTHDAY=15;BYMONTH=1,7",
+		"FREQ=DAILY;INTERVAL=1;UNTIL=20240301":       "FREQ=DAILY;UNTIL=20240301",
+		"FREQ=WEEKLY;WKST=SU;UNTIL=20240301T120000Z": "FREQ=WEEKLY;WKST=SU;UNTIL=20240301T120000Z",
+	}
+	for in, want := range cases {
+		r, err := Parse(in)
+		if err != nil {
+			t.Fatalf("解析 %s 失败: %v", in, err)
+		}
+		if got := r.String(); got != want {
+			t.Errorf("%s: 得到 %s，期望 %s", in, got, want)
+		}
+		// 规范化后的字符串重新解析结果不变
+		if again, err := Parse(r.String()); err != nil || again.String() != want {
+			t.Errorf("%s 重新解析不一致: %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"todolist/config"
+)
+
+// Hook 关闭时执行的清理函数
+type Hook struct {
+	Name string
+	Fn   func(ctx context.Context) error
+}
+
+// Server 带超时配置和优雅退出的 HTTP 服务
+type Server struct {
+	httpServer *http.Server
+	cfg        config.ServerConfig
+
+	mu    sync.Mutex
+	hooks []Hook
+}
+
+// New 根据配置创建服务
+func New(cfg config.ServerConfig, handler http.Handler) *Server {
+	return &Server{
+		cfg: cfg,
+		httpServer: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           handler,
+			ReadTimeout:       cfg.ReadTimeout.Std(),
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout.Std(),
+			WriteTimeout:      cfg.WriteTimeout.Std(),
+			IdleTimeout:       cfg.IdleTimeout.Std(),
+		},
+	}
+}
+
+// OnShutdown 注册关闭钩子，HTTP 请求排空后按注册的逆序执行
+func (s *Server) OnShutdown(name string, fn func(ctx context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, Hook{Name: name, Fn: fn})
+}
+
+// Run 启动服务并阻塞，直到收到 SIGINT/SIGTERM 或监听失败
+func (s *Server) Run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return s.RunContext(ctx)
+}
+
+// RunContext 启动服务并阻塞，直到 ctx 被取消或监听失败
+func (s *Server) RunContext(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("HTTP服务已启动: %s", s.cfg.Addr)
+		if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	var serveErr error
+	select {
+	case serveErr = <-errCh:
+		if serveErr == nil {
+			return nil
+		}
+	case <-ctx.Done():
+		log.Printf("收到退出信号，等待处理中的请求完成（最长 %s）", s.cfg.ShutdownTimeout.Std())
+	}
+
+	return errors.Join(serveErr, s.shutdown())
+}
+
+// shutdown 停止接收新请求、排空处理中的请求，然后依次执行关闭钩子
+func (s *Server) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout.Std())
+	defer cancel()
+
+	var errs []error
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		errs = append(errs, err)
+		log.Printf("HTTP服务关闭失败: %v", err)
+	}
+
+	s.mu.Lock()
+	hooks := append([]Hook(nil), s.hooks...)
+	s.mu.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		if err := h.Fn(ctx); err != nil {
+			errs = append(errs, err)
+			log.Printf("关闭 %s 失败: %v", h.Name, err)
+			continue
+		}
+		log.Printf("已关闭 %s", h.Name)
+	}
+	return errors.Join(errs...)
+}
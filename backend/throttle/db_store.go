@@ -0,0 +1,126 @@
+package throttle
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoginThrottle 数据库中的限流记录
+type LoginThrottle struct {
+	Key         string `gorm:"column:throttle_key;primaryKey;size:191"`
+	Failures    string `gorm:"type:text"` // 失败时间的JSON数组
+	LockedUntil *time.Time
+	UpdatedAt   time.Time
+}
+
+func (LoginThrottle) TableName() string {
+	return "login_throttles"
+}
+
+// DBStore 基于数据库的存储，多个实例共享限流状态
+type DBStore struct {
+	db *gorm.DB
+}
+
+// NewDBStore 创建数据库存储
+func NewDBStore(db *gorm.DB) *DBStore {
+	return &DBStore{db: db}
+}
+
+func (s *DBStore) Get(key string) (*Entry, error) {
+	var row LoginThrottle
+	if err := s.db.Where("throttle_key = ?", key).First(&row).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return row.toEntry()
+}
+
+// Update 在事务中先确保记录存在，再用 SELECT ... FOR UPDATE 锁定该行后修改，
+// 多个实例同时记录失败时依次执行（SQLite 在插入时即获得写锁）
+func (s *DBStore) Update(key string, fn func(e *Entry)) (*Entry, error) {
+	var entry *Entry
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&LoginThrottle{Key: key, Failures: "[]"}).Error; err != nil {
+			return err
+		}
+		var row LoginThrottle
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("throttle_key = ?", key).First(&row).Error; err != nil {
+			return err
+		}
+		e, err := row.toEntry()
+		if err != nil {
+			return err
+		}
+		fn(e)
+
+		failures, err := json.Marshal(e.Failures)
+		if err != nil {
+			return err
+		}
+		var lockedUntil *time.Time
+		if !e.LockedUntil.IsZero() {
+			t := e.LockedUntil.UTC()
+			lockedUntil = &t
+		}
+		entry = e
+		return tx.Model(&LoginThrottle{}).Where("throttle_key = ?", key).Updates(map[string]interface{}{
+			"failures":     string(failures),
+			"locked_until": lockedUntil,
+			"updated_at":   time.Now().UTC(),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *DBStore) Delete(key string) error {
+	return s.db.Where("throttle_key = ?", key).Delete(&LoginThrottle{}).Error
+}
+
+func (s *DBStore) ListLocked(now time.Time) ([]Entry, error) {
+	var rows []LoginThrottle
+	if err := s.db.Where("locked_until > ?", now.UTC()).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(rows))
+	for _, row := range rows {
+		e, err := row.toEntry()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *e)
+	}
+	return entries, nil
+}
+
+// Prune 删除最后一次失败已在窗口外且锁定已结束的记录。记录的每次修改都会刷新 updated_at，
+// updated_at 早于窗口起点说明其中的失败都已过期
+func (s *DBStore) Prune(now time.Time, window time.Duration) (int, error) {
+	result := s.db.Where("updated_at < ? AND (locked_until IS NULL OR locked_until <= ?)",
+		now.Add(-window).UTC(), now.UTC()).Delete(&LoginThrottle{})
+	return int(result.RowsAffected), result.Error
+}
+
+func (row *LoginThrottle) toEntry() (*Entry, error) {
+	e := &Entry{Key: row.Key}
+	if row.Failures != "" {
+		if err := json.Unmarshal([]byte(row.Failures), &e.Failures); err != nil {
+			return nil, err
+		}
+	}
+	if row.LockedUntil != nil {
+		e.LockedUntil = *row.LockedUntil
+	}
+	return e, nil
+}
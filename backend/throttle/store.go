@@ -0,0 +1,134 @@
+package throttle
+
+import (
+	"sync"
+	"time"
+)
+
+// Entry 某个限流键（IP或用户名）的失败记录
+type Entry struct {
+	Key         string
+	Failures    []time.Time
+	LockedUntil time.Time
+}
+
+// prune 丢弃窗口外的失败记录
+func (e *Entry) prune(now time.Time, window time.Duration) {
+	cutoff := now.Add(-window)
+	kept := e.Failures[:0]
+	for _, t := range e.Failures {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	e.Failures = kept
+}
+
+// empty 没有失败记录且未锁定，可以删除
+func (e *Entry) empty(now time.Time) bool {
+	return len(e.Failures) == 0 && !e.LockedUntil.After(now)
+}
+
+// Store 限流状态存储，多实例部署时应使用数据库存储
+type Store interface {
+	// Get 获取记录，不存在时返回 nil
+	Get(key string) (*Entry, error)
+	// Update 原子地读取、修改并保存记录，记录不存在时 fn 收到空记录；返回修改后的记录。
+	// 并发调用同一 key 时依次执行，不会丢失更新
+	Update(key string, fn func(e *Entry)) (*Entry, error)
+	Delete(key string) error
+	// ListLocked 列出锁定尚未结束的记录
+	ListLocked(now time.Time) ([]Entry, error)
+	// Prune 删除窗口内没有失败且未锁定的记录，返回删除的数量
+	Prune(now time.Time, window time.Duration) (int, error)
+}
+
+// MemoryStore 进程内存储，重启后状态丢失，仅适用于单实例部署
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]Entry
+	ttl       time.Duration
+	lastSweep time.Time
+}
+
+// NewMemoryStore 创建内存存储，ttl 用于定期清理过期记录
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{entries: make(map[string]Entry), ttl: ttl}
+}
+
+func (s *MemoryStore) Get(key string) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	e.Failures = append([]time.Time(nil), e.Failures...)
+	return &e, nil
+}
+
+func (s *MemoryStore) Update(key string, fn func(e *Entry)) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok {
+		e = Entry{Key: key}
+	}
+	e.Failures = append([]time.Time(nil), e.Failures...)
+	fn(&e)
+	s.entries[key] = e
+
+	result := e
+	result.Failures = append([]time.Time(nil), e.Failures...)
+	s.sweep(time.Now())
+	return &result, nil
+}
+
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *MemoryStore) ListLocked(now time.Time) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var locked []Entry
+	for _, e := range s.entries {
+		if e.LockedUntil.After(now) {
+			locked = append(locked, e)
+		}
+	}
+	return locked, nil
+}
+
+func (s *MemoryStore) Prune(now time.Time, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.removeExpired(now, window), nil
+}
+
+// sweep 每隔 ttl 清理一次已无意义的记录，避免内存无限增长
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+	s.lastSweep = now
+	s.removeExpired(now, s.ttl)
+}
+
+// removeExpired 丢弃窗口外的失败记录并删除已无意义的记录，返回删除的数量
+func (s *MemoryStore) removeExpired(now time.Time, window time.Duration) int {
+	removed := 0
+	for key, e := range s.entries {
+		e.prune(now, window)
+		if e.empty(now) {
+			delete(s.entries, key)
+			removed++
+		} else {
+			s.entries[key] = e
+		}
+	}
+	return removed
+}
@@ -0,0 +1,166 @@
+package throttle
+
+import (
+	"strings"
+	"time"
+)
+
+// Config 登录限流策略
+type Config struct {
+	// Window 统计失败次数的滑动窗口
+	Window time.Duration
+	// IPMaxFailures 同一IP在窗口内允许的最大失败次数
+	IPMaxFailures int
+	// BackoffAfter 同一用户名失败达到该次数后开始指数退避
+	BackoffAfter int
+	// BackoffBase 首次退避的等待时间，之后每次失败翻倍
+	BackoffBase time.Duration
+	// BackoffMax 单次退避的最长等待时间
+	BackoffMax time.Duration
+	// LockoutThreshold 同一用户名在窗口内失败达到该次数后临时锁定
+	LockoutThreshold int
+	// LockoutDuration 临时锁定的时长
+	LockoutDuration time.Duration
+}
+
+// Decision 登录前的检查结果
+type Decision struct {
+	Allowed    bool
+	Locked     bool          // 用户名处于锁定状态
+	RetryAfter time.Duration // 需要等待的时间
+}
+
+// Lockout 用户名的锁定状态
+type Lockout struct {
+	Username    string
+	LockedUntil time.Time
+}
+
+// Throttler 基于IP和用户名的登录限流器
+type Throttler struct {
+	store Store
+	cfg   Config
+	now   func() time.Time
+}
+
+// New 创建限流器
+func New(store Store, cfg Config) *Throttler {
+	return &Throttler{store: store, cfg: cfg, now: time.Now}
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+func userKey(username string) string {
+	return "user:" + strings.ToLower(username)
+}
+
+func (t *Throttler) load(key string, now time.Time) (*Entry, error) {
+	e, err := t.store.Get(key)
+	if err != nil || e == nil {
+		return &Entry{Key: key}, err
+	}
+	e.prune(now, t.cfg.Window)
+	return e, nil
+}
+
+// Check 在校验密码前调用，判断本次登录尝试是否允许
+func (t *Throttler) Check(ip, username string) (Decision, error) {
+	now := t.now()
+
+	ipEntry, err := t.load(ipKey(ip), now)
+	if err != nil {
+		return Decision{}, err
+	}
+	if n := len(ipEntry.Failures); t.cfg.IPMaxFailures > 0 && n >= t.cfg.IPMaxFailures {
+		// 等到窗口内最早的一次失败过期
+		oldest := ipEntry.Failures[n-t.cfg.IPMaxFailures]
+		return Decision{RetryAfter: oldest.Add(t.cfg.Window).Sub(now)}, nil
+	}
+
+	userEntry, err := t.load(userKey(username), now)
+	if err != nil {
+		return Decision{}, err
+	}
+	if userEntry.LockedUntil.After(now) {
+		return Decision{Locked: true, RetryAfter: userEntry.LockedUntil.Sub(now)}, nil
+	}
+	if n := len(userEntry.Failures); n > 0 {
+		if wait := t.backoff(n); wait > 0 {
+			if next := userEntry.Failures[n-1].Add(wait); next.After(now) {
+				return Decision{RetryAfter: next.Sub(now)}, nil
+			}
+		}
+	}
+
+	return Decision{Allowed: true}, nil
+}
+
+// backoff 计算连续失败 n 次后需要等待的时间
+func (t *Throttler) backoff(n int) time.Duration {
+	if t.cfg.BackoffAfter <= 0 || n < t.cfg.BackoffAfter || t.cfg.BackoffBase <= 0 {
+		return 0
+	}
+	wait := t.cfg.BackoffBase
+	for i := t.cfg.BackoffAfter; i < n; i++ {
+		wait *= 2
+		if t.cfg.BackoffMax > 0 && wait >= t.cfg.BackoffMax {
+			return t.cfg.BackoffMax
+		}
+	}
+	return wait
+}
+
+// Fail 记录一次失败的登录。读取和写回在存储中原子完成，并发的失败不会互相覆盖
+func (t *Throttler) Fail(ip, username string) error {
+	now := t.now()
+
+	_, err := t.store.Update(ipKey(ip), func(e *Entry) {
+		e.prune(now, t.cfg.Window)
+		e.Failures = append(e.Failures, now)
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = t.store.Update(userKey(username), func(e *Entry) {
+		e.prune(now, t.cfg.Window)
+		e.Failures = append(e.Failures, now)
+		if t.cfg.LockoutThreshold > 0 && len(e.Failures) >= t.cfg.LockoutThreshold {
+			e.LockedUntil = now.Add(t.cfg.LockoutDuration)
+			e.Failures = nil
+		}
+	})
+	return err
+}
+
+// Succeed 登录成功后清除用户名的失败记录
+func (t *Throttler) Succeed(username string) error {
+	return t.store.Delete(userKey(username))
+}
+
+// Clear 解除用户名的锁定并清除失败记录
+func (t *Throttler) Clear(username string) error {
+	return t.store.Delete(userKey(username))
+}
+
+// Prune 清理已过期的失败记录和锁定，返回删除的记录数量
+func (t *Throttler) Prune() (int, error) {
+	return t.store.Prune(t.now(), t.cfg.Window)
+}
+
+// Lockouts 返回当前处于锁定状态的用户名
+func (t *Throttler) Lockouts() ([]Lockout, error) {
+	entries, err := t.store.ListLocked(t.now())
+	if err != nil {
+		return nil, err
+	}
+	var lockouts []Lockout
+	for _, e := range entries {
+		if name, ok := strings.CutPrefix(e.Key, "user:"); ok {
+			lockouts = append(lockouts, Lockout{Username: name, LockedUntil: e.LockedUntil})
+		}
+	}
+	return lockouts, nil
+}
@@ -0,0 +1,165 @@
+package throttle
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newDBStore(t *testing.T) *DBStore {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "throttle.db")
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&LoginThrottle{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return NewDBStore(db)
+}
+
+func testStores(t *testing.T, run func(t *testing.T, store Store)) {
+	t.Run("memory", func(t *testing.T) { run(t, NewMemoryStore(time.Hour)) })
+	t.Run("database", func(t *testing.T) { run(t, newDBStore(t)) })
+}
+
+func TestConcurrentFailuresAreCounted(t *testing.T) {
+	testStores(t, func(t *testing.T, store Store) {
+		th := New(store, Config{Window: time.Hour, IPMaxFailures: 1000})
+
+		const n = 40
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := th.Fail("10.0.0.1", "alice"); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		for _, key := range []string{ipKey("10.0.0.1"), userKey("alice")} {
+			e, err := store.Get(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if e == nil || len(e.Failures) != n {
+				t.Errorf("%s: 记录了 %v 次失败，应当为 %d", key, e, n)
+			}
+		}
+	})
+}
+
+func TestConcurrentFailuresLockOut(t *testing.T) {
+	testStores(t, func(t *testing.T, store Store) {
+		th := New(store, Config{
+			Window:           time.Hour,
+			LockoutThreshold: 5,
+			LockoutDuration:  time.Minute,
+		})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := th.Fail("10.0.0.2", "Bob"); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		d, err := th.Check("10.0.0.2", "bob")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Allowed || !d.Locked {
+			t.Fatalf("达到阈值后应当锁定: %+v", d)
+		}
+		lockouts, err := th.Lockouts()
+		if err != nil || len(lockouts) != 1 || lockouts[0].Username != "bob" {
+			t.Fatalf("锁定列表不正确: %+v %v", lockouts, err)
+		}
+
+		if err := th.Clear("bob"); err != nil {
+			t.Fatal(err)
+		}
+		if d, _ := th.Check("10.0.0.2", "bob"); !d.Allowed {
+			t.Fatalf("解除锁定后应当允许登录: %+v", d)
+		}
+	})
+}
+
+func TestFailuresOutsideWindowExpire(t *testing.T) {
+	testStores(t, func(t *testing.T, store Store) {
+		now := time.Now()
+		th := New(store, Config{Window: time.Minute, LockoutThreshold: 3, LockoutDuration: time.Minute})
+		th.now = func() time.Time { return now }
+
+		th.Fail("10.0.0.3", "carol")
+		th.Fail("10.0.0.3", "carol")
+		// 窗口外的失败不计入锁定阈值
+		now = now.Add(2 * time.Minute)
+		th.Fail("10.0.0.3", "carol")
+
+		e, err := store.Get(userKey("carol"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(e.Failures) != 1 || !e.LockedUntil.IsZero() {
+			t.Fatalf("窗口外的失败应当被丢弃: %+v", e)
+		}
+	})
+}
+
+func TestPruneRemovesExpiredEntries(t *testing.T) {
+	testStores(t, func(t *testing.T, store Store) {
+		now := time.Now()
+		th := New(store, Config{Window: time.Minute, LockoutThreshold: 2, LockoutDuration: time.Hour})
+		th.now = func() time.Time { return now }
+
+		th.Fail("10.0.0.4", "dave")
+		th.Fail("10.0.0.4", "erin")
+		th.Fail("10.0.0.4", "erin")
+
+		// 窗口内的失败记录不应被清理
+		if n, err := th.Prune(); err != nil || n != 0 {
+			t.Fatalf("窗口内不应清理记录: n=%d err=%v", n, err)
+		}
+
+		// 窗口过后只剩仍在锁定中的 erin
+		now = now.Add(2 * time.Minute)
+		if n, err := th.Prune(); err != nil || n != 2 {
+			t.Fatalf("应当清理IP和 dave 的记录: n=%d err=%v", n, err)
+		}
+		if e, _ := store.Get(userKey("dave")); e != nil {
+			t.Fatalf("dave 的记录应当被清理: %+v", e)
+		}
+		if e, _ := store.Get(userKey("erin")); e == nil {
+			t.Fatal("锁定中的记录不应被清理")
+		}
+
+		// 锁定结束后也会被清理
+		now = now.Add(2 * time.Hour)
+		if n, err := th.Prune(); err != nil || n != 1 {
+			t.Fatalf("锁定结束后应当清理: n=%d err=%v", n, err)
+		}
+		if e, _ := store.Get(userKey("erin")); e != nil {
+			t.Fatalf("erin 的记录应当被清理: %+v", e)
+		}
+	})
+}
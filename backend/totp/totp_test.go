@@ -0,0 +1,96 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfcSecret RFC 6238 附录 B 中 SHA1 使用的密钥 "12345678901234567890"
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeRFC6238(t *testing.T) {
+	// 附录 B 为 8 位验证码，6 位验证码为其后 6 位
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, v := range vectors {
+		code, err := Code(rfcSecret, Step(time.Unix(v.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != v.code {
+			t.Errorf("T=%d: 得到 %s，期望 %s", v.unix, code, v.code)
+		}
+	}
+	// 密钥不区分大小写，允许首尾空白
+	if code, err := Code(" gezdgnbvgy3tqojqgezdgnbvgy3tqojq ", 1); err != nil || code != "287082" {
+		t.Errorf("小写密钥: %s %v", code, err)
+	}
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("无效的密钥应当返回错误")
+	}
+}
+
+func TestValidateWindow(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+	for offset := int64(-3); offset <= 3; offset++ {
+		code, err := Code(rfcSecret, current+offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		step, ok := Validate(rfcSecret, code, now)
+		if want := offset >= -Skew && offset <= Skew; ok != want {
+			t.Errorf("偏差 %d 步: 通过=%v，期望 %v", offset, ok, want)
+		}
+		if ok && step != current+offset {
+			t.Errorf("偏差 %d 步: 返回步数 %d，期望 %d", offset, step, current+offset)
+		}
+	}
+
+	if _, ok := Validate(rfcSecret, " 050 471 ", now); !ok {
+		t.Error("应当忽略验证码中的空格")
+	}
+	for _, code := range []string{"", "05047", "0504711", "abcdef"} {
+		if _, ok := Validate(rfcSecret, code, now); ok {
+			t.Errorf("%q 不应通过", code)
+		}
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := GenerateSecret()
+	if len(a) != 32 || a == b {
+		t.Errorf("密钥应为 32 个字符的随机值: %s %s", a, b)
+	}
+	if _, err := Code(a, 0); err != nil {
+		t.Errorf("生成的密钥无法使用: %v", err)
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	u, err := url.Parse(ProvisioningURI("Todo List", "alice", rfcSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Todo List:alice" {
+		t.Errorf("地址不正确: %s", u)
+	}
+	if q.Get("secret") != rfcSecret || q.Get("issuer") != "Todo List" || q.Get("digits") != "6" || q.Get("period") != "30" {
+		t.Errorf("参数不正确: %v", q)
+	}
+}
@@ -0,0 +1,11 @@
+// Package util 提供各包共用的小工具函数
+package util
+
+// Truncate 按字符截断字符串，避免超出数据库列长度
+func Truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
@@ -1,10 +1,71 @@
 import { defineStore } from 'pinia'
 import axios from 'axios'
 import { API_CONFIG } from '../config/env'
+import router from '../router'
 
 // 创建 axios 实例
 const api = axios.create(API_CONFIG)
 
+// 刷新令牌专用实例，不经过下面的拦截器
+const refreshApi = axios.create(API_CONFIG)
+
+// 进行中的刷新请求，多个请求同时遇到 401 时共用一次刷新
+let refreshing = null
+
+// 使用刷新令牌换取新的访问令牌，返回新的访问令牌
+const refreshAccessToken = () => {
+  if (!refreshing) {
+    const refreshToken = localStorage.getItem('refresh_token')
+    refreshing = (refreshToken
+      ? refreshApi.post('/auth/refresh', { refresh_token: refreshToken })
+      : Promise.reject(new Error('没有刷新令牌'))
+    )
+      .then((response) => {
+        const { token, refresh_token } = response.data
+        useAuthStore().setTokens(token, refresh_token)
+        return token
+      })
+      .finally(() => {
+        refreshing = null
+      })
+  }
+  return refreshing
+}
+
+// 为 axios 实例添加响应拦截器：访问令牌过期（401）时刷新一次并重试原请求，刷新失败则退出登录
+export const installRefreshInterceptor = (instance) => {
+  instance.interceptors.response.use(
+    (response) => response,
+    async (error) => {
+      const config = error.config
+      const url = config?.url || ''
+      if (
+        error.response?.status !== 401 ||
+        !config ||
+        config._retried ||
+        url.startsWith('/auth/login') ||
+        url.startsWith('/auth/refresh')
+      ) {
+        return Promise.reject(error)
+      }
+      config._retried = true
+
+      let token
+      try {
+        token = await refreshAccessToken()
+      } catch (refreshError) {
+        useAuthStore().logout()
+        if (router.currentRoute.value.meta.requiresAuth) {
+          router.replace('/login')
+        }
+        return Promise.reject(error)
+      }
+      config.headers.Authorization = `Bearer ${token}`
+      return instance(config)
+    }
+  )
+}
+
 // 添加请求拦截器
 api.interceptors.request.use(
   (config) => {
@@ -18,6 +79,7 @@ api.interceptors.request.use(
     return Promise.reject(error)
   }
 )
+installRefreshInterceptor(api)
 
 export const useAuthStore = defineStore('auth', {
   state: () => {
@@ -36,6 +98,7 @@ export const useAuthStore = defineStore('auth', {
     
     return {
       token: token || null,
+      refreshToken: localStorage.getItem('refresh_token') || null,
       user: user || null
     }
   },
@@ -55,14 +118,11 @@ export const useAuthStore = defineStore('auth', {
           password
         })
 
-        const { token, user } = response.data
+        const { token, refresh_token, user } = response.data
         
-        // 保存到 store
-        this.token = token
+        // 保存到 store 和 localStorage
+        this.setTokens(token, refresh_token)
         this.user = user
-        
-        // 保存到 localStorage
-        localStorage.setItem('token', token)
         localStorage.setItem('user', JSON.stringify(user))
         
         return true
@@ -97,10 +157,20 @@ export const useAuthStore = defineStore('auth', {
       }
     },
 
+    // 保存访问令牌和刷新令牌，访问令牌有效期较短，过期后由响应拦截器自动刷新
+    setTokens(token, refreshToken) {
+      this.token = token
+      this.refreshToken = refreshToken
+      localStorage.setItem('token', token)
+      localStorage.setItem('refresh_token', refreshToken)
+    },
+
     logout() {
       this.token = null
+      this.refreshToken = null
       this.user = null
       localStorage.removeItem('token')
+      localStorage.removeItem('refresh_token')
       localStorage.removeItem('user')
     }
   }
@@ -1,6 +1,7 @@
 import { defineStore } from 'pinia'
 import axios from 'axios'
 import { API_CONFIG } from '../config/env'
+import { installRefreshInterceptor } from './auth'
 
 // 创建 axios 实例
 const api = axios.create(API_CONFIG)
@@ -19,6 +20,7 @@ api.interceptors.request.use(
     return Promise.reject(error)
   }
 )
+installRefreshInterceptor(api)
 
 // API 端点
 const API_ENDPOINTS = {